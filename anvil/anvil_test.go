@@ -0,0 +1,271 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+// testChunk creates the root compound of a chunk with a single section at y=1.
+func testChunk(x, z int32) nbt.Compound {
+	blocks := make([]byte, sectionVolume)
+	add := make([]byte, sectionVolume/2)
+	data := make([]byte, sectionVolume/2)
+
+	// stone at 0,16,0
+	blocks[0] = 1
+	// wool with metadata 14 at 1,16,0, which is stored in the upper nibble
+	blocks[1] = 35
+	data[0] = 14 << 4
+	// block 256+1 at 0,17,0, of which the upper bits are stored in Add
+	blocks[256] = 1
+	add[128] = 1
+
+	biomes := make([]byte, biomeCount)
+	for i := range biomes {
+		biomes[i] = unknownBiome
+	}
+	biomes[0] = 2
+
+	return nbt.Compound{
+		"Level": nbt.Compound{
+			"xPos": x,
+			"zPos": z,
+			"Sections": nbt.List{
+				nbt.Compound{
+					"Y":      int8(1),
+					"Blocks": blocks,
+					"Add":    add,
+					"Data":   data,
+				},
+			},
+			"Biomes": biomes,
+			"TileEntities": nbt.List{
+				nbt.Compound{
+					"id":    "Sign",
+					"x":     x<<4 | 2,
+					"y":     int32(16),
+					"z":     z<<4 | 3,
+					"Text1": "hello",
+				},
+			},
+		},
+	}
+}
+
+// writeTestRegion creates a region file containing the specified chunks, indexed by their position within the region.
+func writeTestRegion(t *testing.T, chunks map[[2]int]nbt.Compound) []byte {
+	t.Helper()
+
+	out := make([]byte, headerSectors*sectorSize)
+	for pos, c := range chunks {
+		var body bytes.Buffer
+		zw := zlib.NewWriter(&body)
+		if err := nbt.Write(zw, "", c); err != nil {
+			t.Fatal(err)
+		}
+		zw.Close()
+
+		var hdr [5]byte
+		binary.BigEndian.PutUint32(hdr[:], uint32(body.Len()+1))
+		hdr[4] = compressionZlib
+
+		offset := len(out) / sectorSize
+		sector := append(hdr[:], body.Bytes()...)
+		sectors := (len(sector) + sectorSize - 1) / sectorSize
+		out = append(out, make([]byte, sectors*sectorSize)...)
+		copy(out[offset*sectorSize:], sector)
+
+		i := pos[1]*regionSize + pos[0]
+		binary.BigEndian.PutUint32(out[i*4:], uint32(offset<<8|sectors))
+	}
+	return out
+}
+
+func TestRegion(t *testing.T) {
+	data := writeTestRegion(t, map[[2]int]nbt.Compound{
+		{3, 5}: testChunk(3, 5),
+	})
+	r, err := NewRegion(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.HasChunk(3, 5) {
+		t.Error("Expected chunk 3,5 to exist")
+	}
+	if r.HasChunk(5, 3) {
+		t.Error("Expected chunk 5,3 to not exist")
+	}
+
+	c, err := r.ReadChunk(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, _ := c.Compound("Level")
+	if x, _ := level.Int("xPos"); x != 3 {
+		t.Errorf("Expected xPos 3, got %d", x)
+	}
+
+	c, err = r.ReadChunk(5, 3)
+	if err != nil || c != nil {
+		t.Errorf("Expected nil, nil for missing chunk, got %v, %v", c, err)
+	}
+}
+
+func TestRegionBadLocation(t *testing.T) {
+	data := make([]byte, headerSectors*sectorSize)
+	binary.BigEndian.PutUint32(data, 10<<8|1)
+
+	r, err := NewRegion(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ReadChunk(0, 0); err == nil {
+		t.Error("Expected error for chunk outside the file")
+	}
+}
+
+func TestConvertChunk(t *testing.T) {
+	c, err := ConvertChunk(testChunk(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		x, y, z uint8
+		want    block.Data
+	}{
+		{0, 16, 0, block.Stone.ToData()},
+		{1, 16, 0, block.ID(35).ToDataWithMetadata(14)},
+		{0, 17, 0, block.ID(257).ToData()},
+		{0, 0, 0, block.ID(0).ToData()},
+	}
+	for _, test := range tests {
+		if got := c.Block(test.x, test.y, test.z); got != test.want {
+			t.Errorf("Block at %d,%d,%d: expected %v, got %v", test.x, test.y, test.z, test.want, got)
+		}
+	}
+
+	if e := c.BlockEntity(2, 16, 3); e == nil {
+		t.Error("Expected a block entity at 2,16,3")
+	} else if _, ok := e["x"]; ok || e["Text1"] != "hello" {
+		t.Errorf("Expected the block entity data without its position, got %v", e)
+	}
+
+	if b := c.Biome(0, 0); b != biome.ID(2) {
+		t.Errorf("Expected biome 2 at 0,0, got %v", b)
+	}
+	if b := c.Biome(1, 0); b != biome.Plains {
+		t.Errorf("Expected plains for unknown biome, got %v", b)
+	}
+}
+
+func TestConvertChunkInvalid(t *testing.T) {
+	root := testChunk(0, 0)
+	level, _ := root.Compound("Level")
+	sections, _ := level.List("Sections")
+	sections[0].(nbt.Compound)["Blocks"] = make([]byte, 10)
+
+	if _, err := ConvertChunk(root); err == nil {
+		t.Error("Expected error for short Blocks array")
+	}
+}
+
+func TestReadLevel(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	err := nbt.Write(zw, "", nbt.Compound{
+		"Data": nbt.Compound{
+			"LevelName":     "test",
+			"SpawnX":        int32(10),
+			"SpawnY":        int32(64),
+			"SpawnZ":        int32(-3),
+			"Difficulty":    int8(game.DifficultyHard),
+			"generatorName": "flat",
+			"RandomSeed":    int64(1234),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	l, err := ReadLevel(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Level{
+		Name:  "test",
+		Spawn: game.Pos{X: 10.5, Y: 64, Z: -2.5},
+		Settings: game.WorldSettings{
+			Dimension:  game.DimensionOverworld,
+			Difficulty: game.DifficultyHard,
+			LevelType:  game.LevelTypeFlat,
+		},
+		Seed: 1234,
+	}
+	if *l != want {
+		t.Errorf("Expected %+v, got %+v", want, *l)
+	}
+}
+
+func TestLoadWorld(t *testing.T) {
+	dir := t.TempDir()
+	regionDir := filepath.Join(dir, "region")
+	if err := os.Mkdir(regionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	regions := map[string][]byte{
+		// chunks 0,0 and 1,0
+		"r.0.0.mca": writeTestRegion(t, map[[2]int]nbt.Compound{
+			{0, 0}: testChunk(0, 0),
+			{1, 0}: testChunk(1, 0),
+		}),
+		// chunk -1,-1
+		"r.-1.-1.mca": writeTestRegion(t, map[[2]int]nbt.Compound{
+			{31, 31}: testChunk(-1, -1),
+		}),
+		"r.0.0.mcr": nil,
+	}
+	for name, data := range regions {
+		if err := os.WriteFile(filepath.Join(regionDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := LoadWorld(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pos := range []game.ChunkPos{{X: 0, Z: 0}, {X: 1, Z: 0}, {X: -1, Z: -1}} {
+		if w.GetChunk(pos) == nil {
+			t.Errorf("Expected chunk %v to be loaded", pos)
+		}
+	}
+	if w.Spawn() != game.DefaultSpawn {
+		t.Errorf("Expected default spawn without level.dat, got %v", w.Spawn())
+	}
+
+	w, err = LoadWorld(dir, &Bounds{Min: game.ChunkPos{X: 0, Z: 0}, Max: game.ChunkPos{X: 0, Z: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.GetChunk(game.ChunkPos{X: 0, Z: 0}) == nil {
+		t.Error("Expected chunk 0,0 to be loaded")
+	}
+	if w.GetChunk(game.ChunkPos{X: 1, Z: 0}) != nil || w.GetChunk(game.ChunkPos{X: -1, Z: -1}) != nil {
+		t.Error("Expected chunks outside the bounds to be skipped")
+	}
+}
@@ -0,0 +1,20 @@
+/*
+Package anvil loads and saves worlds that are stored in the Anvil format, which is used by vanilla Minecraft 1.2 to
+1.12. This makes it possible to design a world in singleplayer and load it into a game.World.
+
+Only block IDs, metadata, biomes and block entities are loaded. Entities and light are ignored, since Mable sends every
+block as fully lit. To limit memory usage, the chunks that are loaded can be restricted using Bounds:
+	w, err := anvil.LoadWorld("lobby", &anvil.Bounds{
+		Min: game.ChunkPos{X: -4, Z: -4},
+		Max: game.ChunkPos{X: 3, Z: 3},
+	})
+
+A World can be saved using SaveWorld, which writes a snapshot of the World. The snapshot must be taken on the game
+goroutine, but it can be written from any goroutine:
+	snapshot := w.Snapshot()
+	go func() {
+		err := anvil.SaveWorld("lobby", snapshot)
+		// ...
+	}()
+*/
+package anvil
@@ -0,0 +1,93 @@
+package anvil
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+// Level contains the information from a level.dat file.
+type Level struct {
+	// Name is the name of the world.
+	Name string
+	// Spawn is the position at which players spawn, which is the center of the spawn block.
+	Spawn game.Pos
+	// Settings are the settings of the world. The dimension is always the overworld.
+	Settings game.WorldSettings
+	// Seed is the seed that was used to generate the world.
+	Seed int64
+}
+
+// LoadLevel loads a level.dat file.
+func LoadLevel(path string) (*Level, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadLevel(f)
+}
+
+// ReadLevel reads a level.dat file from r, which must still be compressed.
+func ReadLevel(r io.Reader) (*Level, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	_, root, err := nbt.Read(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := root.Compound("Data")
+	if !ok {
+		return nil, errors.New("missing Data compound")
+	}
+
+	l := &Level{
+		Settings: game.DefaultWorldSettings,
+	}
+	l.Name, _ = data.String("LevelName")
+	l.Seed, _ = data.Int("RandomSeed")
+
+	x, okX := data.Int("SpawnX")
+	y, okY := data.Int("SpawnY")
+	z, okZ := data.Int("SpawnZ")
+	if !okX || !okY || !okZ {
+		return nil, errors.New("missing spawn position")
+	}
+	l.Spawn = game.Pos{
+		X: float64(x) + 0.5,
+		Y: float64(y),
+		Z: float64(z) + 0.5,
+	}
+
+	// worlds created before 1.8 do not store the difficulty
+	if d, ok := data.Int("Difficulty"); ok && d >= int64(game.DifficultyPeaceful) && d <= int64(game.DifficultyHard) {
+		l.Settings.Difficulty = game.Difficulty(d)
+	}
+	if gen, ok := data.String("generatorName"); ok {
+		l.Settings.LevelType = levelTypeOf(gen)
+	}
+	return l, nil
+}
+
+// levelTypeOf returns the game.LevelType that corresponds to the generator name in a level.dat file.
+func levelTypeOf(generator string) game.LevelType {
+	switch strings.ToLower(generator) {
+	case "flat":
+		return game.LevelTypeFlat
+	case "largebiomes":
+		return game.LevelTypeLargeBiomes
+	case "amplified":
+		return game.LevelTypeAmplified
+	default:
+		return game.LevelTypeDefault
+	}
+}
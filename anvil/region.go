@@ -0,0 +1,195 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gitfyu/mable/nbt"
+)
+
+const (
+	// sectorSize is the size of a sector in a region file, in bytes. Chunks are stored in whole sectors.
+	sectorSize = 4096
+	// regionSize is the number of chunks along each axis of a region.
+	regionSize = 32
+	// regionChunks is the number of chunks in a region.
+	regionChunks = regionSize * regionSize
+	// headerSectors is the number of sectors used by the header, which contains the locations and timestamps.
+	headerSectors = 2
+)
+
+const (
+	compressionGzip uint8 = 1
+	compressionZlib uint8 = 2
+)
+
+var errBadLocation = errors.New("chunk location is outside the file")
+
+// Region is a region file, which stores the chunks of a 32x32 chunk area.
+type Region struct {
+	r io.ReaderAt
+	// size is the size of the file in bytes.
+	size int64
+	// locations contains the location of each chunk, indexed by z*32+x. The upper 3 bytes are the offset in sectors,
+	// the lowest byte is the number of sectors. A location of 0 means that the chunk does not exist.
+	locations [regionChunks]uint32
+	// timestamps contains the time at which each chunk was last saved, in seconds since the Unix epoch.
+	timestamps [regionChunks]uint32
+	// closer closes the file, or is nil if the Region was not opened from a file.
+	closer io.Closer
+}
+
+// OpenRegion opens a region file. The Region must be closed when it is no longer used.
+func OpenRegion(path string) (*Region, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r, err := NewRegion(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewRegion reads the header of a region that is stored in r, which contains size bytes.
+func NewRegion(r io.ReaderAt, size int64) (*Region, error) {
+	reg := &Region{
+		r:    r,
+		size: size,
+	}
+
+	// empty regions may not contain a header
+	if size == 0 {
+		return reg, nil
+	}
+
+	var header [headerSectors * sectorSize]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	for i := range reg.locations {
+		reg.locations[i] = binary.BigEndian.Uint32(header[i*4:])
+		reg.timestamps[i] = binary.BigEndian.Uint32(header[sectorSize+i*4:])
+	}
+	return reg, nil
+}
+
+// Close closes the file of the Region, if it was opened using OpenRegion.
+func (r *Region) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// HasChunk returns whether the chunk at the specified coordinates exists. The coordinates are relative to the region,
+// so they must be within the range [0,31].
+func (r *Region) HasChunk(x, z int) bool {
+	return r.locations[z*regionSize+x] != 0
+}
+
+// ReadChunk reads the root compound of the chunk at the specified coordinates, which are relative to the region. It
+// returns nil if the chunk does not exist.
+func (r *Region) ReadChunk(x, z int) (nbt.Compound, error) {
+	raw, err := r.readRaw(z*regionSize + x)
+	if raw == nil || err != nil {
+		return nil, err
+	}
+	return decompressChunk(raw)
+}
+
+// decompressChunk decodes the root compound of a chunk that is stored as returned by readRaw.
+func decompressChunk(raw []byte) (nbt.Compound, error) {
+	if len(raw) < 5 {
+		return nil, errors.New("chunk is too short")
+	}
+
+	var err error
+	data := bytes.NewReader(raw[5:])
+	var zr io.Reader
+	switch raw[4] {
+	case compressionGzip:
+		zr, err = gzip.NewReader(data)
+	case compressionZlib:
+		zr, err = zlib.NewReader(data)
+	default:
+		return nil, fmt.Errorf("unknown compression type %d", raw[4])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, c, err := nbt.Read(zr)
+	return c, err
+}
+
+// readRaw reads the chunk at the specified index, including the length and compression type that precede the
+// compressed data. It returns nil if the chunk does not exist.
+func (r *Region) readRaw(i int) ([]byte, error) {
+	loc := r.locations[i]
+	if loc == 0 {
+		return nil, nil
+	}
+
+	offset := int64(loc>>8) * sectorSize
+	sectors := int64(loc & 0xFF)
+	if offset < headerSectors*sectorSize || offset >= r.size {
+		return nil, errBadLocation
+	}
+
+	var length [4]byte
+	if _, err := r.r.ReadAt(length[:], offset); err != nil {
+		return nil, err
+	}
+	n := int64(binary.BigEndian.Uint32(length[:]))
+	if n < 1 || n > sectors*sectorSize-4 {
+		return nil, fmt.Errorf("invalid chunk length %d", n)
+	}
+
+	raw := make([]byte, 4+n)
+	if _, err := r.r.ReadAt(raw, offset); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// readSectors reads the sectors of the chunk at the specified index as they are stored, without checking their
+// contents. It is used to keep chunks that cannot be read when a region is rewritten. If the last sector is not
+// complete, because the file ends before it, only the part that exists is returned.
+func (r *Region) readSectors(i int) ([]byte, error) {
+	loc := r.locations[i]
+	offset := int64(loc>>8) * sectorSize
+	n := int64(loc&0xFF) * sectorSize
+	if offset < headerSectors*sectorSize || offset >= r.size || n == 0 {
+		return nil, errBadLocation
+	}
+	if offset+n > r.size {
+		n = r.size - offset
+	}
+
+	data := make([]byte, n)
+	if _, err := r.r.ReadAt(data, offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// regionFileName returns the name of the file that stores the region with the specified coordinates.
+func regionFileName(x, z int32) string {
+	return fmt.Sprintf("r.%d.%d.mca", x, z)
+}
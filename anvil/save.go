@@ -0,0 +1,416 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/atomicfile"
+	"github.com/gitfyu/mable/nbt"
+)
+
+const (
+	// maxChunkSectors is the maximum number of sectors of a chunk, since the sector count is stored in a single byte.
+	maxChunkSectors = 255
+	// chunkVersion is the version of the chunk format that is used since 1.2.
+	chunkVersion = 1
+	// fullBright is the value of every nibble in the light arrays of a section, since Mable sends every block as fully
+	// lit.
+	fullBright = 0xFF
+)
+
+// SaveWorld saves a snapshot of a World in dir, which is created if it does not exist. The chunks are merged into the
+// existing region files, so chunks in those files that are not part of the snapshot are kept. If level.dat exists, only
+// the spawn and settings are updated, otherwise a new level.dat is created using the name of the snapshot.
+//
+// Since the snapshot does not share data with the World, SaveWorld can be called from any goroutine. Saving the same
+// directory concurrently is not supported.
+func SaveWorld(dir string, s *game.WorldSnapshot) error {
+	regionDir := filepath.Join(dir, "region")
+	if err := os.MkdirAll(regionDir, 0755); err != nil {
+		return err
+	}
+
+	regions := make(map[game.ChunkPos]map[game.ChunkPos]*game.Chunk)
+	for pos, c := range s.Chunks {
+		rpos := game.ChunkPos{X: pos.X >> 5, Z: pos.Z >> 5}
+		if regions[rpos] == nil {
+			regions[rpos] = make(map[game.ChunkPos]*game.Chunk)
+		}
+		regions[rpos][pos] = c
+	}
+
+	now := uint32(time.Now().Unix())
+	for rpos, chunks := range regions {
+		path := filepath.Join(regionDir, regionFileName(rpos.X, rpos.Z))
+		if err := saveRegion(path, chunks, now); err != nil {
+			return fmt.Errorf("saving %s: %w", path, err)
+		}
+	}
+
+	levelPath := filepath.Join(dir, "level.dat")
+	if err := saveLevel(levelPath, s); err != nil {
+		return fmt.Errorf("saving %s: %w", levelPath, err)
+	}
+	return nil
+}
+
+// saveRegion writes chunks to the region file at path, keeping the other chunks that are already stored in it. The
+// file is rewritten with the chunks in consecutive sectors, which also removes any unused sectors. Stored chunks of
+// which the blocks, biomes and block entities did not change are kept as they are, and the others are merged into the
+// stored chunk using mergeChunk. The timestamps of the written chunks are set to now.
+func saveRegion(path string, chunks map[game.ChunkPos]*game.Chunk, now uint32) error {
+	var raw [regionChunks][]byte
+	var timestamps [regionChunks]uint32
+	if err := readRawChunks(path, &raw, &timestamps); err != nil {
+		return err
+	}
+
+	for pos, c := range chunks {
+		i := int(pos.Z&(regionSize-1))*regionSize + int(pos.X&(regionSize-1))
+		root := EncodeChunk(pos, c)
+		if raw[i] != nil {
+			// a stored chunk that cannot be decoded is replaced, since it could not have been loaded either
+			if stored, err := decompressChunk(raw[i]); err == nil {
+				if sameContents(pos, root, stored) {
+					continue
+				}
+				root = mergeChunk(root, stored)
+			}
+		}
+
+		data, err := compressChunk(root)
+		if err != nil {
+			return fmt.Errorf("chunk %d,%d: %w", pos.X, pos.Z, err)
+		}
+		raw[i] = data
+		timestamps[i] = now
+	}
+
+	return atomicfile.Write(path, func(w io.Writer) error {
+		return writeRegion(w, &raw, &timestamps)
+	})
+}
+
+// readRawChunks reads the chunks and timestamps of the region file at path, if it exists. The sectors of chunks that
+// cannot be read are copied unchanged, so saving a region never loses chunks. An error is only returned if the sectors
+// of a chunk cannot be read at all.
+func readRawChunks(path string, raw *[regionChunks][]byte, timestamps *[regionChunks]uint32) error {
+	r, err := OpenRegion(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for i := range raw {
+		data, err := r.readRaw(i)
+		if err != nil {
+			if data, err = r.readSectors(i); err != nil {
+				return fmt.Errorf("chunk %d,%d: %w", i%regionSize, i/regionSize, err)
+			}
+		}
+		raw[i] = data
+		timestamps[i] = r.timestamps[i]
+	}
+	return nil
+}
+
+// writeRegion writes a region file containing the chunks in raw, which include their length and compression type. Each
+// chunk is stored in the sectors following the previous chunk, padded to a whole number of sectors.
+func writeRegion(w io.Writer, raw *[regionChunks][]byte, timestamps *[regionChunks]uint32) error {
+	header := make([]byte, headerSectors*sectorSize)
+	offset := headerSectors
+	for i, data := range raw {
+		if data == nil {
+			continue
+		}
+
+		sectors := (len(data) + sectorSize - 1) / sectorSize
+		if sectors > maxChunkSectors {
+			return fmt.Errorf("chunk %d,%d is too large, it needs %d sectors", i%regionSize, i/regionSize, sectors)
+		}
+		binary.BigEndian.PutUint32(header[i*4:], uint32(offset<<8|sectors))
+		binary.BigEndian.PutUint32(header[sectorSize+i*4:], timestamps[i])
+		offset += sectors
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	var padding [sectorSize]byte
+	for _, data := range raw {
+		if data == nil {
+			continue
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if n := len(data) % sectorSize; n != 0 {
+			if _, err := w.Write(padding[n:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressChunk encodes the root compound of a chunk using zlib, prefixed with its length and compression type.
+func compressChunk(root nbt.Compound) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, 0, 0, compressionZlib})
+
+	zw := zlib.NewWriter(&buf)
+	if err := nbt.Write(zw, "", root); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	return data, nil
+}
+
+// EncodeChunk converts a game.Chunk to the root compound of a chunk in the Anvil format, which is the reverse of
+// ConvertChunk. Sections that only contain air are omitted. Since Mable does not calculate light, every block is stored
+// as fully lit.
+func EncodeChunk(pos game.ChunkPos, c *game.Chunk) nbt.Compound {
+	heightMap := make([]int32, biomeCount)
+	sections := nbt.List{}
+	for i := uint8(0); i < 16; i++ {
+		if !c.HasSection(i) {
+			continue
+		}
+		if s := encodeSection(c, i, heightMap); s != nil {
+			sections = append(sections, s)
+		}
+	}
+
+	biomes := make([]byte, biomeCount)
+	for i := range biomes {
+		biomes[i] = byte(c.Biome(uint8(i&15), uint8(i>>4)))
+	}
+
+	entities := nbt.List{}
+	for _, e := range c.BlockEntities() {
+		data := make(nbt.Compound, len(e.Data)+3)
+		for k, v := range e.Data {
+			data[k] = v
+		}
+		data["x"] = pos.X<<4 | int32(e.X)
+		data["y"] = int32(e.Y)
+		data["z"] = pos.Z<<4 | int32(e.Z)
+		entities = append(entities, data)
+	}
+
+	return nbt.Compound{
+		"Level": nbt.Compound{
+			"xPos":             pos.X,
+			"zPos":             pos.Z,
+			"LastUpdate":       int64(0),
+			"InhabitedTime":    int64(0),
+			"V":                int8(chunkVersion),
+			"TerrainPopulated": int8(1),
+			"LightPopulated":   int8(1),
+			"Sections":         sections,
+			"Biomes":           biomes,
+			"HeightMap":        heightMap,
+			"Entities":         nbt.List{},
+			"TileEntities":     entities,
+		},
+	}
+}
+
+// sameContents returns whether root, which is encoded by EncodeChunk, contains the same blocks, biomes and block
+// entities as stored, which is the root compound of a chunk as it is stored in a region file.
+func sameContents(pos game.ChunkPos, root, stored nbt.Compound) bool {
+	c, err := ConvertChunk(stored)
+	if err != nil {
+		return false
+	}
+	level, _ := root.Compound("Level")
+	storedLevel, _ := EncodeChunk(pos, c).Compound("Level")
+	for _, k := range []string{"Sections", "Biomes", "TileEntities"} {
+		if !reflect.DeepEqual(level[k], storedLevel[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeChunk merges root, which is encoded by EncodeChunk, into stored, which is the root compound of the same chunk
+// as it is stored in a region file. The data that Mable does not keep track of, such as entities, is kept. The light
+// of sections that are also stored is kept as well, but LightPopulated is cleared, so Minecraft calculates the light
+// again when it loads the chunk.
+func mergeChunk(root, stored nbt.Compound) nbt.Compound {
+	level, _ := root.Compound("Level")
+	storedLevel, ok := stored.Compound("Level")
+	if !ok {
+		return root
+	}
+
+	storedSections, _ := storedLevel.List("Sections")
+	storedByY := make(map[int64]nbt.Compound, len(storedSections))
+	for _, v := range storedSections {
+		if s, ok := v.(nbt.Compound); ok {
+			if y, ok := s.Int("Y"); ok {
+				storedByY[y] = s
+			}
+		}
+	}
+	sections, _ := level.List("Sections")
+	for _, v := range sections {
+		s := v.(nbt.Compound)
+		y, _ := s.Int("Y")
+		if old, ok := storedByY[y]; ok {
+			for _, k := range []string{"BlockLight", "SkyLight"} {
+				if arr, ok := old.ByteArray(k); ok && len(arr) == sectionVolume/2 {
+					s[k] = arr
+				}
+			}
+		}
+	}
+
+	merged := make(nbt.Compound, len(storedLevel))
+	for k, v := range storedLevel {
+		merged[k] = v
+	}
+	for _, k := range []string{"xPos", "zPos", "V", "Sections", "Biomes", "HeightMap", "TileEntities"} {
+		merged[k] = level[k]
+	}
+	merged["LightPopulated"] = int8(0)
+
+	out := make(nbt.Compound, len(stored))
+	for k, v := range stored {
+		out[k] = v
+	}
+	out["Level"] = merged
+	return out
+}
+
+// encodeSection converts the section at the specified index to a compound, or returns nil if it only contains air.
+// heightMap, which is indexed by z*16+x, is updated to contain one more than the height of the highest non-air block
+// in each column, so sections must be encoded from bottom to top.
+func encodeSection(c *game.Chunk, index uint8, heightMap []int32) nbt.Compound {
+	blocks := make([]byte, sectionVolume)
+	data := make([]byte, sectionVolume/2)
+	var add []byte
+	empty := true
+
+	baseY := index << 4
+	for i := 0; i < sectionVolume; i++ {
+		y := baseY | uint8(i>>8)
+		b := c.Block(uint8(i&15), y, uint8(i>>4&15))
+		if b == 0 {
+			continue
+		}
+		empty = false
+
+		id := b.Type()
+		blocks[i] = byte(id)
+		if id > math.MaxUint8 {
+			if add == nil {
+				add = make([]byte, sectionVolume/2)
+			}
+			setNibble(add, i, uint8(id>>8))
+		}
+		setNibble(data, i, b.Metadata())
+		if id != 0 {
+			heightMap[i&0xFF] = int32(y) + 1
+		}
+	}
+	if empty {
+		return nil
+	}
+
+	light := make([]byte, sectionVolume/2)
+	for i := range light {
+		light[i] = fullBright
+	}
+
+	s := nbt.Compound{
+		"Y":          int8(index),
+		"Blocks":     blocks,
+		"Data":       data,
+		"BlockLight": light,
+		"SkyLight":   light,
+	}
+	if add != nil {
+		s["Add"] = add
+	}
+	return s
+}
+
+// setNibble sets the 4-bit value at index i of a nibble array, in which the even indices use the lower 4 bits.
+func setNibble(arr []byte, i int, v uint8) {
+	if i&1 == 0 {
+		arr[i>>1] = arr[i>>1]&0xF0 | v&15
+	} else {
+		arr[i>>1] = arr[i>>1]&0x0F | v<<4
+	}
+}
+
+// saveLevel updates the spawn and settings in the level.dat file at path, or creates it if it does not exist.
+func saveLevel(path string, s *game.WorldSnapshot) error {
+	root, err := readLevelRoot(path)
+	if err != nil {
+		return err
+	}
+
+	data, ok := root.Compound("Data")
+	if !ok {
+		data = nbt.Compound{
+			"LevelName": s.Name,
+			// the version of the Anvil format
+			"version": int32(19133),
+		}
+		root["Data"] = data
+	}
+	data["SpawnX"] = int32(math.Floor(s.Spawn.X))
+	data["SpawnY"] = int32(math.Floor(s.Spawn.Y))
+	data["SpawnZ"] = int32(math.Floor(s.Spawn.Z))
+	data["Difficulty"] = int8(s.Settings.Difficulty)
+	data["generatorName"] = string(s.Settings.LevelType)
+
+	return atomicfile.Write(path, func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		if err := nbt.Write(zw, "", root); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+}
+
+// readLevelRoot reads the root compound of the level.dat file at path, or returns an empty compound if it does not
+// exist.
+func readLevelRoot(path string) (nbt.Compound, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nbt.Compound{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	_, root, err := nbt.Read(zr)
+	return root, err
+}
@@ -0,0 +1,207 @@
+package anvil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+func TestEncodeChunk(t *testing.T) {
+	c := game.NewChunk()
+	c.SetBlock(0, 16, 0, block.Stone.ToData())
+	c.SetBlock(5, 200, 9, block.ID(300).ToDataWithMetadata(7))
+	c.SetBlock(1, 1, 1, block.Stone.ToData())
+	c.SetBlock(1, 1, 1, 0)
+	c.SetBiome(3, 4, 2)
+	c.SetBlockEntity(5, 200, 9, nbt.Compound{"id": "Chest"})
+
+	root := EncodeChunk(game.ChunkPos{X: 1, Z: 2}, c)
+	level, _ := root.Compound("Level")
+	if x, _ := level.Int("xPos"); x != 1 {
+		t.Errorf("Expected xPos 1, got %d", x)
+	}
+	sections, _ := level.List("Sections")
+	if len(sections) != 2 {
+		t.Errorf("Expected the section containing only air to be omitted, got %d sections", len(sections))
+	}
+	heightMap, _ := level.IntArray("HeightMap")
+	if h := heightMap[9*16+5]; h != 201 {
+		t.Errorf("Expected height 201, got %d", h)
+	}
+
+	got, err := ConvertChunk(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pos := range [][3]uint8{{0, 16, 0}, {5, 200, 9}, {1, 1, 1}} {
+		if want, b := c.Block(pos[0], pos[1], pos[2]), got.Block(pos[0], pos[1], pos[2]); b != want {
+			t.Errorf("Block at %v: expected %v, got %v", pos, want, b)
+		}
+	}
+	entities, _ := level.List("TileEntities")
+	if e := entities[0].(nbt.Compound); e["x"] != int32(16+5) || e["z"] != int32(32+9) {
+		t.Errorf("Expected the block entity to use world coordinates, got %v", e)
+	}
+	if e := got.BlockEntity(5, 200, 9); e["id"] != "Chest" {
+		t.Errorf("Expected the chest to be converted back, got %v", e)
+	}
+	if b := got.Biome(3, 4); b != 2 {
+		t.Errorf("Expected biome 2, got %v", b)
+	}
+}
+
+func TestSaveWorld(t *testing.T) {
+	dir := t.TempDir()
+
+	w := game.NewWorld(map[game.ChunkPos]*game.Chunk{
+		{X: 0, Z: 0}:   game.NewChunk(),
+		{X: -1, Z: 40}: game.NewChunk(),
+	})
+	w.GetChunk(game.ChunkPos{X: 0, Z: 0}).SetBlock(1, 2, 3, block.Stone.ToData())
+	w.SetSpawn(game.Pos{X: 4.5, Y: 3, Z: -7.5})
+	if err := SaveWorld(dir, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	// saving a single chunk must keep the other chunks in the region
+	c := game.NewChunk()
+	c.SetBlock(0, 0, 0, block.Stone.ToData())
+	w = game.NewWorld(map[game.ChunkPos]*game.Chunk{{X: 1, Z: 0}: c})
+	w.SetSpawn(game.Pos{X: 1, Y: 2, Z: 3})
+	if err := SaveWorld(dir, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"r.0.0.mca", "r.-1.1.mca"} {
+		info, err := os.Stat(filepath.Join(dir, "region", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size()%sectorSize != 0 {
+			t.Errorf("Expected the size of %s to be a multiple of the sector size, got %d", name, info.Size())
+		}
+	}
+
+	loaded, err := LoadWorld(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := loaded.GetChunk(game.ChunkPos{X: 0, Z: 0}).Block(1, 2, 3); b != block.Stone.ToData() {
+		t.Errorf("Expected stone in chunk 0,0, got %v", b)
+	}
+	if b := loaded.GetChunk(game.ChunkPos{X: 1, Z: 0}).Block(0, 0, 0); b != block.Stone.ToData() {
+		t.Errorf("Expected stone in chunk 1,0, got %v", b)
+	}
+	if loaded.GetChunk(game.ChunkPos{X: -1, Z: 40}) == nil {
+		t.Error("Expected chunk -1,40 to be saved")
+	}
+	if want := (game.Pos{X: 1.5, Y: 2, Z: 3.5}); loaded.Spawn() != want {
+		t.Errorf("Expected spawn %v, got %v", want, loaded.Spawn())
+	}
+}
+
+func TestSaveWorld_CorruptChunk(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "region"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// chunk 2,0 has an invalid length, so it cannot be read, but saving another chunk must not remove it
+	data := writeTestRegion(t, map[[2]int]nbt.Compound{{2, 0}: testChunk(2, 0)})
+	binary.BigEndian.PutUint32(data[headerSectors*sectorSize:], 0xFFFFFF)
+	corrupt := append([]byte(nil), data[headerSectors*sectorSize:]...)
+	path := filepath.Join(dir, "region", "r.0.0.mca")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := game.NewWorld(map[game.ChunkPos]*game.Chunk{{X: 0, Z: 0}: game.NewChunk()})
+	if err := SaveWorld(dir, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := f.readSectors(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, corrupt) {
+		t.Error("Expected the sectors of the corrupt chunk to be kept unchanged")
+	}
+	if !f.HasChunk(0, 0) {
+		t.Error("Expected chunk 0,0 to be saved")
+	}
+}
+
+func TestSaveWorld_KeepsStoredData(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "region"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := map[[2]int]nbt.Compound{{0, 0}: testChunk(0, 0), {1, 0}: testChunk(1, 0)}
+	for _, c := range chunks {
+		level, _ := c.Compound("Level")
+		level["Entities"] = nbt.List{nbt.Compound{"id": "Painting", "Motive": "Kebab"}}
+	}
+	path := filepath.Join(dir, "region", "r.0.0.mca")
+	if err := os.WriteFile(path, writeTestRegion(t, chunks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, err := before.readRaw(0)
+	before.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := LoadWorld(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetChunk(game.ChunkPos{X: 1, Z: 0}).SetBlock(4, 20, 4, block.Stone.ToData())
+	if err := SaveWorld(dir, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenRegion(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if raw, err := r.readRaw(0); err != nil || !bytes.Equal(raw, unchanged) {
+		t.Errorf("Expected the unchanged chunk to be kept as it is stored, got error %v", err)
+	}
+
+	root, err := r.ReadChunk(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, _ := root.Compound("Level")
+	if entities, _ := level.List("Entities"); len(entities) != 1 {
+		t.Errorf("Expected the entities of the changed chunk to be kept, got %v", entities)
+	}
+	if v, ok := level.Int("LightPopulated"); !ok || v != 0 {
+		t.Errorf("Expected LightPopulated to be cleared, got %d", v)
+	}
+	c, err := ConvertChunk(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := c.Block(4, 20, 4); b != block.Stone.ToData() {
+		t.Errorf("Expected the changed block to be saved, got %v", b)
+	}
+}
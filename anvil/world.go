@@ -0,0 +1,234 @@
+package anvil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+const (
+	// sectionVolume is the number of blocks in a section.
+	sectionVolume = 16 * 16 * 16
+	// biomeCount is the number of biomes in a chunk, one for each column.
+	biomeCount = 16 * 16
+	// unknownBiome is stored for columns of which the biome has not been generated yet.
+	unknownBiome = 0xFF
+)
+
+// Bounds is a rectangular area of chunks. Both Min and Max are included.
+type Bounds struct {
+	Min, Max game.ChunkPos
+}
+
+// Contains returns whether the chunk at pos is within the Bounds. A nil *Bounds contains every chunk.
+func (b *Bounds) Contains(pos game.ChunkPos) bool {
+	if b == nil {
+		return true
+	}
+	return pos.X >= b.Min.X && pos.X <= b.Max.X && pos.Z >= b.Min.Z && pos.Z <= b.Max.Z
+}
+
+// containsRegion returns whether any chunk of the region at the specified region coordinates is within the Bounds.
+func (b *Bounds) containsRegion(x, z int32) bool {
+	if b == nil {
+		return true
+	}
+	return x >= b.Min.X>>5 && x <= b.Max.X>>5 && z >= b.Min.Z>>5 && z <= b.Max.Z>>5
+}
+
+// LoadWorld loads the world that is stored in dir, which contains a level.dat file and a region directory. Only the
+// chunks within bounds are loaded, or every chunk if bounds is nil. The spawn and settings of the World are taken from
+// level.dat. If level.dat does not exist, for example when loading the Nether from its DIM-1 directory,
+// game.DefaultSpawn and game.DefaultWorldSettings are used.
+func LoadWorld(dir string, bounds *Bounds) (*game.World, error) {
+	var level *Level
+	if l, err := LoadLevel(filepath.Join(dir, "level.dat")); err == nil {
+		level = l
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading level.dat: %w", err)
+	}
+
+	chunks, err := LoadChunks(filepath.Join(dir, "region"), bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	w := game.NewWorld(chunks)
+	if level != nil {
+		w.SetSpawn(level.Spawn)
+		w.SetSettings(level.Settings)
+	}
+	return w, nil
+}
+
+// LoadChunks loads the chunks within bounds from the region files in dir, or every chunk if bounds is nil.
+func LoadChunks(dir string, bounds *Bounds) (map[game.ChunkPos]*game.Chunk, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(map[game.ChunkPos]*game.Chunk)
+	for _, e := range entries {
+		var rx, rz int32
+		if n, _ := fmt.Sscanf(e.Name(), "r.%d.%d.mca", &rx, &rz); n != 2 || e.Name() != regionFileName(rx, rz) {
+			continue
+		}
+		if !bounds.containsRegion(rx, rz) {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		if err := loadRegion(path, rx, rz, bounds, chunks); err != nil {
+			return nil, err
+		}
+	}
+	return chunks, nil
+}
+
+// loadRegion loads the chunks within bounds from the region file at path into chunks.
+func loadRegion(path string, rx, rz int32, bounds *Bounds, chunks map[game.ChunkPos]*game.Chunk) error {
+	r, err := OpenRegion(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for z := 0; z < regionSize; z++ {
+		for x := 0; x < regionSize; x++ {
+			pos := game.ChunkPos{
+				X: rx<<5 | int32(x),
+				Z: rz<<5 | int32(z),
+			}
+			if !r.HasChunk(x, z) || !bounds.Contains(pos) {
+				continue
+			}
+
+			root, err := r.ReadChunk(x, z)
+			if err != nil {
+				return fmt.Errorf("reading chunk %d,%d from %s: %w", pos.X, pos.Z, path, err)
+			}
+			c, err := ConvertChunk(root)
+			if err != nil {
+				return fmt.Errorf("converting chunk %d,%d from %s: %w", pos.X, pos.Z, path, err)
+			}
+			chunks[pos] = c
+		}
+	}
+	return nil
+}
+
+// ConvertChunk converts the root compound of a chunk that is stored in the Anvil format to a game.Chunk.
+func ConvertChunk(root nbt.Compound) (*game.Chunk, error) {
+	level, ok := root.Compound("Level")
+	if !ok {
+		return nil, errors.New("missing Level compound")
+	}
+
+	c := game.NewChunk()
+	// chunks without any blocks may not have a Sections list
+	sections, _ := level.List("Sections")
+	for i, v := range sections {
+		s, ok := v.(nbt.Compound)
+		if !ok {
+			return nil, fmt.Errorf("section %d is not a compound", i)
+		}
+		if err := convertSection(c, s); err != nil {
+			return nil, fmt.Errorf("section %d: %w", i, err)
+		}
+	}
+
+	entities, _ := level.List("TileEntities")
+	for i, v := range entities {
+		e, ok := v.(nbt.Compound)
+		if !ok {
+			return nil, fmt.Errorf("block entity %d is not a compound", i)
+		}
+		if err := convertBlockEntity(c, e); err != nil {
+			return nil, fmt.Errorf("block entity %d: %w", i, err)
+		}
+	}
+
+	if biomes, ok := level.ByteArray("Biomes"); ok {
+		if len(biomes) != biomeCount {
+			return nil, fmt.Errorf("biomes contain %d bytes, expected %d", len(biomes), biomeCount)
+		}
+		for i, b := range biomes {
+			if b == unknownBiome {
+				b = uint8(biome.Plains)
+			}
+			c.SetBiome(uint8(i&15), uint8(i>>4), biome.ID(b))
+		}
+	}
+	return c, nil
+}
+
+// convertSection copies the blocks of a section to c. Blocks are stored in YZX order, with the lower 8 bits of the ID
+// in Blocks, the optional upper 4 bits in Add and the metadata in Data.
+func convertSection(c *game.Chunk, s nbt.Compound) error {
+	y, ok := s.Int("Y")
+	if !ok || y < 0 || y >= 16 {
+		return errors.New("invalid Y")
+	}
+	blocks, ok := s.ByteArray("Blocks")
+	if !ok || len(blocks) != sectionVolume {
+		return errors.New("invalid Blocks")
+	}
+	data, ok := s.ByteArray("Data")
+	if !ok || len(data) != sectionVolume/2 {
+		return errors.New("invalid Data")
+	}
+	add, ok := s.ByteArray("Add")
+	if ok && len(add) != sectionVolume/2 {
+		return errors.New("invalid Add")
+	}
+
+	baseY := uint8(y) << 4
+	for i := 0; i < sectionVolume; i++ {
+		id := block.ID(blocks[i])
+		if add != nil {
+			id |= block.ID(nibble(add, i)) << 8
+		}
+		meta := nibble(data, i)
+		if id == 0 && meta == 0 {
+			continue
+		}
+
+		c.SetBlock(uint8(i&15), baseY|uint8(i>>8), uint8(i>>4&15), id.ToDataWithMetadata(meta))
+	}
+	return nil
+}
+
+// convertBlockEntity adds a block entity to c. The world coordinates that are stored in the compound are removed, since
+// game.Chunk stores block entities by their position within the chunk.
+func convertBlockEntity(c *game.Chunk, e nbt.Compound) error {
+	x, okX := e.Int("x")
+	y, okY := e.Int("y")
+	z, okZ := e.Int("z")
+	if !okX || !okY || !okZ || y < 0 || y > 255 {
+		return errors.New("invalid position")
+	}
+
+	data := make(nbt.Compound, len(e))
+	for k, v := range e {
+		if k != "x" && k != "y" && k != "z" {
+			data[k] = v
+		}
+	}
+	c.SetBlockEntity(uint8(x&15), uint8(y), uint8(z&15), data)
+	return nil
+}
+
+// nibble returns the 4-bit value at index i of a nibble array, in which the even indices use the lower 4 bits.
+func nibble(arr []byte, i int) uint8 {
+	if i&1 == 0 {
+		return arr[i>>1] & 15
+	}
+	return arr[i>>1] >> 4
+}
@@ -3,5 +3,12 @@ package biome
 type ID uint8
 
 const (
-	Plains ID = 1
+	Ocean        ID = 0
+	Plains       ID = 1
+	Desert       ID = 2
+	ExtremeHills ID = 3
+	FrozenOcean  ID = 10
+	IcePlains    ID = 12
+	Beach        ID = 16
+	ColdBeach    ID = 26
 )
@@ -1,5 +1,7 @@
 package block
 
+import "strings"
+
 // ID represents a 12-bit block ID.
 type ID uint16
 
@@ -12,9 +14,67 @@ const (
 )
 
 const (
-	Stone ID = 1
+	Air         ID = 0
+	Stone       ID = 1
+	Grass       ID = 2
+	Dirt        ID = 3
+	Cobblestone ID = 4
+	Planks      ID = 5
+	Bedrock     ID = 7
+	Water       ID = 9
+	Lava        ID = 11
+	Sand        ID = 12
+	Gravel      ID = 13
+	Log         ID = 17
+	Leaves      ID = 18
+	Glass       ID = 20
+	Sandstone   ID = 24
+	Wool        ID = 35
+	SnowLayer   ID = 78
+	Ice         ID = 79
+	Snow        ID = 80
+	Clay        ID = 82
+	Netherrack  ID = 87
+	StoneBrick  ID = 98
+	Quartz      ID = 155
+	HardClay    ID = 172
 )
 
+// names contains the IDs of the blocks above by their name, without the minecraft: prefix.
+var names = map[string]ID{
+	"air":           Air,
+	"stone":         Stone,
+	"grass":         Grass,
+	"dirt":          Dirt,
+	"cobblestone":   Cobblestone,
+	"planks":        Planks,
+	"bedrock":       Bedrock,
+	"water":         Water,
+	"lava":          Lava,
+	"sand":          Sand,
+	"gravel":        Gravel,
+	"log":           Log,
+	"leaves":        Leaves,
+	"glass":         Glass,
+	"sandstone":     Sandstone,
+	"wool":          Wool,
+	"snow_layer":    SnowLayer,
+	"ice":           Ice,
+	"snow":          Snow,
+	"clay":          Clay,
+	"netherrack":    Netherrack,
+	"stonebrick":    StoneBrick,
+	"quartz_block":  Quartz,
+	"hardened_clay": HardClay,
+}
+
+// FromName returns the ID of a block by its name, such as "minecraft:stone" or "stone". Only the names of the blocks
+// that have a constant in this package are known.
+func FromName(name string) (ID, bool) {
+	id, ok := names[strings.TrimPrefix(name, "minecraft:")]
+	return id, ok
+}
+
 // Data encodes a block ID with metadata.
 type Data uint16
 
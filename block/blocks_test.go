@@ -21,3 +21,15 @@ func TestData_Metadata(t *testing.T) {
 		}
 	}
 }
+
+func TestFromName(t *testing.T) {
+	if id, ok := FromName("minecraft:bedrock"); !ok || id != Bedrock {
+		t.Errorf("Expected bedrock, got %d, %v", id, ok)
+	}
+	if id, ok := FromName("grass"); !ok || id != Grass {
+		t.Errorf("Expected grass, got %d, %v", id, ok)
+	}
+	if _, ok := FromName("minecraft:unknown"); ok {
+		t.Error("Expected unknown block to not exist")
+	}
+}
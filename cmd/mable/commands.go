@@ -0,0 +1,196 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/command"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/server"
+	"github.com/gitfyu/mable/log"
+)
+
+// defaultKickReason is displayed to players that are kicked without a reason.
+const defaultKickReason = "Kicked by an operator"
+
+var logLevelNames = []string{"trace", "debug", "info", "warn", "error"}
+
+// registerCommands registers the built-in commands. The stop function is called to shut down the server.
+func registerCommands(d *command.Dispatcher, g *game.Game, srv *server.Server, sv *saver, stop func()) {
+	d.Register(command.Literal("stop").Requires("mable.command.stop").Executes(func(ctx *command.Context) error {
+		reply(ctx, "Stopping the server")
+		stop()
+		return nil
+	}))
+
+	d.Register(command.Literal("list").Requires("mable.command.list").Executes(func(ctx *command.Context) error {
+		var names []string
+		for _, p := range ctx.Game().Players() {
+			names = append(names, p.Name())
+		}
+		sort.Strings(names)
+		msg := "There are " + strconv.Itoa(len(names)) + " players online"
+		if len(names) > 0 {
+			msg += ": " + strings.Join(names, ", ")
+		}
+		reply(ctx, msg)
+		return nil
+	}))
+
+	kick := func(ctx *command.Context) error {
+		p := ctx.Player("player")
+		reason := defaultKickReason
+		if ctx.Has("reason") {
+			reason = ctx.String("reason")
+		}
+		p.Kick(&chat.Msg{Text: reason})
+		reply(ctx, "Kicked "+p.Name()+": "+reason)
+		return nil
+	}
+	d.Register(command.Literal("kick").Requires("mable.command.kick").Then(
+		command.Arg("player", command.Player()).Executes(kick).Then(
+			command.Arg("reason", command.GreedyString()).Executes(kick),
+		),
+	))
+
+	d.Register(command.Literal("broadcast").Requires("mable.command.broadcast").Then(
+		command.Arg("message", command.GreedyString()).Executes(func(ctx *command.Context) error {
+			msg := &chat.Msg{
+				Text:  "[" + ctx.Sender.Name() + "] " + ctx.String("message"),
+				Color: chat.ColorPink,
+			}
+			ctx.Game().BroadcastMessage(msg, game.ChatPositionChat)
+			// players already receive the broadcast, but the console does not
+			if _, ok := ctx.Sender.(*game.Player); !ok {
+				ctx.Sender.SendMessage(msg, game.ChatPositionChat)
+			}
+			return nil
+		}),
+	))
+
+	d.Register(command.Literal("tp").Requires("mable.command.tp").Then(
+		command.Arg("target", command.Player()).Executes(func(ctx *command.Context) error {
+			p, err := senderPlayer(ctx)
+			if err != nil {
+				return err
+			}
+			teleportToPlayer(ctx, p, ctx.Player("target"))
+			return nil
+		}).Then(
+			command.Arg("destination", command.Player()).Executes(func(ctx *command.Context) error {
+				teleportToPlayer(ctx, ctx.Player("target"), ctx.Player("destination"))
+				return nil
+			}),
+			command.Arg("pos", command.Coordinates()).Executes(func(ctx *command.Context) error {
+				teleportToPos(ctx, ctx.Player("target"))
+				return nil
+			}),
+		),
+		command.Arg("pos", command.Coordinates()).Executes(func(ctx *command.Context) error {
+			p, err := senderPlayer(ctx)
+			if err != nil {
+				return err
+			}
+			teleportToPos(ctx, p)
+			return nil
+		}),
+	))
+
+	d.Register(command.Literal("world").Requires("mable.command.world").Executes(func(ctx *command.Context) error {
+		reply(ctx, "Worlds: "+strings.Join(ctx.Game().WorldNames(), ", "))
+		return nil
+	}).Then(
+		command.Arg("world", command.World(g)).Executes(func(ctx *command.Context) error {
+			p, err := senderPlayer(ctx)
+			if err != nil {
+				return err
+			}
+			moveToWorld(ctx, p, ctx.World("world"))
+			return nil
+		}).Then(
+			command.Arg("player", command.Player()).Executes(func(ctx *command.Context) error {
+				moveToWorld(ctx, ctx.Player("player"), ctx.World("world"))
+				return nil
+			}),
+		),
+	))
+
+	d.Register(command.Literal("save").Requires("mable.command.save").Executes(func(ctx *command.Context) error {
+		if !sv.enabled() {
+			return command.Errorf("Saving is disabled, since world.path is not set")
+		}
+
+		sender := ctx.Sender
+		reply(ctx, "Saving the world")
+		sv.saveAsync(ctx.Game(), ctx.Game().DefaultWorld(), func(err error, d time.Duration) {
+			if err != nil {
+				logger.Error("Failed to save the world").Err(err).Log()
+				sender.SendMessage(&chat.Msg{Text: "Failed to save the world", Color: chat.ColorRed},
+					game.ChatPositionSystem)
+				return
+			}
+			sender.SendMessage(&chat.Msg{Text: "Saved the world in " + d.Round(time.Millisecond).String()},
+				game.ChatPositionSystem)
+		})
+		return nil
+	}))
+
+	showLevel := func(ctx *command.Context) error {
+		reply(ctx, "The log level is "+levelName(srv.LogLevel().Level()))
+		return nil
+	}
+	d.Register(command.Literal("loglevel").Requires("mable.command.loglevel").Executes(showLevel).Then(
+		command.Arg("level", command.Choice(logLevelNames...)).Executes(func(ctx *command.Context) error {
+			lvl := log.LevelFromString(ctx.String("level"))
+			srv.LogLevel().Set(lvl)
+			reply(ctx, "Set the log level to "+levelName(lvl))
+			return nil
+		}),
+	))
+}
+
+// reply sends a message in the default color to the sender of a command.
+func reply(ctx *command.Context, text string) {
+	ctx.Sender.SendMessage(&chat.Msg{Text: text}, game.ChatPositionSystem)
+}
+
+// senderPlayer returns the player that sent a command, or an error if the command was sent by the console.
+func senderPlayer(ctx *command.Context) (*game.Player, error) {
+	p, ok := ctx.Sender.(*game.Player)
+	if !ok {
+		return nil, command.Errorf("You must specify which player you wish to perform this action on")
+	}
+	return p, nil
+}
+
+func teleportToPlayer(ctx *command.Context, p, dest *game.Player) {
+	if p.World() != dest.World() {
+		p.SetWorld(dest.World())
+	}
+	p.Teleport(dest.Pos())
+	reply(ctx, "Teleported "+p.Name()+" to "+dest.Name())
+}
+
+func moveToWorld(ctx *command.Context, p *game.Player, w *game.World) {
+	p.SetWorld(w)
+	reply(ctx, "Moved "+p.Name()+" to "+w.Name())
+}
+
+func teleportToPos(ctx *command.Context, p *game.Player) {
+	pos := ctx.Coordinates("pos").Resolve(p.Pos())
+	p.Teleport(pos)
+	reply(ctx, "Teleported "+p.Name()+" to "+formatCoordinate(pos.X)+", "+formatCoordinate(pos.Y)+", "+
+		formatCoordinate(pos.Z))
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// levelName returns the lowercase name of a log.Level, without padding.
+func levelName(lvl log.Level) string {
+	return strings.ToLower(strings.TrimSpace(lvl.String()))
+}
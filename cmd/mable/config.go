@@ -0,0 +1,472 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/server"
+	"github.com/gitfyu/mable/worldfile"
+	"github.com/gitfyu/mable/worldgen"
+)
+
+const (
+	// maxWorldRadius is the maximum radius of the default world in chunks.
+	maxWorldRadius = 32
+	// maxPlatformRadius is the maximum radius of the platform generator in blocks, which is the distance to the world
+	// border in vanilla.
+	maxPlatformRadius = 30000000
+)
+
+// config contains all settings of the server. It is loaded from a JSON file, of which every setting may also be
+// overridden using a command-line flag.
+type config struct {
+	Server    serverConfig    `json:"server"`
+	Game      gameConfig      `json:"game"`
+	World     worldConfig     `json:"world"`
+	Whitelist whitelistConfig `json:"whitelist"`
+}
+
+type serverConfig struct {
+	Bind                 string     `json:"bind"`
+	MaxPacketSize        int        `json:"max-packet-size"`
+	Timeout              int        `json:"timeout"`
+	LogLevel             string     `json:"log-level"`
+	OnlineMode           bool       `json:"online-mode"`
+	SessionServer        string     `json:"session-server"`
+	CompressionThreshold int        `json:"compression-threshold"`
+	BungeeCord           bool       `json:"bungeecord"`
+	ProxyProtocol        bool       `json:"proxy-protocol"`
+	ProxyTrusted         stringList `json:"proxy-trusted"`
+	MaxPlayers           int        `json:"max-players"`
+	MOTD                 string     `json:"motd"`
+	Favicon              string     `json:"favicon"`
+	FlushWindow          duration   `json:"flush-window"`
+	WriteQueueSize       int        `json:"write-queue-size"`
+	QueuePolicy          string     `json:"queue-policy"`
+	SpillBufferSize      int        `json:"spill-buffer-size"`
+}
+
+type gameConfig struct {
+	MaxJobs           int      `json:"max-jobs"`
+	TickInterval      duration `json:"tick-interval"`
+	KeepAliveInterval duration `json:"keep-alive-interval"`
+	KeepAliveTimeout  duration `json:"keep-alive-timeout"`
+	Brand             string   `json:"brand"`
+}
+
+type worldConfig struct {
+	// Path is the directory of an Anvil world, or a file ending in worldfile.Extension, to load as the default world.
+	// The world is saved there by the save command, and on shutdown if SaveOnShutdown is set. If it is empty or does
+	// not exist, a world filled with stone layers is generated instead, and saving is disabled if it is empty. The
+	// spawn of a loaded world is stored in the world itself, so Spawn is ignored.
+	Path string `json:"path"`
+	// SaveOnShutdown determines whether the world is saved to Path when the server stops. It is disabled by default,
+	// so the world is only changed on disk when an operator uses the save command.
+	SaveOnShutdown bool `json:"save-on-shutdown"`
+	// Radius is the number of chunks that the default world extends in each direction from the origin. Chunks of a
+	// loaded world outside this area are not kept in memory.
+	Radius int      `json:"radius"`
+	Spawn  game.Pos `json:"spawn"`
+	// Generator is the name of the generator that creates the chunks outside the predefined area, see generatorNames.
+	// If it is empty, those chunks do not exist.
+	Generator string `json:"generator"`
+	// FlatPreset is the superflat preset string that is used if Generator is "flat".
+	FlatPreset string `json:"flat-preset"`
+	// Seed is the seed that is used if Generator is "terrain". The same seed always generates the same terrain.
+	Seed int64 `json:"seed"`
+	// PlatformBlock, PlatformY and PlatformRadius are the block, the height and the radius in blocks of the platform
+	// that is centered at 0,0 if Generator is "platform".
+	PlatformBlock  string `json:"platform-block"`
+	PlatformY      int    `json:"platform-y"`
+	PlatformRadius int    `json:"platform-radius"`
+}
+
+type whitelistConfig struct {
+	Enabled bool       `json:"enabled"`
+	Players stringList `json:"players"`
+}
+
+// defaultConfig returns the config that is used for settings that are not in the config file or flags.
+func defaultConfig() *config {
+	return &config{
+		Server: serverConfig{
+			Bind:                 ":25565",
+			MaxPacketSize:        1 << 16,
+			Timeout:              20,
+			LogLevel:             "debug",
+			SessionServer:        "https://sessionserver.mojang.com",
+			CompressionThreshold: 256,
+			ProxyTrusted:         stringList{"127.0.0.1/32"},
+			MaxPlayers:           20,
+			MOTD:                 "A Mable server",
+			WriteQueueSize:       100,
+			QueuePolicy:          server.QueueBlock.String(),
+			SpillBufferSize:      1 << 20,
+		},
+		Game: gameConfig{
+			MaxJobs:           100,
+			TickInterval:      duration(game.DefaultTickInterval),
+			KeepAliveInterval: duration(game.DefaultKeepAliveInterval),
+			KeepAliveTimeout:  duration(game.DefaultKeepAliveTimeout),
+			Brand:             game.DefaultBrand,
+		},
+		World: worldConfig{
+			Radius:         2,
+			Spawn:          game.DefaultSpawn,
+			FlatPreset:     worldgen.DefaultFlatPreset,
+			PlatformBlock:  "minecraft:stone",
+			PlatformY:      64,
+			PlatformRadius: 16,
+		},
+	}
+}
+
+// flagSet creates a FlagSet that stores the values of the flags in c. The path of the config file is stored in path.
+func (c *config) flagSet(path *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.StringVar(path, "config", *path, "Path to a JSON config file, flags override the settings in this file")
+
+	// Server config
+	s := &c.Server
+	fs.StringVar(&s.Bind, "srv-bind", s.Bind, "address to bind to, such as :25565 or 123.123.123.123:123")
+	fs.IntVar(&s.MaxPacketSize, "srv-max-packet-size", s.MaxPacketSize, "Maximum size of a single packet, in bytes")
+	fs.IntVar(&s.Timeout, "srv-timeout", s.Timeout, "Time in seconds after which idle clients are kicked")
+	fs.StringVar(&s.LogLevel, "srv-log-level", s.LogLevel, "The minimum level that will be logged")
+	fs.BoolVar(&s.OnlineMode, "srv-online-mode", s.OnlineMode, "Authenticate players using the session server")
+	fs.StringVar(&s.SessionServer, "srv-session-server", s.SessionServer,
+		"Base URL of the session server used in online mode")
+	fs.IntVar(&s.CompressionThreshold, "srv-compression-threshold", s.CompressionThreshold,
+		"Minimum size of a packet before it is compressed, in bytes, or -1 to disable compression")
+	fs.BoolVar(&s.BungeeCord, "srv-bungeecord", s.BungeeCord, "Accept IP forwarding from a BungeeCord proxy")
+	fs.BoolVar(&s.ProxyProtocol, "srv-proxy-protocol", s.ProxyProtocol,
+		"Accept PROXY protocol headers from trusted proxies")
+	fs.IntVar(&s.MaxPlayers, "srv-max-players", s.MaxPlayers,
+		"Maximum number of players that can be online at the same time")
+	fs.StringVar(&s.MOTD, "srv-motd", s.MOTD, "Description displayed in the server list")
+	fs.StringVar(&s.Favicon, "srv-favicon", s.Favicon, "Path to a 64x64 PNG image displayed in the server list")
+	fs.Var(&s.ProxyTrusted, "srv-proxy-trusted",
+		"Comma separated list of networks from which PROXY protocol headers are accepted")
+	fs.Var(&s.FlushWindow, "srv-flush-window",
+		"Maximum time that packets are buffered before they are sent, or 0 to send them as soon as possible")
+	fs.IntVar(&s.WriteQueueSize, "srv-write-queue-size", s.WriteQueueSize,
+		"Number of packets that can be queued for a client before the queue policy is applied")
+	fs.StringVar(&s.QueuePolicy, "srv-queue-policy", s.QueuePolicy,
+		"What to do when the write queue of a client is full: block, drop, kick or spill")
+	fs.IntVar(&s.SpillBufferSize, "srv-spill-buffer-size", s.SpillBufferSize,
+		"Maximum number of bytes that can be spilled for a client with the spill queue policy")
+
+	// Game config
+	g := &c.Game
+	fs.IntVar(&g.MaxJobs, "game-max-jobs", g.MaxJobs, "Maximum number of pending jobs")
+	fs.Var(&g.TickInterval, "game-tick-interval", "How often a tick should occur")
+	fs.Var(&g.KeepAliveInterval, "game-keep-alive-interval", "How often a keep-alive is sent to players")
+	fs.Var(&g.KeepAliveTimeout, "game-keep-alive-timeout",
+		"Time after which players that do not respond to a keep-alive are kicked")
+	fs.StringVar(&g.Brand, "game-brand", g.Brand, "Server brand displayed in the debug screen")
+
+	// World config
+	fs.StringVar(&c.World.Path, "world-path", c.World.Path,
+		"Anvil world directory or "+worldfile.Extension+" file to load the default world from and to save it in")
+	fs.BoolVar(&c.World.SaveOnShutdown, "world-save-on-shutdown", c.World.SaveOnShutdown,
+		"Save the default world to its path when the server stops")
+	fs.IntVar(&c.World.Radius, "world-radius", c.World.Radius,
+		"Number of chunks that the default world extends in each direction")
+	fs.StringVar(&c.World.Generator, "world-generator", c.World.Generator,
+		"Generator for chunks outside the default world: "+strings.Join(generatorNames, ", ")+", or empty for none")
+	fs.StringVar(&c.World.FlatPreset, "world-flat-preset", c.World.FlatPreset,
+		"Superflat preset string that is used by the flat generator")
+	fs.Int64Var(&c.World.Seed, "world-seed", c.World.Seed, "Seed that is used by the terrain generator")
+	fs.StringVar(&c.World.PlatformBlock, "world-platform-block", c.World.PlatformBlock,
+		"Block of which the platform generator builds the platform, such as minecraft:stone")
+	fs.IntVar(&c.World.PlatformY, "world-platform-y", c.World.PlatformY, "Y coordinate of the platform generator")
+	fs.IntVar(&c.World.PlatformRadius, "world-platform-radius", c.World.PlatformRadius,
+		"Number of blocks that the platform generator extends in each direction from 0,0")
+
+	// Whitelist
+	fs.BoolVar(&c.Whitelist.Enabled, "whitelist", c.Whitelist.Enabled, "Only allow whitelisted players to join")
+	fs.Var(&c.Whitelist.Players, "whitelist-players", "Comma separated list of whitelisted players")
+	return fs
+}
+
+// loadConfig loads the config from the file specified by the -config flag, if any, and applies the flags in args to
+// it. Settings that are specified in neither are set to their default values. The returned config is validated.
+func loadConfig(args []string) (*config, string, error) {
+	var path string
+
+	// the flags are parsed twice, first to find the path of the config file, and then to override its settings
+	c := defaultConfig()
+	if err := c.flagSet(&path).Parse(args); err != nil {
+		return nil, "", err
+	}
+
+	if path != "" {
+		c = defaultConfig()
+		if err := c.readFile(path); err != nil {
+			return nil, "", err
+		}
+		if err := c.flagSet(&path).Parse(args); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, "", err
+	}
+	return c, path, nil
+}
+
+// readFile reads the config file, overwriting the settings that it contains. Unknown settings are rejected.
+func (c *config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// validationError contains all problems that were found while validating a config.
+type validationError []string
+
+func (e validationError) Error() string {
+	return "invalid config: " + strings.Join(e, "; ")
+}
+
+// validate checks whether all settings have valid values.
+func (c *config) validate() error {
+	var errs validationError
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	s := &c.Server
+	check(s.Bind != "", "server.bind must not be empty")
+	check(s.MaxPacketSize > 0, "server.max-packet-size must be positive")
+	check(s.Timeout > 0, "server.timeout must be positive")
+	check(isLogLevel(s.LogLevel), "server.log-level must be one of %s, got %q", strings.Join(logLevelNames, ", "),
+		s.LogLevel)
+	if s.OnlineMode {
+		u, err := url.Parse(s.SessionServer)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"server.session-server must be an http(s) URL, got %q", s.SessionServer)
+	}
+	check(s.CompressionThreshold >= -1, "server.compression-threshold must be -1 or higher")
+	if s.ProxyProtocol {
+		for _, n := range s.ProxyTrusted {
+			_, _, err := net.ParseCIDR(n)
+			check(err == nil, "server.proxy-trusted contains an invalid network %q", n)
+		}
+	}
+	check(s.MaxPlayers >= 0, "server.max-players must not be negative")
+	check(s.FlushWindow >= 0, "server.flush-window must not be negative")
+	check(s.WriteQueueSize > 0, "server.write-queue-size must be positive")
+	_, err := server.ParseQueuePolicy(s.QueuePolicy)
+	check(err == nil, "server.queue-policy must be one of block, drop, kick or spill, got %q", s.QueuePolicy)
+	check(s.SpillBufferSize > 0, "server.spill-buffer-size must be positive")
+
+	g := &c.Game
+	check(g.MaxJobs > 0, "game.max-jobs must be positive")
+	check(g.TickInterval > 0, "game.tick-interval must be positive")
+	check(g.KeepAliveInterval > 0, "game.keep-alive-interval must be positive")
+	check(g.KeepAliveTimeout > g.KeepAliveInterval, "game.keep-alive-timeout must be longer than "+
+		"game.keep-alive-interval")
+	check(g.Brand != "", "game.brand must not be empty")
+
+	check(c.World.Radius >= 0 && c.World.Radius <= maxWorldRadius, "world.radius must be between 0 and %d",
+		maxWorldRadius)
+	if c.World.Generator == "platform" {
+		check(c.World.PlatformY >= 0 && c.World.PlatformY <= 255, "world.platform-y must be between 0 and 255")
+		check(c.World.PlatformRadius >= 0 && c.World.PlatformRadius <= maxPlatformRadius,
+			"world.platform-radius must be between 0 and %d", maxPlatformRadius)
+	}
+	if _, err := c.World.generator(); err != nil {
+		key := "world.generator"
+		switch c.World.Generator {
+		case "flat":
+			key = "world.flat-preset"
+		case "platform":
+			key = "world.platform-block"
+		}
+		check(false, "%s is invalid: %v", key, err)
+	}
+
+	for _, name := range c.Whitelist.Players {
+		check(isValidUsername(name), "whitelist.players contains an invalid username %q", name)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// serverConfig converts the settings to a server.Config. The config must be valid.
+func (c *config) serverConfig() server.Config {
+	s := &c.Server
+	policy, _ := server.ParseQueuePolicy(s.QueuePolicy)
+	return server.Config{
+		Addr:                 s.Bind,
+		MaxPacketSize:        s.MaxPacketSize,
+		Timeout:              s.Timeout,
+		LogLevel:             s.LogLevel,
+		OnlineMode:           s.OnlineMode,
+		SessionServer:        s.SessionServer,
+		CompressionThreshold: s.CompressionThreshold,
+		BungeeCord:           s.BungeeCord,
+		ProxyProtocol:        s.ProxyProtocol,
+		ProxyTrusted:         s.ProxyTrusted,
+		MaxPlayers:           s.MaxPlayers,
+		MOTD:                 &chat.Msg{Text: s.MOTD},
+		Favicon:              s.Favicon,
+		FlushWindow:          time.Duration(s.FlushWindow),
+		WriteQueueSize:       s.WriteQueueSize,
+		QueuePolicy:          policy,
+		SpillBufferSize:      s.SpillBufferSize,
+	}
+}
+
+// gameConfig converts the settings to a game.Config.
+func (c *config) gameConfig() game.Config {
+	g := &c.Game
+	return game.Config{
+		MaxJobs:           g.MaxJobs,
+		TickInterval:      time.Duration(g.TickInterval),
+		KeepAliveInterval: time.Duration(g.KeepAliveInterval),
+		KeepAliveTimeout:  time.Duration(g.KeepAliveTimeout),
+		Brand:             g.Brand,
+	}
+}
+
+// requiresRestart returns whether the differences between c and other contain settings that cannot be changed while
+// the server is running.
+func (c *config) requiresRestart(other *config) bool {
+	a, b := *c, *other
+	for _, cfg := range []*config{&a, &b} {
+		cfg.Server.MOTD = ""
+		cfg.Server.LogLevel = ""
+		cfg.Server.MaxPlayers = 0
+		cfg.Whitelist = whitelistConfig{}
+	}
+	return !jsonEqual(a, b)
+}
+
+// jsonEqual returns whether a and b have the same JSON encoding.
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// generatorNames contains the names of the generators that can be used as world.generator.
+var generatorNames = []string{"flat", "platform", "terrain", "void"}
+
+// generator creates the ChunkGenerator that is specified by Generator, or returns nil if it is empty.
+func (c *worldConfig) generator() (game.ChunkGenerator, error) {
+	switch c.Generator {
+	case "":
+		return nil, nil
+	case "flat":
+		return worldgen.ParseFlatPreset(c.FlatPreset)
+	case "platform":
+		id, ok := block.FromName(c.PlatformBlock)
+		if !ok {
+			return nil, fmt.Errorf("unknown block %q", c.PlatformBlock)
+		}
+		return worldgen.NewPlatform(id.ToData(), uint8(c.PlatformY), int32(c.PlatformRadius)), nil
+	case "terrain":
+		return worldgen.NewTerrain(c.Seed), nil
+	case "void":
+		return worldgen.Void{}, nil
+	default:
+		return nil, fmt.Errorf("unknown generator %q, expected one of %s", c.Generator,
+			strings.Join(generatorNames, ", "))
+	}
+}
+
+func isLogLevel(s string) bool {
+	for _, name := range logLevelNames {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidUsername returns whether s is a valid Minecraft username: 1 to 16 letters, digits or underscores.
+func isValidUsername(s string) bool {
+	if len(s) == 0 || len(s) > 16 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// duration is a time.Duration that is stored as a string such as "10ms", both in JSON and flags.
+type duration time.Duration
+
+func (d duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("duration must be a string such as \"10ms\"")
+	}
+	return d.Set(s)
+}
+
+// stringList is a list of strings, which is a comma separated list when used as a flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
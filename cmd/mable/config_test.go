@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/internal/server"
+	"github.com/gitfyu/mable/worldgen"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "mable.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, path, err := loadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "" {
+		t.Errorf("Expected no config file, got %s", path)
+	}
+	if !jsonEqual(cfg, defaultConfig()) {
+		t.Error("Expected the default config")
+	}
+}
+
+func TestLoadConfig_FileAndFlags(t *testing.T) {
+	path := writeTestConfig(t, `{
+		"server": {"motd": "From file", "max-players": 5, "queue-policy": "spill"},
+		"game": {"tick-interval": "40ms"},
+		"whitelist": {"enabled": true, "players": ["Alice"]}
+	}`)
+
+	cfg, _, err := loadConfig([]string{"-config", path, "-srv-max-players", "10", "-game-keep-alive-timeout", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.MOTD != "From file" {
+		t.Errorf("Expected the MOTD from the file, got %q", cfg.Server.MOTD)
+	}
+	if cfg.Server.MaxPlayers != 10 {
+		t.Errorf("Expected the flag to override max players, got %d", cfg.Server.MaxPlayers)
+	}
+	if cfg.Server.Timeout != 20 {
+		t.Errorf("Expected the default timeout, got %d", cfg.Server.Timeout)
+	}
+	if d := cfg.gameConfig().TickInterval; d != 40*time.Millisecond {
+		t.Errorf("Expected a tick interval of 40ms, got %s", d)
+	}
+	if d := cfg.gameConfig().KeepAliveTimeout; d != time.Minute {
+		t.Errorf("Expected a keep-alive timeout of 1m, got %s", d)
+	}
+	if p := cfg.serverConfig().QueuePolicy; p != server.QueueSpill {
+		t.Errorf("Expected the spill queue policy, got %s", p)
+	}
+	if !cfg.Whitelist.Enabled || len(cfg.Whitelist.Players) != 1 {
+		t.Errorf("Expected the whitelist from the file, got %+v", cfg.Whitelist)
+	}
+}
+
+func TestLoadConfig_TickIntervalFlag(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"-game-tick-interval", "25ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cfg.gameConfig().TickInterval; d != 25*time.Millisecond {
+		t.Errorf("Expected a tick interval of 25ms, got %s", d)
+	}
+}
+
+func TestLoadConfig_TerrainGenerator(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"-world-generator", "terrain", "-world-seed", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := cfg.World.generator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gen.(*worldgen.Terrain); !ok {
+		t.Errorf("Expected a terrain generator, got %T", gen)
+	}
+	if cfg.World.Seed != 5 {
+		t.Errorf("Expected seed 5, got %d", cfg.World.Seed)
+	}
+}
+
+func TestLoadConfig_SaveOnShutdown(t *testing.T) {
+	if defaultConfig().World.SaveOnShutdown {
+		t.Error("Expected saving on shutdown to be disabled by default")
+	}
+	cfg, _, err := loadConfig([]string{"-world-path", "world", "-world-save-on-shutdown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.World.SaveOnShutdown {
+		t.Error("Expected saving on shutdown to be enabled by the flag")
+	}
+}
+
+func TestLoadConfig_PlatformGenerator(t *testing.T) {
+	cfg, _, err := loadConfig([]string{"-world-generator", "platform", "-world-platform-block", "glass",
+		"-world-platform-y", "100", "-world-platform-radius", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := cfg.World.generator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &worldgen.Platform{Block: block.Glass.ToData(), Y: 100, MinX: -3, MinZ: -3, MaxX: 3, MaxZ: 3}
+	if p, ok := gen.(*worldgen.Platform); !ok || *p != *want {
+		t.Errorf("Expected platform %+v, got %+v", want, gen)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		content string
+		expect  []string
+	}{
+		{`{"server": {"unknown": 1}}`, []string{`unknown field "unknown"`}},
+		{`{"game": {"tick-interval": 10}}`, []string{"duration must be a string"}},
+		{
+			`{"server": {"timeout": 0, "log-level": "verbose", "queue-policy": "wait"}, "world": {"radius": 100}}`,
+			[]string{"server.timeout", "server.log-level", "server.queue-policy", "world.radius"},
+		},
+		{`{"game": {"keep-alive-interval": "1m", "keep-alive-timeout": "30s"}}`, []string{"game.keep-alive-timeout"}},
+		{`{"whitelist": {"players": ["not valid"]}}`, []string{"whitelist.players"}},
+		{`{"world": {"generator": "noise"}}`, []string{"world.generator"}},
+		{`{"world": {"generator": "flat", "flat-preset": "3;minecraft:unknown"}}`, []string{"world.flat-preset"}},
+		{
+			`{"world": {"generator": "platform", "platform-block": "x", "platform-y": 256, "platform-radius": -1}}`,
+			[]string{"world.platform-block", "world.platform-y", "world.platform-radius"},
+		},
+	}
+
+	for _, test := range tests {
+		path := writeTestConfig(t, test.content)
+		_, _, err := loadConfig([]string{"-config", path})
+		if err == nil {
+			t.Errorf("Expected an error for %s", test.content)
+			continue
+		}
+		for _, s := range test.expect {
+			if !strings.Contains(err.Error(), s) {
+				t.Errorf("Expected error %q to contain %q", err, s)
+			}
+		}
+	}
+}
+
+func TestConfig_requiresRestart(t *testing.T) {
+	a := defaultConfig()
+	b := defaultConfig()
+	b.Server.MOTD = "Changed"
+	b.Server.MaxPlayers = 100
+	b.Server.LogLevel = "error"
+	b.Whitelist.Enabled = true
+	if a.requiresRestart(b) {
+		t.Error("Expected reloadable settings not to require a restart")
+	}
+
+	b.Game.MaxJobs = 1
+	if !a.requiresRestart(b) {
+		t.Error("Expected other settings to require a restart")
+	}
+}
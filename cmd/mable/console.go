@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/command"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/log"
+)
+
+// console is the command.Sender for commands typed into the standard input of the server. Its output is written
+// using a log.Logger, so that it does not interleave with log messages.
+type console struct {
+	logger log.Logger
+}
+
+// Name implements command.Sender.Name.
+func (c *console) Name() string {
+	return "CONSOLE"
+}
+
+// SendMessage implements command.Sender.SendMessage.
+func (c *console) SendMessage(msg *chat.Msg, _ game.ChatPosition) {
+	c.logger.Info(msg.String()).Log()
+}
+
+// HasPermission implements command.Sender.HasPermission. The console has every permission.
+func (c *console) HasPermission(string) bool {
+	return true
+}
+
+// run reads command lines from r and executes them on the goroutine that called game.Game.Run, until r is closed.
+func (c *console) run(r io.Reader, g *game.Game, d *command.Dispatcher) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimPrefix(strings.TrimSpace(s.Text()), "/")
+		if line == "" {
+			continue
+		}
+
+		g.Schedule(func() {
+			d.Execute(c, line)
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		c.logger.Warn("Failed to read console input").Err(err).Log()
+	}
+}
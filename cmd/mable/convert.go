@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitfyu/mable/anvil"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/worldfile"
+)
+
+// convertUsage is displayed when the arguments of the convert subcommand are invalid.
+const convertUsage = "Usage: %s convert [-bounds minX,minZ,maxX,maxZ] <anvil world directory> <output file>\n\n" +
+	"Converts an Anvil world to Mable's compact world format. " +
+	"The bounds are in chunk coordinates and are inclusive.\n\n"
+
+// runConvert runs the convert subcommand, which converts an Anvil world to the format of the worldfile package.
+func runConvert(args []string) error {
+	var bounds boundsFlag
+	fs := flag.NewFlagSet(os.Args[0]+" convert", flag.ContinueOnError)
+	fs.Var(&bounds, "bounds", "Only convert the chunks within these bounds, such as -4,-4,3,3")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), convertUsage, os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	start := time.Now()
+	w, err := anvil.LoadWorld(src, bounds.b)
+	if err != nil {
+		return err
+	}
+	snapshot := w.Snapshot()
+	if len(snapshot.Chunks) == 0 {
+		return errors.New("the world does not contain any chunks within the bounds")
+	}
+	if err := worldfile.Save(dst, snapshot); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return err
+	}
+	logger.Info("Converted world").
+		Str("src", src).
+		Str("dst", dst).
+		Int("chunks", int64(len(snapshot.Chunks))).
+		Int("bytes", info.Size()).
+		Stringer("time", time.Since(start).Round(time.Millisecond)).
+		Log()
+	return nil
+}
+
+// boundsFlag is a flag.Value that parses anvil.Bounds in the format minX,minZ,maxX,maxZ.
+type boundsFlag struct {
+	// b contains the parsed bounds, or nil if the flag was not set.
+	b *anvil.Bounds
+}
+
+func (f *boundsFlag) String() string {
+	if f.b == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d,%d,%d,%d", f.b.Min.X, f.b.Min.Z, f.b.Max.X, f.b.Max.Z)
+}
+
+func (f *boundsFlag) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return errors.New("expected minX,minZ,maxX,maxZ")
+	}
+
+	var v [4]int32
+	for i, p := range parts {
+		n, err := strconv.ParseInt(strings.TrimSpace(p), 10, 32)
+		if err != nil {
+			return err
+		}
+		v[i] = int32(n)
+	}
+	if v[0] > v[2] || v[1] > v[3] {
+		return errors.New("the minimum must not be larger than the maximum")
+	}
+
+	f.b = &anvil.Bounds{
+		Min: game.ChunkPos{X: v[0], Z: v[1]},
+		Max: game.ChunkPos{X: v[2], Z: v[3]},
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gitfyu/mable/game"
+)
+
+func TestBoundsFlag(t *testing.T) {
+	var f boundsFlag
+	if err := f.Set("-4, -2,3,5"); err != nil {
+		t.Fatal(err)
+	}
+	if f.b.Min != (game.ChunkPos{X: -4, Z: -2}) || f.b.Max != (game.ChunkPos{X: 3, Z: 5}) {
+		t.Errorf("Expected -4,-2 to 3,5, got %s", f.String())
+	}
+
+	for _, s := range []string{"1,2,3", "a,0,0,0", "1,0,0,0"} {
+		if err := f.Set(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
@@ -8,29 +8,46 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/gitfyu/mable/anvil"
 	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/command"
 	"github.com/gitfyu/mable/game"
 	"github.com/gitfyu/mable/internal/server"
 	"github.com/gitfyu/mable/log"
+	"github.com/gitfyu/mable/worldfile"
 )
 
-var (
-	srvConf  server.Config
-	gameConf game.Config
-
-	defaultWorld *game.World
+var logger = log.Logger{
+	Name: "MAIN",
+}
 
-	logger = log.Logger{
-		Name: "MAIN",
+// loadDefaultWorld loads the world at cfg.Path, or creates a world using createDefaultWorld if it is not set or if it
+// does not exist yet. Paths ending in worldfile.Extension use the compact world format, other paths are Anvil worlds.
+func loadDefaultWorld(cfg worldConfig) (*game.World, error) {
+	if cfg.Path == "" {
+		return createDefaultWorld(cfg), nil
+	}
+	if _, err := os.Stat(cfg.Path); errors.Is(err, os.ErrNotExist) {
+		return createDefaultWorld(cfg), nil
+	}
+	if isWorldFile(cfg.Path) {
+		return worldfile.Load(cfg.Path)
 	}
-)
 
-func createDefaultWorld() *game.World {
+	r := int32(cfg.Radius)
+	return anvil.LoadWorld(cfg.Path, &anvil.Bounds{
+		Min: game.ChunkPos{X: -r, Z: -r},
+		Max: game.ChunkPos{X: r, Z: r},
+	})
+}
+
+func createDefaultWorld(cfg worldConfig) *game.World {
+	r := int32(cfg.Radius)
 	chunks := make(map[game.ChunkPos]*game.Chunk)
-	for x := int32(-2); x <= 2; x++ {
-		for z := int32(-2); z <= 2; z++ {
+	for x := -r; x <= r; x++ {
+		for z := -r; z <= r; z++ {
 			c := game.NewChunk()
 			for dx := uint8(0); dx < 16; dx++ {
 				for dz := uint8(0); dz < 16; dz++ {
@@ -43,53 +60,96 @@ func createDefaultWorld() *game.World {
 			chunks[game.ChunkPos{X: x, Z: z}] = c
 		}
 	}
-	return game.NewWorld(chunks)
-}
 
-func init() {
-	// Server config
-	flag.StringVar(&srvConf.Addr, "srv-bind", ":25565", "address to bind to, such as :25565 or 123.123.123.123:123")
-	flag.IntVar(&srvConf.MaxPacketSize, "srv-max-packet-size", 1<<16, "Maximum size of a single packet, in bytes")
-	flag.IntVar(&srvConf.Timeout, "srv-timeout", 20, "Time in seconds after which idle clients are kicked")
-	flag.StringVar(&srvConf.LogLevel, "srv-log-level", "debug", "The minimum level that will be logged")
+	w := game.NewWorld(chunks)
+	w.SetSpawn(cfg.Spawn)
+	return w
+}
 
-	// Game config
-	flag.IntVar(&gameConf.MaxJobs, "game-max-jobs", 100, "Maximum number of pending jobs")
-	tickIntervalStr := flag.String("game-tick-interval", "10ms", "How often a tick should occur")
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		if err := runConvert(os.Args[2:]); errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		} else if err != nil {
+			logger.Error("Failed to convert world").Err(err).Log()
+			os.Exit(1)
+		}
+		return
+	}
 
-	var err error
-	gameConf.TickInterval, err = time.ParseDuration(*tickIntervalStr)
+	cfg, _, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		panic("invalid tick interval value")
+		logger.Error("Failed to load config").Err(err).Log()
+		os.Exit(2)
 	}
 
-	flag.Parse()
-
-	defaultWorld = createDefaultWorld()
-}
-
-func main() {
-	game := game.NewGame([]*game.World{defaultWorld}, gameConf)
-	defer game.Close()
-
-	srv, err := server.NewServer(srvConf, game)
+	w, err := loadDefaultWorld(cfg.World)
+	if err != nil {
+		logger.Error("Failed to load world").Err(err).Str("path", cfg.World.Path).Log()
+		os.Exit(-1)
+	}
+	// the config has been validated, so creating the generator cannot fail
+	gen, _ := cfg.World.generator()
+	w.SetGenerator(gen, 0)
+	g := game.NewGame([]*game.World{w}, cfg.gameConfig())
+	defer g.Close()
+
+	wl := &whitelist{}
+	wl.set(cfg.Whitelist)
+	srvConf := cfg.serverConfig()
+	srvConf.LoginHandler = wl.handleLogin
+
+	srv, err := server.NewServer(srvConf, g)
 	if err != nil {
 		logger.Error("Failed to start").Err(err).Log()
 		os.Exit(-1)
 	}
+	logger.Level = srv.LogLevel()
 
 	logger.Info("Server started").Log()
 
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	stop := func() {
+		select {
+		case ch <- syscall.SIGINT:
+		default:
+		}
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	// the reloaded config is only used by this goroutine, the rest of main keeps using the initial config
+	go func(cfg *config) {
+		for range reload {
+			cfg = reloadConfig(cfg, srv, wl)
+		}
+	}(cfg)
+
+	sv := &saver{path: cfg.World.Path}
+	saveOnShutdown := cfg.World.SaveOnShutdown
+	d := command.NewDispatcher(g)
+	registerCommands(d, g, srv, sv, stop)
+	g.SetCommandHandler(d)
 
 	go func() {
-		if err := game.Run(); err != nil {
+		if err := g.Run(); err != nil {
 			logger.Error("Game execution failed").Err(err).Log()
-			ch <- syscall.SIGINT
+			stop()
 		}
 	}()
 
+	// the console does not share the log level, since its output should always be visible
+	con := &console{
+		logger: log.Logger{
+			Name: "CONSOLE",
+		},
+	}
+	go con.run(os.Stdin, g, d)
+
 	go func() {
 		<-ch
 		logger.Info("Shutting down").Log()
@@ -99,4 +159,32 @@ func main() {
 	if err := srv.ListenAndServe(); !errors.Is(err, net.ErrClosed) {
 		logger.Error("Server execution failed").Err(err).Log()
 	}
+
+	if sv.enabled() && saveOnShutdown {
+		logger.Info("Saving the world").Str("path", sv.path).Log()
+		if err := sv.save(g); err != nil {
+			logger.Error("Failed to save the world").Err(err).Log()
+		}
+	}
+}
+
+// reloadConfig loads the config again and applies the settings that can be changed while the server is running. It
+// returns the new config, or the old one if the new config is invalid.
+func reloadConfig(old *config, srv *server.Server, wl *whitelist) *config {
+	cfg, path, err := loadConfig(os.Args[1:])
+	if err != nil {
+		logger.Error("Failed to reload config").Err(err).Log()
+		return old
+	}
+
+	srv.SetMOTD(&chat.Msg{Text: cfg.Server.MOTD})
+	srv.SetMaxPlayers(cfg.Server.MaxPlayers)
+	srv.LogLevel().Set(log.LevelFromString(cfg.Server.LogLevel))
+	wl.set(cfg.Whitelist)
+
+	logger.Info("Reloaded config").Str("path", path).Log()
+	if old.requiresRestart(cfg) {
+		logger.Warn("Some changed settings will only be applied after a restart").Log()
+	}
+	return cfg
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gitfyu/mable/anvil"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/worldfile"
+)
+
+// saver saves the default world in the Anvil format, or in the compact world format if the path ends in
+// worldfile.Extension. Snapshots are taken on the game goroutine, but they are written from a different goroutine, so
+// saving does not block the game.
+type saver struct {
+	// path is the directory or file in which the world is saved, or empty if saving is disabled.
+	path string
+	// mu ensures that only one snapshot is written at a time, since the region files would be corrupted otherwise.
+	mu sync.Mutex
+}
+
+// enabled returns whether a directory has been configured to save the world in.
+func (s *saver) enabled() bool {
+	return s.path != ""
+}
+
+// saveAsync takes a snapshot of w and writes it in a new goroutine, after which done is scheduled with the result and
+// the time it took. This function may only be called from the goroutine that called Game.Run.
+func (s *saver) saveAsync(g *game.Game, w *game.World, done func(err error, d time.Duration)) {
+	start := time.Now()
+	snapshot := w.Snapshot()
+	go func() {
+		err := s.write(snapshot)
+		d := time.Since(start)
+		g.Schedule(func() {
+			done(err, d)
+		})
+	}()
+}
+
+// save takes a snapshot of the default World on the game goroutine and writes it, waiting until it has been written.
+// This function must not be called from the goroutine that called Game.Run. It returns an error if the game is closed
+// before the snapshot has been taken.
+func (s *saver) save(g *game.Game) error {
+	ch := make(chan *game.WorldSnapshot, 1)
+	g.Schedule(func() {
+		ch <- g.DefaultWorld().Snapshot()
+	})
+	select {
+	case snapshot := <-ch:
+		return s.write(snapshot)
+	case <-g.Done():
+		return errors.New("the game was closed before the world could be saved")
+	}
+}
+
+// write writes a snapshot to the path of the saver. This function may be called concurrently.
+func (s *saver) write(snapshot *game.WorldSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if isWorldFile(s.path) {
+		return worldfile.Save(s.path, snapshot)
+	}
+	return anvil.SaveWorld(s.path, snapshot)
+}
+
+// isWorldFile returns whether the world at path uses the compact world format instead of the Anvil format.
+func isWorldFile(path string) bool {
+	return strings.HasSuffix(path, worldfile.Extension)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/login"
+)
+
+var msgNotWhitelisted = &chat.Msg{Text: "You are not white-listed on this server!"}
+
+// whitelist restricts which players may join the server. It can be updated while the server is running.
+type whitelist struct {
+	mu      sync.RWMutex
+	enabled bool
+	// names contains the lowercase names of the whitelisted players.
+	names map[string]struct{}
+}
+
+// set replaces the whitelist with the settings from cfg. This function may be called concurrently.
+func (w *whitelist) set(cfg whitelistConfig) {
+	names := make(map[string]struct{}, len(cfg.Players))
+	for _, name := range cfg.Players {
+		names[strings.ToLower(name)] = struct{}{}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.enabled = cfg.Enabled
+	w.names = names
+}
+
+// handleLogin implements login.Handler.
+func (w *whitelist) handleLogin(req *login.Request) *chat.Msg {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.enabled {
+		return nil
+	}
+	if _, ok := w.names[strings.ToLower(req.Name)]; !ok {
+		return msgNotWhitelisted
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package command
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gitfyu/mable/game"
+)
+
+// Argument parses a typed argument of a command.
+type Argument interface {
+	// Parse parses the argument from the start of words. It returns the value and the number of words that were
+	// used. If the words are not valid, an *Error should be returned.
+	Parse(ctx *Context, words []string) (interface{}, int, error)
+	// Complete returns suggestions for the last word, which may be incomplete. The words start at the first word of
+	// the argument. If the last word is not part of the argument, nil should be returned.
+	Complete(ctx *Context, words []string) []string
+	// Usage returns how the argument is displayed in usage messages, given its name.
+	Usage(name string) string
+}
+
+// singleWord implements Argument.Usage and Argument.Complete for arguments that consist of a single word and do not
+// have suggestions.
+type singleWord struct{}
+
+func (singleWord) Complete(*Context, []string) []string {
+	return nil
+}
+
+func (singleWord) Usage(name string) string {
+	return "<" + name + ">"
+}
+
+type intArg struct {
+	singleWord
+	min, max int
+}
+
+// Int creates an Argument for an integer in the range [min,max].
+func Int(min, max int) Argument {
+	return intArg{
+		min: min,
+		max: max,
+	}
+}
+
+func (a intArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+
+	v, err := strconv.Atoi(words[0])
+	if err != nil {
+		return nil, 0, Errorf("'%s' is not a valid number", words[0])
+	}
+	if v < a.min || v > a.max {
+		return nil, 0, Errorf("The number you have entered (%d) must be between %d and %d", v, a.min, a.max)
+	}
+	return v, 1, nil
+}
+
+type floatArg struct {
+	singleWord
+	min, max float64
+}
+
+// Float creates an Argument for a floating point number in the range [min,max].
+func Float(min, max float64) Argument {
+	return floatArg{
+		min: min,
+		max: max,
+	}
+}
+
+func (a floatArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+
+	v, err := strconv.ParseFloat(words[0], 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, 0, Errorf("'%s' is not a valid number", words[0])
+	}
+	if v < a.min || v > a.max {
+		return nil, 0, Errorf("The number you have entered (%g) must be between %g and %g", v, a.min, a.max)
+	}
+	return v, 1, nil
+}
+
+type wordArg struct {
+	singleWord
+}
+
+// Word creates an Argument for a single word.
+func Word() Argument {
+	return wordArg{}
+}
+
+func (wordArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+	return words[0], 1, nil
+}
+
+type choiceArg struct {
+	singleWord
+	choices []string
+}
+
+// Choice creates an Argument for a word that must be one of the specified choices, such as the name of a log level.
+// Choices are case-insensitive, the value is the choice as it was specified here.
+func Choice(choices ...string) Argument {
+	return choiceArg{
+		choices: choices,
+	}
+}
+
+func (a choiceArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+	for _, c := range a.choices {
+		if strings.EqualFold(c, words[0]) {
+			return c, 1, nil
+		}
+	}
+	return nil, 0, Errorf("'%s' must be one of: %s", words[0], strings.Join(a.choices, ", "))
+}
+
+func (a choiceArg) Complete(_ *Context, words []string) []string {
+	if len(words) != 1 {
+		return nil
+	}
+
+	var matches []string
+	for _, c := range a.choices {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(words[0])) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+type greedyStringArg struct{}
+
+// GreedyString creates an Argument that consumes all remaining words, such as the message of a /broadcast command.
+// It must not have any children.
+func GreedyString() Argument {
+	return greedyStringArg{}
+}
+
+func (greedyStringArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+	return strings.Join(words, " "), len(words), nil
+}
+
+func (greedyStringArg) Complete(*Context, []string) []string {
+	return nil
+}
+
+func (greedyStringArg) Usage(name string) string {
+	return "<" + name + "...>"
+}
+
+type playerArg struct {
+	singleWord
+}
+
+// Player creates an Argument for the name of an online player. Names are case-insensitive.
+func Player() Argument {
+	return playerArg{}
+}
+
+func (playerArg) Parse(ctx *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+
+	for _, p := range ctx.game.Players() {
+		if strings.EqualFold(p.Name(), words[0]) {
+			return p, 1, nil
+		}
+	}
+	return nil, 0, Errorf("That player cannot be found")
+}
+
+func (playerArg) Complete(ctx *Context, words []string) []string {
+	if len(words) != 1 {
+		return nil
+	}
+	return completePlayerNames(ctx.game, words[0])
+}
+
+// completePlayerNames returns the names of all players that start with prefix, ignoring case.
+func completePlayerNames(g *game.Game, prefix string) []string {
+	var names []string
+	prefix = strings.ToLower(prefix)
+	for _, p := range g.Players() {
+		if strings.HasPrefix(strings.ToLower(p.Name()), prefix) {
+			names = append(names, p.Name())
+		}
+	}
+	return names
+}
+
+// WorldProvider provides the worlds that can be selected using a World argument.
+type WorldProvider interface {
+	// World returns the world with the specified name, or nil if it does not exist.
+	World(name string) *game.World
+	// WorldNames returns the names of all worlds.
+	WorldNames() []string
+}
+
+// game.Game is the WorldProvider of the worlds that are registered in it.
+var _ WorldProvider = (*game.Game)(nil)
+
+type worldArg struct {
+	singleWord
+	worlds WorldProvider
+}
+
+// World creates an Argument for the name of a world.
+func World(worlds WorldProvider) Argument {
+	return worldArg{
+		worlds: worlds,
+	}
+}
+
+func (a worldArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) == 0 {
+		return nil, 0, errIncomplete
+	}
+
+	w := a.worlds.World(words[0])
+	if w == nil {
+		return nil, 0, Errorf("That world cannot be found")
+	}
+	return w, 1, nil
+}
+
+func (a worldArg) Complete(_ *Context, words []string) []string {
+	if len(words) != 1 {
+		return nil
+	}
+
+	var names []string
+	for _, name := range a.worlds.WorldNames() {
+		if strings.HasPrefix(name, words[0]) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CoordinatesValue is the value of a Coordinates argument. Each coordinate may be relative, in which case it is an
+// offset from the position of the sender.
+type CoordinatesValue struct {
+	X, Y, Z          float64
+	RelX, RelY, RelZ bool
+}
+
+// Resolve returns the position described by the coordinates, relative to base. The rotation of base is kept.
+func (c CoordinatesValue) Resolve(base game.Pos) game.Pos {
+	pos := base
+	pos.X = resolveCoordinate(c.X, c.RelX, base.X)
+	pos.Y = resolveCoordinate(c.Y, c.RelY, base.Y)
+	pos.Z = resolveCoordinate(c.Z, c.RelZ, base.Z)
+	return pos
+}
+
+func resolveCoordinate(v float64, relative bool, base float64) float64 {
+	if relative {
+		return base + v
+	}
+	return v
+}
+
+type coordinatesArg struct{}
+
+// Coordinates creates an Argument for x, y and z coordinates. A coordinate can be made relative using a tilde, for
+// example "~ ~5 ~" is 5 blocks above the sender.
+func Coordinates() Argument {
+	return coordinatesArg{}
+}
+
+func (coordinatesArg) Parse(_ *Context, words []string) (interface{}, int, error) {
+	if len(words) < 3 {
+		return nil, 0, errIncomplete
+	}
+
+	var c CoordinatesValue
+	var err error
+	if c.X, c.RelX, err = parseCoordinate(words[0]); err != nil {
+		return nil, 0, err
+	}
+	if c.Y, c.RelY, err = parseCoordinate(words[1]); err != nil {
+		return nil, 0, err
+	}
+	if c.Z, c.RelZ, err = parseCoordinate(words[2]); err != nil {
+		return nil, 0, err
+	}
+	return c, 3, nil
+}
+
+func (coordinatesArg) Complete(_ *Context, words []string) []string {
+	if len(words) > 3 || words[len(words)-1] != "" {
+		return nil
+	}
+	return []string{"~"}
+}
+
+func (coordinatesArg) Usage(string) string {
+	return "<x> <y> <z>"
+}
+
+// parseCoordinate parses a single coordinate, which is relative if it starts with a tilde.
+func parseCoordinate(s string) (float64, bool, error) {
+	relative := strings.HasPrefix(s, "~")
+	if relative {
+		s = s[1:]
+		if s == "" {
+			return 0, true, nil
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false, Errorf("'%s' is not a valid coordinate", s)
+	}
+	return v, relative, nil
+}
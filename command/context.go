@@ -0,0 +1,56 @@
+package command
+
+import (
+	"github.com/gitfyu/mable/game"
+)
+
+// Context contains the state of a command that is being executed or completed.
+type Context struct {
+	// Sender is the player or console that sent the command.
+	Sender Sender
+	game   *game.Game
+	args   map[string]interface{}
+}
+
+// Game returns the Game in which the command is executed.
+func (c *Context) Game() *game.Game {
+	return c.game
+}
+
+// Has returns whether an argument with the specified name was parsed. This can be used to check for optional
+// arguments, when multiple paths in the tree share the same Handler.
+func (c *Context) Has(name string) bool {
+	_, ok := c.args[name]
+	return ok
+}
+
+// Int returns the value of an argument created using Int. It panics if the argument does not exist.
+func (c *Context) Int(name string) int {
+	return c.args[name].(int)
+}
+
+// Float returns the value of an argument created using Float. It panics if the argument does not exist.
+func (c *Context) Float(name string) float64 {
+	return c.args[name].(float64)
+}
+
+// String returns the value of an argument created using Word, Choice or GreedyString. It panics if the argument does
+// not exist.
+func (c *Context) String(name string) string {
+	return c.args[name].(string)
+}
+
+// Player returns the value of an argument created using Player. It panics if the argument does not exist.
+func (c *Context) Player(name string) *game.Player {
+	return c.args[name].(*game.Player)
+}
+
+// World returns the value of an argument created using World. It panics if the argument does not exist.
+func (c *Context) World(name string) *game.World {
+	return c.args[name].(*game.World)
+}
+
+// Coordinates returns the value of an argument created using Coordinates. It panics if the argument does not exist.
+func (c *Context) Coordinates(name string) CoordinatesValue {
+	return c.args[name].(CoordinatesValue)
+}
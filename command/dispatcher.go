@@ -0,0 +1,186 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+)
+
+// errIncomplete indicates that the input ended at a node that cannot be executed, or that an argument is missing. It
+// is turned into a usage message.
+var errIncomplete = errors.New("incomplete command")
+
+var (
+	msgNoPermission  = &chat.Msg{Text: "You do not have permission to use this command.", Color: chat.ColorRed}
+	msgInternalError = &chat.Msg{Text: "An error occurred while executing this command.", Color: chat.ColorRed}
+)
+
+// Sender is a player or the console, which can execute commands.
+type Sender interface {
+	// Name returns the name of the sender.
+	Name() string
+	// SendMessage displays a message to the sender.
+	SendMessage(msg *chat.Msg, pos game.ChatPosition)
+	// HasPermission returns whether the sender has the specified permission.
+	HasPermission(perm string) bool
+}
+
+// Error is an error that is displayed to the sender of a command, such as an invalid argument.
+type Error struct {
+	Msg *chat.Msg
+}
+
+// Errorf creates an *Error with a red message, formatted using fmt.Sprintf.
+func Errorf(format string, args ...interface{}) *Error {
+	return &Error{
+		Msg: &chat.Msg{
+			Text:  fmt.Sprintf(format, args...),
+			Color: chat.ColorRed,
+		},
+	}
+}
+
+// Error implements error.Error.
+func (e *Error) Error() string {
+	return e.Msg.String()
+}
+
+// Dispatcher executes and completes the commands that have been registered to it. It implements
+// game.CommandHandler. The functions of a Dispatcher may only be called from the goroutine that called game.Game.Run,
+// or before Run is called.
+type Dispatcher struct {
+	game     *game.Game
+	commands map[string]*Node
+}
+
+// NewDispatcher creates a Dispatcher without any commands, which executes commands in the specified Game. Use
+// game.Game.SetCommandHandler to use it for commands sent by players.
+func NewDispatcher(g *game.Game) *Dispatcher {
+	return &Dispatcher{
+		game:     g,
+		commands: make(map[string]*Node),
+	}
+}
+
+// Register adds a command, replacing any existing command with the same name. The node must be a literal.
+func (d *Dispatcher) Register(n *Node) {
+	if n.arg != nil {
+		panic("command must start with a literal")
+	}
+	d.commands[n.name] = n
+}
+
+// Commands returns the names of all registered commands that the sender may use, in alphabetical order.
+func (d *Dispatcher) Commands(s Sender) []string {
+	var names []string
+	for name, n := range d.commands {
+		if n.canUse(s) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute parses and executes a command line, without the leading slash. Any errors are displayed to the sender.
+func (d *Dispatcher) Execute(s Sender, line string) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return
+	}
+
+	root, ok := d.commands[strings.ToLower(words[0])]
+	if !ok {
+		s.SendMessage(game.MsgUnknownCommand, game.ChatPositionSystem)
+		return
+	}
+	if !root.canUse(s) {
+		s.SendMessage(msgNoPermission, game.ChatPositionSystem)
+		return
+	}
+
+	ctx := d.newContext(s)
+	h, err := root.parse(ctx, words[1:])
+	if err == nil {
+		err = h(ctx)
+	}
+	if err == nil {
+		return
+	}
+
+	var cmdErr *Error
+	switch {
+	case err == errIncomplete:
+		s.SendMessage(d.usage(s, root), game.ChatPositionSystem)
+	case errors.As(err, &cmdErr):
+		s.SendMessage(cmdErr.Msg, game.ChatPositionSystem)
+	default:
+		s.SendMessage(msgInternalError, game.ChatPositionSystem)
+	}
+}
+
+// Complete returns suggestions for the last word of a partial command line, without the leading slash.
+func (d *Dispatcher) Complete(s Sender, line string) []string {
+	words := strings.Split(line, " ")
+	if len(words) == 1 {
+		var names []string
+		for _, name := range d.Commands(s) {
+			if strings.HasPrefix(name, strings.ToLower(words[0])) {
+				names = append(names, "/"+name)
+			}
+		}
+		return names
+	}
+
+	root, ok := d.commands[strings.ToLower(words[0])]
+	if !ok || !root.canUse(s) {
+		return nil
+	}
+
+	suggestions := root.complete(d.newContext(s), removeEmpty(words[1:]))
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+// ExecuteCommand implements game.CommandHandler.ExecuteCommand.
+func (d *Dispatcher) ExecuteCommand(p *game.Player, line string) {
+	d.Execute(p, line)
+}
+
+// CompleteCommand implements game.CommandHandler.CompleteCommand.
+func (d *Dispatcher) CompleteCommand(p *game.Player, line string) []string {
+	return d.Complete(p, line)
+}
+
+func (d *Dispatcher) newContext(s Sender) *Context {
+	return &Context{
+		Sender: s,
+		game:   d.game,
+		args:   make(map[string]interface{}),
+	}
+}
+
+// usage creates a message describing how a command can be used.
+func (d *Dispatcher) usage(s Sender, root *Node) *chat.Msg {
+	usages := root.appendUsages(nil, s, "/")
+	return &chat.Msg{
+		Text:  "Usage: " + strings.Join(usages, " OR "),
+		Color: chat.ColorRed,
+	}
+}
+
+// removeEmpty removes empty words caused by repeated spaces, except for the last word, which is the word that is
+// being completed.
+func removeEmpty(words []string) []string {
+	res := words[:0]
+	for i, w := range words {
+		if w != "" || i == len(words)-1 {
+			res = append(res, w)
+		}
+	}
+	return res
+}
@@ -0,0 +1,173 @@
+package command
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	"github.com/google/uuid"
+)
+
+// testSender is a Sender that records the messages sent to it.
+type testSender struct {
+	perms    map[string]bool
+	messages []string
+}
+
+func (s *testSender) Name() string {
+	return "tester"
+}
+
+func (s *testSender) SendMessage(msg *chat.Msg, _ game.ChatPosition) {
+	s.messages = append(s.messages, msg.String())
+}
+
+func (s *testSender) HasPermission(perm string) bool {
+	return s.perms[perm]
+}
+
+func (s *testSender) lastMessage() string {
+	if len(s.messages) == 0 {
+		return ""
+	}
+	return s.messages[len(s.messages)-1]
+}
+
+// nopConn is a game.PlayerConn that discards everything.
+type nopConn struct{}
+
+func (nopConn) WritePacket(packet.Outbound) {}
+func (nopConn) Disconnect(*chat.Msg)        {}
+func (nopConn) Version() protocol.Version   { return protocol.Version1_8 }
+func (nopConn) RemoteAddr() net.Addr        { return &net.TCPAddr{} }
+
+func newTestDispatcher(players ...string) *Dispatcher {
+	g := game.NewGame([]*game.World{game.NewWorld(nil)}, game.Config{})
+	for _, name := range players {
+		g.AddPlayer(game.NewPlayer(name, uuid.New(), nil, nopConn{}))
+	}
+	return NewDispatcher(g)
+}
+
+func TestDispatcher_Execute(t *testing.T) {
+	d := newTestDispatcher("Alice")
+
+	var got []interface{}
+	d.Register(Literal("test").Then(
+		Literal("int").Then(
+			Arg("n", Int(0, 10)).Executes(func(ctx *Context) error {
+				got = append(got, ctx.Int("n"))
+				return nil
+			}),
+		),
+		Literal("player").Then(
+			Arg("target", Player()).Executes(func(ctx *Context) error {
+				got = append(got, ctx.Player("target").Name())
+				return nil
+			}),
+		),
+		Literal("pos").Then(
+			Arg("pos", Coordinates()).Executes(func(ctx *Context) error {
+				got = append(got, ctx.Coordinates("pos").Resolve(game.Pos{X: 1, Y: 2, Z: 3}))
+				return nil
+			}),
+		),
+		Literal("say").Then(
+			Arg("msg", GreedyString()).Executes(func(ctx *Context) error {
+				got = append(got, ctx.String("msg"))
+				return nil
+			}),
+		),
+	))
+
+	s := &testSender{}
+	d.Execute(s, "test int 5")
+	d.Execute(s, "TEST player alice")
+	d.Execute(s, "test pos ~ 10 ~-1.5")
+	d.Execute(s, "test say hello  world")
+
+	expect := []interface{}{5, "Alice", game.Pos{X: 1, Y: 10, Z: 1.5}, "hello world"}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("Expected %v, got %v", expect, got)
+	}
+	if len(s.messages) != 0 {
+		t.Errorf("Unexpected messages %v", s.messages)
+	}
+}
+
+func TestDispatcher_Execute_Errors(t *testing.T) {
+	d := newTestDispatcher()
+	d.Register(Literal("test").Then(
+		Arg("n", Int(0, 10)).Executes(func(*Context) error {
+			return nil
+		}),
+	))
+	d.Register(Literal("secret").Requires("test.secret").Executes(func(*Context) error {
+		return nil
+	}))
+	d.Register(Literal("level").Then(
+		Arg("level", Choice("debug", "info")).Executes(func(*Context) error {
+			return nil
+		}),
+	))
+
+	tests := []struct {
+		line, expect string
+	}{
+		{"unknown", game.MsgUnknownCommand.Text},
+		{"secret", msgNoPermission.Text},
+		{"test", "Usage: /test <n>"},
+		{"test abc", "'abc' is not a valid number"},
+		{"test 11", "The number you have entered (11) must be between 0 and 10"},
+		{"level x", "'x' must be one of: debug, info"},
+	}
+	for _, test := range tests {
+		s := &testSender{}
+		d.Execute(s, test.line)
+		if msg := s.lastMessage(); msg != test.expect {
+			t.Errorf("%s: expected %q, got %q", test.line, test.expect, msg)
+		}
+	}
+}
+
+func TestDispatcher_Complete(t *testing.T) {
+	d := newTestDispatcher("Alice", "Bob")
+	d.Register(Literal("tp").Then(
+		Arg("target", Player()).Executes(func(*Context) error {
+			return nil
+		}),
+		Arg("pos", Coordinates()).Executes(func(*Context) error {
+			return nil
+		}),
+	))
+	d.Register(Literal("time").Then(
+		Literal("set").Then(Arg("time", Int(0, 24000))),
+		Literal("add").Then(Arg("time", Int(0, 24000))),
+	))
+	d.Register(Literal("secret").Requires("test.secret"))
+	d.Register(Literal("level").Then(Arg("level", Choice("debug", "info", "Warn"))))
+
+	tests := []struct {
+		line   string
+		expect []string
+	}{
+		{"level w", []string{"Warn"}},
+		{"t", []string{"/time", "/tp"}},
+		{"s", nil},
+		{"tp a", []string{"Alice"}},
+		{"tp ", []string{"Alice", "Bob", "~"}},
+		{"tp 1 ", []string{"~"}},
+		{"tp 1 2 3 ", nil},
+		{"time s", []string{"set"}},
+	}
+	for _, test := range tests {
+		got := d.Complete(&testSender{}, test.line)
+		if !reflect.DeepEqual(test.expect, got) {
+			t.Errorf("%q: expected %v, got %v", test.line, test.expect, got)
+		}
+	}
+}
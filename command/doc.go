@@ -0,0 +1,21 @@
+/*
+Package command implements slash commands, such as /spawn, using a tree of nodes.
+
+Each command is a tree, in which a node is either a literal word or a typed argument. A node that has a Handler can
+be executed, which happens when the input ends at that node. Arguments are parsed before the Handler runs, so it can
+simply retrieve them from the Context:
+	d := NewDispatcher(g)
+	d.Register(Literal("tp").Requires("mable.tp").Then(
+		Arg("target", Player()).Executes(func(ctx *Context) error {
+			// teleport to the target
+		}),
+		Arg("pos", Coordinates()).Executes(func(ctx *Context) error {
+			// teleport to ctx.Coordinates("pos")
+		}),
+	))
+	g.SetCommandHandler(d)
+
+The same tree is used to generate usage messages and tab completions. Commands are executed on the goroutine that
+called game.Game.Run, regardless of whether they were sent by a player or the console.
+*/
+package command
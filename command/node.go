@@ -0,0 +1,163 @@
+package command
+
+import (
+	"strings"
+)
+
+// Handler executes a command. If it returns an *Error, its message is displayed to the sender.
+type Handler func(ctx *Context) error
+
+// Node is a node in a command tree, which is either a literal word or an argument.
+type Node struct {
+	// name is the literal word, or the name of the argument.
+	name       string
+	arg        Argument
+	permission string
+	children   []*Node
+	handler    Handler
+}
+
+// Literal creates a Node that matches a single word. Literals are case-insensitive.
+func Literal(word string) *Node {
+	return &Node{
+		name: strings.ToLower(word),
+	}
+}
+
+// Arg creates a Node that parses an argument. The name is used to retrieve the value from the Context, and is
+// displayed in usage messages.
+func Arg(name string, a Argument) *Node {
+	return &Node{
+		name: name,
+		arg:  a,
+	}
+}
+
+// Then adds child nodes, which may follow this node. Children are tried in the order in which they were added.
+func (n *Node) Then(children ...*Node) *Node {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// Executes sets the Handler that runs when the input ends at this node.
+func (n *Node) Executes(h Handler) *Node {
+	n.handler = h
+	return n
+}
+
+// Requires sets the permission that is required to use this node and its children.
+func (n *Node) Requires(permission string) *Node {
+	n.permission = permission
+	return n
+}
+
+// usage returns how the node is displayed in usage messages.
+func (n *Node) usage() string {
+	if n.arg == nil {
+		return n.name
+	}
+	return n.arg.Usage(n.name)
+}
+
+// canUse returns whether the sender has permission to use the node.
+func (n *Node) canUse(s Sender) bool {
+	return n.permission == "" || s.HasPermission(n.permission)
+}
+
+// parse matches the remaining words against the children of the node, and returns the handler of the node at which
+// the input ends. The values of arguments are stored in ctx.
+func (n *Node) parse(ctx *Context, words []string) (Handler, error) {
+	if len(words) == 0 {
+		if n.handler == nil {
+			return nil, errIncomplete
+		}
+		return n.handler, nil
+	}
+
+	var argErr error
+	for _, child := range n.children {
+		if !child.canUse(ctx.Sender) {
+			continue
+		}
+
+		if child.arg == nil {
+			if strings.EqualFold(words[0], child.name) {
+				return child.parse(ctx, words[1:])
+			}
+			continue
+		}
+
+		v, used, err := child.arg.Parse(ctx, words)
+		if err != nil {
+			if argErr == nil {
+				argErr = err
+			}
+			continue
+		}
+
+		ctx.args[child.name] = v
+		h, err := child.parse(ctx, words[used:])
+		if err == nil {
+			return h, nil
+		}
+		delete(ctx.args, child.name)
+		if argErr == nil && err != errIncomplete {
+			argErr = err
+		}
+	}
+
+	if argErr != nil {
+		return nil, argErr
+	}
+	return nil, errIncomplete
+}
+
+// complete returns suggestions for the last word, which may be incomplete.
+func (n *Node) complete(ctx *Context, words []string) []string {
+	var suggestions []string
+	last := words[len(words)-1]
+
+	for _, child := range n.children {
+		if !child.canUse(ctx.Sender) {
+			continue
+		}
+
+		if child.arg == nil {
+			if len(words) == 1 {
+				if strings.HasPrefix(child.name, strings.ToLower(last)) {
+					suggestions = append(suggestions, child.name)
+				}
+			} else if strings.EqualFold(words[0], child.name) {
+				suggestions = append(suggestions, child.complete(ctx, words[1:])...)
+			}
+			continue
+		}
+
+		suggestions = append(suggestions, child.arg.Complete(ctx, words)...)
+
+		// only the complete words are parsed, the last word belongs to this argument or one of the children
+		if v, used, err := child.arg.Parse(ctx, words[:len(words)-1]); err == nil {
+			ctx.args[child.name] = v
+			suggestions = append(suggestions, child.complete(ctx, words[used:])...)
+			delete(ctx.args, child.name)
+		}
+	}
+
+	return suggestions
+}
+
+// appendUsages appends the usage of every executable path that starts at this node to usages. The prefix is the
+// usage of the path leading up to this node.
+func (n *Node) appendUsages(usages []string, s Sender, prefix string) []string {
+	prefix += n.usage()
+	if n.handler != nil {
+		usages = append(usages, prefix)
+	}
+
+	for _, child := range n.children {
+		if child.canUse(s) {
+			usages = child.appendUsages(usages, s, prefix+" ")
+		}
+	}
+	return usages
+}
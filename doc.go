@@ -0,0 +1,20 @@
+/*
+Package mable can be used to embed a Mable server in another program. It wraps the networking layer and the Game, so
+that a custom server can be built without depending on any internal packages.
+
+A Server is configured using functional options:
+	srv := mable.New(
+		mable.WithAddr(":25565"),
+		mable.WithMOTD(&chat.Msg{Text: "My server"}),
+		mable.OnJoin(func(p *game.Player) {
+			p.SendMessage(&chat.Msg{Text: "Welcome!"}, game.ChatPositionSystem)
+		}),
+	)
+	if err := srv.Start(); err != nil {
+		// handle the error
+	}
+	defer srv.Shutdown(context.Background())
+
+Commands can be registered using the Dispatcher returned by Server.Commands, before or after the Server is started.
+*/
+package mable
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"sort"
+
+	"github.com/gitfyu/mable/nbt"
+)
+
+// BlockEntity contains the additional data of a block, such as the text of a sign or the items in a chest. Block
+// entities are stored in their Chunk so they can be loaded and saved, but they are not sent to clients.
+type BlockEntity struct {
+	// X, Y and Z are the coordinates of the block, relative to the Chunk.
+	X, Y, Z uint8
+	// Data contains the NBT data of the block entity, such as its id, without the coordinates. It must not be modified
+	// after the BlockEntity has been added to a Chunk, since it is shared with snapshots of the Chunk.
+	Data nbt.Compound
+}
+
+// BlockEntity returns the data of the block entity at the specified position, or nil if there is none. Note that the
+// coordinates are relative to the chunk, not world coordinates.
+func (c *Chunk) BlockEntity(x, y, z uint8) nbt.Compound {
+	return c.blockEntities[blockEntityKey(x, y, z)]
+}
+
+// SetBlockEntity changes the data of the block entity at the specified position, or removes it if data is nil. Note
+// that the coordinates are relative to the chunk, not world coordinates. The coordinates must all be within the range
+// [0,15], except for y which can be in the range [0,255]. The data must not be modified afterwards.
+func (c *Chunk) SetBlockEntity(x, y, z uint8, data nbt.Compound) {
+	k := blockEntityKey(x, y, z)
+	if data == nil {
+		delete(c.blockEntities, k)
+		return
+	}
+
+	if c.blockEntities == nil {
+		c.blockEntities = make(map[uint16]nbt.Compound)
+	}
+	c.blockEntities[k] = data
+}
+
+// BlockEntities returns the block entities in the Chunk, ordered by their position in the same way as the blocks of a
+// section, which is YZX order.
+func (c *Chunk) BlockEntities() []BlockEntity {
+	keys := make([]int, 0, len(c.blockEntities))
+	for k := range c.blockEntities {
+		keys = append(keys, int(k))
+	}
+	sort.Ints(keys)
+
+	entities := make([]BlockEntity, len(keys))
+	for i, k := range keys {
+		entities[i] = BlockEntity{
+			X:    uint8(k & 15),
+			Y:    uint8(k >> 8),
+			Z:    uint8(k >> 4 & 15),
+			Data: c.blockEntities[uint16(k)],
+		}
+	}
+	return entities
+}
+
+// blockEntityKey returns the key of the block entity at the specified position in Chunk.blockEntities.
+func blockEntityKey(x, y, z uint8) uint16 {
+	return uint16(y)<<8 | uint16(z&15)<<4 | uint16(x&15)
+}
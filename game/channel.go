@@ -0,0 +1,147 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gitfyu/mable/internal/protocol"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+const (
+	// ChannelRegister is used to announce the channels that a client or server listens on.
+	ChannelRegister = "REGISTER"
+	// ChannelUnregister is used to announce the channels that a client or server no longer listens on.
+	ChannelUnregister = "UNREGISTER"
+	// ChannelBrand is used to exchange the name of the client and server software.
+	ChannelBrand = "MC|Brand"
+
+	// DefaultBrand is the server brand used if Config.Brand is not set.
+	DefaultBrand = "Mable"
+)
+
+// ChannelHandler handles a plugin message that a player sent on a channel. It runs on the goroutine that called
+// Game.Run.
+type ChannelHandler func(p *Player, data []byte)
+
+// RegisterChannel registers a handler for plugin messages on the specified channel, replacing any previous handler.
+// Players are told that the server listens on the channel, so that client mods know they can use it. This function
+// may only be called before Run, or from the goroutine that called Run.
+func (g *Game) RegisterChannel(channel string, h ChannelHandler) {
+	_, exists := g.channels[channel]
+	g.channels[channel] = h
+	if exists {
+		return
+	}
+
+	for _, p := range g.Players() {
+		p.SendPluginMessage(ChannelRegister, []byte(channel))
+	}
+}
+
+// UnregisterChannel removes the handler for the specified channel. If no handler is registered, this function does
+// nothing. This function may only be called before Run, or from the goroutine that called Run.
+func (g *Game) UnregisterChannel(channel string) {
+	if _, ok := g.channels[channel]; !ok {
+		return
+	}
+	delete(g.channels, channel)
+
+	for _, p := range g.Players() {
+		p.SendPluginMessage(ChannelUnregister, []byte(channel))
+	}
+}
+
+// registeredChannels returns the payload for a ChannelRegister message containing all registered channels.
+func (g *Game) registeredChannels() []byte {
+	names := make([]string, 0, len(g.channels))
+	for name := range g.channels {
+		names = append(names, name)
+	}
+	return []byte(strings.Join(names, "\x00"))
+}
+
+// SendPluginMessage sends a custom payload to the player on the specified channel. This function may be called
+// concurrently.
+func (p *Player) SendPluginMessage(channel string, data []byte) {
+	p.conn.WritePacket(&outbound.PluginMessage{
+		Channel: channel,
+		Data:    data,
+	})
+}
+
+// ListensOn returns whether the player's client has registered the specified channel.
+func (p *Player) ListensOn(channel string) bool {
+	_, ok := p.channels[channel]
+	return ok
+}
+
+// Brand returns the name of the player's client software, such as "vanilla", or an empty string if the client did
+// not send it.
+func (p *Player) Brand() string {
+	return p.brand
+}
+
+// sendServerChannels sends the server brand and the registered channels to the player.
+func (p *Player) sendServerChannels() {
+	p.SendPluginMessage(ChannelBrand, encodeBrand(p.game.cfg.Brand, p.conn.Version()))
+	if len(p.game.channels) > 0 {
+		p.SendPluginMessage(ChannelRegister, p.game.registeredChannels())
+	}
+}
+
+func (p *Player) handlePluginMessage(pk *inbound.PluginMessage) {
+	switch pk.Channel {
+	case ChannelRegister:
+		for _, name := range splitChannels(pk.Data) {
+			p.channels[name] = struct{}{}
+		}
+	case ChannelUnregister:
+		for _, name := range splitChannels(pk.Data) {
+			delete(p.channels, name)
+		}
+	case ChannelBrand:
+		p.brand = decodeBrand(pk.Data, p.conn.Version())
+	}
+
+	if h, ok := p.game.channels[pk.Channel]; ok {
+		h(p, pk.Data)
+	}
+}
+
+// splitChannels splits the payload of a ChannelRegister or ChannelUnregister message into channel names.
+func splitChannels(data []byte) []string {
+	var names []string
+	for _, name := range bytes.Split(data, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names
+}
+
+// encodeBrand encodes a brand for a ChannelBrand message. Versions older than 1.8 use the raw string, newer versions
+// prefix it with its length.
+func encodeBrand(brand string, v protocol.Version) []byte {
+	if v < protocol.Version1_8 {
+		return []byte(brand)
+	}
+
+	var buf bytes.Buffer
+	protocol.WriteString(&buf, brand)
+	return buf.Bytes()
+}
+
+// decodeBrand decodes the payload of a ChannelBrand message, see encodeBrand.
+func decodeBrand(data []byte, v protocol.Version) string {
+	if v < protocol.Version1_8 {
+		return string(data)
+	}
+
+	brand, err := protocol.ReadString(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+	return brand
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gitfyu/mable/internal/protocol"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+func TestPlayer_handlePluginMessage(t *testing.T) {
+	p, _ := newTestPlayer(newTestGame())
+
+	var received []byte
+	p.game.RegisterChannel("test:channel", func(_ *Player, data []byte) {
+		received = data
+	})
+
+	p.HandlePacket(&inbound.PluginMessage{
+		Channel: ChannelRegister,
+		Data:    []byte("a\x00b"),
+	})
+	if !p.ListensOn("a") || !p.ListensOn("b") {
+		t.Error("Expected channels a and b to be registered")
+	}
+	p.HandlePacket(&inbound.PluginMessage{
+		Channel: ChannelUnregister,
+		Data:    []byte("a"),
+	})
+	if p.ListensOn("a") {
+		t.Error("Expected channel a to be unregistered")
+	}
+
+	p.HandlePacket(&inbound.PluginMessage{
+		Channel: ChannelBrand,
+		Data:    encodeBrand("vanilla", protocol.Version1_8),
+	})
+	if p.Brand() != "vanilla" {
+		t.Errorf("Expected brand vanilla, got %q", p.Brand())
+	}
+
+	p.HandlePacket(&inbound.PluginMessage{
+		Channel: "test:channel",
+		Data:    []byte{1, 2, 3},
+	})
+	if !bytes.Equal(received, []byte{1, 2, 3}) {
+		t.Errorf("Expected handler to receive 010203, got %x", received)
+	}
+}
+
+func TestGame_AddPlayer_Brand(t *testing.T) {
+	_, c := newTestPlayer(newTestGame())
+
+	pk, ok := c.packets[0].(*outbound.PluginMessage)
+	if !ok || pk.Channel != ChannelBrand {
+		t.Fatalf("Expected the brand to be sent first, got %#v", c.packets[0])
+	}
+	if brand := decodeBrand(pk.Data, protocol.Version1_8); brand != DefaultBrand {
+		t.Errorf("Expected brand %q, got %q", DefaultBrand, brand)
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+// ChatPosition determines where a message is displayed.
+type ChatPosition uint8
+
+const (
+	// ChatPositionChat displays a message in the chat as a message from a player. Players can hide these messages in
+	// their settings.
+	ChatPositionChat ChatPosition = iota
+	// ChatPositionSystem displays a message in the chat as a message from the server.
+	ChatPositionSystem
+	// ChatPositionActionBar displays a message above the hotbar. Clients older than 1.8 do not support this, so the
+	// message is not sent to them.
+	ChatPositionActionBar
+)
+
+// ChatScope determines which players receive the chat messages of a player.
+type ChatScope uint8
+
+const (
+	// ChatScopeGame sends chat messages to all players in the Game.
+	ChatScopeGame ChatScope = iota
+	// ChatScopeWorld sends chat messages to all players in the same World as the sender.
+	ChatScopeWorld
+)
+
+// ChatFormatter creates the message that is broadcast when a player chats. The message has already been validated.
+type ChatFormatter func(p *Player, msg string) *chat.Msg
+
+// DefaultChatFormat formats chat messages in the same way as vanilla, which is "<name> message".
+func DefaultChatFormat(p *Player, msg string) *chat.Msg {
+	return chat.NewBuilder("<" + p.Name() + "> ").Append(msg).Build()
+}
+
+// SendMessage displays a message to the player. This function may be called concurrently.
+func (p *Player) SendMessage(msg *chat.Msg, pos ChatPosition) {
+	if pos == ChatPositionActionBar && p.conn.Version() < protocol.Version1_8 {
+		return
+	}
+
+	p.conn.WritePacket(&outbound.Chat{
+		Message:  msg,
+		Position: outbound.ChatPosition(pos),
+	})
+}
+
+// BroadcastMessage displays a message to all players in the Game. This function may be called concurrently.
+func (g *Game) BroadcastMessage(msg *chat.Msg, pos ChatPosition) {
+	for _, p := range g.Players() {
+		p.SendMessage(msg, pos)
+	}
+}
+
+// BroadcastMessage displays a message to all players in the World.
+func (w *World) BroadcastMessage(msg *chat.Msg, pos ChatPosition) {
+	for _, e := range w.entities {
+		if p, ok := e.(*Player); ok {
+			p.SendMessage(msg, pos)
+		}
+	}
+}
+
+func (p *Player) handleChat(pk *inbound.Chat) {
+	if utf8.RuneCountInString(pk.Message) > maxChatLength(p.conn.Version()) {
+		p.conn.Disconnect(&chat.Msg{Text: "Chat message too long"})
+		return
+	}
+
+	// the raw message is checked, so whitespace other than spaces is rejected like in vanilla
+	for _, r := range pk.Message {
+		if !isAllowedChatChar(r) {
+			p.conn.Disconnect(&chat.Msg{Text: "Illegal characters in chat"})
+			return
+		}
+	}
+	// like vanilla, leading, trailing and repeated spaces are removed
+	msg := strings.Join(strings.Fields(pk.Message), " ")
+	if msg == "" {
+		return
+	}
+	if strings.HasPrefix(msg, "/") {
+		p.executeCommand(msg[1:])
+		return
+	}
+	if !p.game.fireChat(p, msg) {
+		return
+	}
+
+	formatted := p.game.cfg.ChatFormatter(p, msg)
+	if p.game.cfg.ChatScope == ChatScopeWorld && p.world != nil {
+		p.world.BroadcastMessage(formatted, ChatPositionChat)
+	} else {
+		p.game.BroadcastMessage(formatted, ChatPositionChat)
+	}
+}
+
+// maxChatLength returns the maximum length of a chat message sent by a client using the specified protocol.Version.
+func maxChatLength(v protocol.Version) int {
+	if v < protocol.Version1_11 {
+		return 100
+	}
+	return 256
+}
+
+// isAllowedChatChar returns whether a character may be used in chat messages. Formatting codes and control
+// characters are not allowed.
+func isAllowedChatChar(r rune) bool {
+	return r != '§' && r >= ' ' && r != 0x7F
+}
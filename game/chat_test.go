@@ -0,0 +1,52 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+// lastChat returns the last chat message that was sent, or nil if there is none.
+func lastChat(packets []packet.Outbound) *outbound.Chat {
+	for i := len(packets) - 1; i >= 0; i-- {
+		if c, ok := packets[i].(*outbound.Chat); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestPlayer_handleChat(t *testing.T) {
+	g := newTestGame()
+	sender, _ := newTestPlayer(g)
+	_, receiver := newTestPlayer(g)
+
+	sender.HandlePacket(&inbound.Chat{Message: "  hello   world "})
+	c := lastChat(receiver.packets)
+	if c == nil {
+		t.Fatal("Expected a chat message")
+	}
+	if s, expect := c.Message.String(), "<test> hello world"; s != expect {
+		t.Errorf("Expected %q, got %q", expect, s)
+	}
+}
+
+func TestPlayer_handleChat_Invalid(t *testing.T) {
+	tests := []string{
+		"§cred",
+		"bell\x07",
+		"tab\tseparated",
+		"two\nlines",
+		string(make([]byte, 101)),
+	}
+
+	for _, msg := range tests {
+		p, c := newTestPlayer(newTestGame())
+		p.HandlePacket(&inbound.Chat{Message: msg})
+		if c.disconnected == nil {
+			t.Errorf("Expected the player to be kicked for %q", msg)
+		}
+	}
+}
@@ -1,23 +1,47 @@
 package game
 
 import (
+	"math"
+	"math/bits"
+
 	"github.com/gitfyu/mable/biome"
 	"github.com/gitfyu/mable/block"
-	"math"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+	"github.com/gitfyu/mable/nbt"
 )
 
 const (
 	// chunkSectionBlocksSize is the number of bytes used for block data per chunkSection.
 	chunkSectionBlocksSize = 16 * 16 * 16 * 2
 
+	// chunkSectionVolume is the number of blocks in a chunkSection.
+	chunkSectionVolume = 16 * 16 * 16
+
 	// chunkSectionsPerChunk is the maximum number of chunkSection instances within a single Chunk.
 	chunkSectionsPerChunk = 16
 
 	// lightDataSize is the number of bytes used for both block- and skylight data per chunkSection.
 	lightDataSize = 16 * 16 * 16 / 2 * 2
 
+	// blockLightDataSize is the number of bytes used for block light data per chunkSection. Only worlds in the
+	// overworld dimension also send skylight data.
+	blockLightDataSize = lightDataSize / 2
+
 	// biomeDataSize is the number of bytes used for biome data in a single Chunk.
 	biomeDataSize = 256
+
+	// minPaletteBits is the minimum number of bits per block used by a section palette.
+	minPaletteBits = 4
+
+	// maxPaletteBits is the maximum number of bits per block used by a section palette. Sections that would need more
+	// bits use the global palette instead.
+	maxPaletteBits = 8
+
+	// globalPaletteBits is the number of bits per block used by the global palette, in which the value of a block is
+	// its block.Data.
+	globalPaletteBits = 13
 )
 
 var (
@@ -75,6 +99,37 @@ func (p ChunkPos) Dist(other ChunkPos) int32 {
 	}
 }
 
+// dataFormat identifies a format of the data that is sent to clients, see Chunk.appendData.
+type dataFormat uint8
+
+const (
+	// splitFormat is used by versions older than 1.8.
+	splitFormat dataFormat = iota
+	// flatFormat is used by 1.8.
+	flatFormat
+	// palettedFormat is used starting from 1.9.
+	palettedFormat
+)
+
+// dataFormatOf returns the dataFormat used by the specified protocol.Version.
+func dataFormatOf(v protocol.Version) dataFormat {
+	switch {
+	case v < protocol.Version1_8:
+		return splitFormat
+	case v < protocol.Version1_9:
+		return flatFormat
+	default:
+		return palettedFormat
+	}
+}
+
+// chunkPacketKey identifies a cached packet of a Chunk.
+type chunkPacketKey struct {
+	pos       ChunkPos
+	format    dataFormat
+	dimension Dimension
+}
+
 // chunkSection represents a 16-block tall section within a chunk.
 type chunkSection [chunkSectionBlocksSize]byte
 
@@ -91,6 +146,16 @@ type Chunk struct {
 	// sections contains all chunkSection instances for this Chunk. It is possible that not all indices contain a
 	// chunkSection, in which case they will be nil.
 	sections [chunkSectionsPerChunk]*chunkSection
+
+	// biomes contains the biome of each column, indexed by z*16+x. If it is nil, every column is biome.Plains.
+	biomes *[biomeDataSize]byte
+
+	// blockEntities contains the block entities in the Chunk, see blockEntityKey. It is nil if there are none.
+	blockEntities map[uint16]nbt.Compound
+
+	// packets caches the encoded packets that are used to send this Chunk, since the same Chunk is usually sent to
+	// many players. It is cleared whenever the Chunk is modified.
+	packets map[chunkPacketKey]*packet.Encoded
 }
 
 // NewChunk constructs a new Chunk.
@@ -112,6 +177,112 @@ func (c *Chunk) SetBlock(x, y, z uint8, data block.Data) {
 
 	section[idx] = uint8(v)
 	section[idx+1] = uint8(v >> 8)
+
+	c.packets = nil
+}
+
+// Block returns the block at the specified position. Note that the coordinates are relative to the chunk, not world
+// coordinates. Coordinates must all be within the range [0,15] or the function will panic.
+func (c *Chunk) Block(x, y, z uint8) block.Data {
+	section := c.sections[y>>4]
+	if section == nil {
+		return 0
+	}
+	return section.block(int(y&15)<<8 | int(z)<<4 | int(x))
+}
+
+// HasSection returns whether the 16 blocks high section at the specified index, which must be within the range
+// [0,15], has been created. Sections are created when a block in them is set, so a section that does not exist only
+// contains air.
+func (c *Chunk) HasSection(index uint8) bool {
+	return c.sectionMask&(1<<index) != 0
+}
+
+// SectionData returns a copy of the blocks in the section at the specified index, or nil if the section does not exist.
+// It contains 4096 blocks in YZX order, each stored as the little-endian value of block.Data.ToUint16.
+func (c *Chunk) SectionData(index uint8) []byte {
+	section := c.sections[index]
+	if section == nil {
+		return nil
+	}
+	data := make([]byte, len(section))
+	copy(data, section[:])
+	return data
+}
+
+// SetSectionData replaces all blocks in the section at the specified index, using data in the format returned by
+// SectionData. Panics if data does not contain exactly 4096 blocks.
+func (c *Chunk) SetSectionData(index uint8, data []byte) {
+	if len(data) != chunkSectionBlocksSize {
+		panic("invalid section data length")
+	}
+	c.createSectionIfNotExists(index)
+	copy(c.sections[index][:], data)
+
+	c.packets = nil
+}
+
+// Clone returns a copy of the blocks, biomes and block entities of the Chunk. The copy only shares the data of the
+// block entities with the original, which is never modified, so it can be used from a different goroutine.
+func (c *Chunk) Clone() *Chunk {
+	clone := NewChunk()
+	clone.sectionMask = c.sectionMask
+	clone.sectionCount = c.sectionCount
+	for i, section := range c.sections {
+		if section != nil {
+			s := *section
+			clone.sections[i] = &s
+		}
+	}
+	if c.biomes != nil {
+		b := *c.biomes
+		clone.biomes = &b
+	}
+	for k, v := range c.blockEntities {
+		if clone.blockEntities == nil {
+			clone.blockEntities = make(map[uint16]nbt.Compound, len(c.blockEntities))
+		}
+		clone.blockEntities[k] = v
+	}
+	return clone
+}
+
+// Biome returns the biome of the column at the specified position, relative to the chunk. The coordinates must be
+// within the range [0,15].
+func (c *Chunk) Biome(x, z uint8) biome.ID {
+	if c.biomes == nil {
+		return biome.Plains
+	}
+	return biome.ID(c.biomes[int(z)<<4|int(x)])
+}
+
+// SetBiome changes the biome of the column at the specified position, relative to the chunk. The coordinates must be
+// within the range [0,15].
+func (c *Chunk) SetBiome(x, z uint8, id biome.ID) {
+	if c.biomes == nil {
+		c.biomes = new([biomeDataSize]byte)
+		copy(c.biomes[:], cachedLightAndBiomeData[lightDataSize*chunkSectionsPerChunk:])
+	}
+	c.biomes[int(z)<<4|int(x)] = uint8(id)
+
+	c.packets = nil
+}
+
+// appendBiomes appends the biome data of the chunk.
+func (c *Chunk) appendBiomes(buf []byte) []byte {
+	if c.biomes == nil {
+		return append(buf, cachedLightAndBiomeData[lightDataSize*chunkSectionsPerChunk:]...)
+	}
+	return append(buf, c.biomes[:]...)
+}
+
+// appendLight appends the light data of all sections of the chunk. Every block is fully lit. Skylight is only included
+// if the dimension has a sky, since the client does not expect it otherwise.
+func (c *Chunk) appendLight(buf []byte, dim Dimension) []byte {
+	if !dim.hasSkyLight() {
+		return append(buf, cachedLightAndBiomeData[:c.sectionCount*blockLightDataSize]...)
+	}
+	return append(buf, cachedLightAndBiomeData[:c.sectionCount*lightDataSize]...)
 }
 
 // createSectionIfNotExists creates and stores a new chunkSection at the specified index if it does not exist yet.
@@ -125,9 +296,42 @@ func (c *Chunk) createSectionIfNotExists(index uint8) {
 	c.sections[index] = new(chunkSection)
 }
 
-// appendData will append the data for this chunk to the buffer, to be sent in a packet. The appended buffer will be
-// returned.
-func (c *Chunk) appendData(buf []byte) []byte {
+// packet returns a packet that sends this Chunk to a client using the specified protocol.Version, assuming that the
+// Chunk is located at pos in a World of the specified Dimension. The packet is cached until the Chunk is modified.
+func (c *Chunk) packet(pos ChunkPos, v protocol.Version, dim Dimension) *packet.Encoded {
+	k := chunkPacketKey{
+		pos:       pos,
+		format:    dataFormatOf(v),
+		dimension: dim,
+	}
+	if pk, ok := c.packets[k]; ok {
+		return pk
+	}
+
+	pk := packet.NewEncoded(&outbound.ChunkData{
+		X:         pos.X,
+		Z:         pos.Z,
+		FullChunk: true,
+		Mask:      c.sectionMask,
+		Data:      c.appendData(nil, v, dim),
+	})
+	if c.packets == nil {
+		c.packets = make(map[chunkPacketKey]*packet.Encoded)
+	}
+	c.packets[k] = pk
+	return pk
+}
+
+// appendData will append the data for this chunk to the buffer, to be sent in a packet to a client using the specified
+// protocol.Version. The Dimension determines whether skylight is included. The appended buffer will be returned.
+func (c *Chunk) appendData(buf []byte, v protocol.Version, dim Dimension) []byte {
+	switch dataFormatOf(v) {
+	case splitFormat:
+		return c.appendSplitData(buf, dim)
+	case palettedFormat:
+		return c.appendPalettedData(buf, dim)
+	}
+
 	// blocks
 	for i := 0; i < chunkSectionsPerChunk; i++ {
 		if c.sectionMask&(1<<i) != 0 {
@@ -135,7 +339,125 @@ func (c *Chunk) appendData(buf []byte) []byte {
 		}
 	}
 
-	return append(buf, cachedLightAndBiomeData[(chunkSectionsPerChunk-c.sectionCount)*lightDataSize:]...)
+	return c.appendBiomes(c.appendLight(buf, dim))
+}
+
+// appendSplitData appends the data for this chunk in the format used by versions older than 1.8, in which the block
+// IDs and metadata are stored in separate arrays. For each type of array, the arrays of all sections are written
+// before moving on to the next type.
+func (c *Chunk) appendSplitData(buf []byte, dim Dimension) []byte {
+	// block IDs, one byte per block
+	for i := 0; i < chunkSectionsPerChunk; i++ {
+		if c.sectionMask&(1<<i) == 0 {
+			continue
+		}
+
+		s := c.sections[i]
+		for j := 0; j < chunkSectionVolume; j++ {
+			buf = append(buf, uint8(s.block(j).Type()))
+		}
+	}
+
+	// metadata, one nibble per block
+	for i := 0; i < chunkSectionsPerChunk; i++ {
+		if c.sectionMask&(1<<i) == 0 {
+			continue
+		}
+
+		// the metadata is stored in the lower 4 bits of the first byte of each block
+		s := c.sections[i]
+		for j := 0; j < chunkSectionVolume; j += 2 {
+			buf = append(buf, s[j*2]&15|s[j*2+2]<<4)
+		}
+	}
+
+	// the block light and skylight arrays use the same format as 1.8
+	return c.appendBiomes(c.appendLight(buf, dim))
+}
+
+// appendPalettedData appends the data for this chunk in the format used starting from 1.9. Each section contains a
+// palette of the blocks it uses, followed by the palette indices of all blocks packed into longs and the light arrays.
+func (c *Chunk) appendPalettedData(buf []byte, dim Dimension) []byte {
+	for i := 0; i < chunkSectionsPerChunk; i++ {
+		if c.sectionMask&(1<<i) != 0 {
+			buf = c.sections[i].appendPaletted(buf, dim.hasSkyLight())
+		}
+	}
+
+	return c.appendBiomes(buf)
+}
+
+// appendPaletted appends the section in the format used starting from 1.9, including its light data. Skylight is
+// only included if skyLight is true.
+func (s *chunkSection) appendPaletted(buf []byte, skyLight bool) []byte {
+	var palette []block.Data
+	indices := make(map[block.Data]uint64)
+	for i := 0; i < chunkSectionVolume; i++ {
+		d := s.block(i)
+		if _, ok := indices[d]; !ok {
+			indices[d] = uint64(len(palette))
+			palette = append(palette, d)
+		}
+	}
+
+	bitsPerBlock := bits.Len(uint(len(palette) - 1))
+	if bitsPerBlock < minPaletteBits {
+		bitsPerBlock = minPaletteBits
+	}
+	if bitsPerBlock > maxPaletteBits {
+		bitsPerBlock = globalPaletteBits
+		palette = nil
+	}
+
+	buf = append(buf, uint8(bitsPerBlock))
+	buf = appendVarInt(buf, int32(len(palette)))
+	for _, d := range palette {
+		buf = appendVarInt(buf, int32(d))
+	}
+
+	// values may span two longs
+	longs := make([]uint64, chunkSectionVolume*bitsPerBlock/64)
+	for i := 0; i < chunkSectionVolume; i++ {
+		v := uint64(s.block(i))
+		if palette != nil {
+			v = indices[s.block(i)]
+		}
+
+		bit := i * bitsPerBlock
+		idx, offset := bit/64, bit%64
+		longs[idx] |= v << offset
+		if offset+bitsPerBlock > 64 {
+			longs[idx+1] |= v >> (64 - offset)
+		}
+	}
+
+	buf = appendVarInt(buf, int32(len(longs)))
+	for _, l := range longs {
+		buf = append(buf,
+			uint8(l>>56), uint8(l>>48), uint8(l>>40), uint8(l>>32),
+			uint8(l>>24), uint8(l>>16), uint8(l>>8), uint8(l))
+	}
+
+	// every section uses the same light data, so the start of the cached data can be used for each of them
+	if !skyLight {
+		return append(buf, cachedLightAndBiomeData[:blockLightDataSize]...)
+	}
+	return append(buf, cachedLightAndBiomeData[:lightDataSize]...)
+}
+
+// block returns the block.Data at the specified index within the section.
+func (s *chunkSection) block(i int) block.Data {
+	return block.Data(s[i*2]) | block.Data(s[i*2+1])<<8
+}
+
+// appendVarInt appends v to buf as a VarInt.
+func appendVarInt(buf []byte, v int32) []byte {
+	u := uint32(v)
+	for u >= 0x80 {
+		buf = append(buf, uint8(u)|0x80)
+		u >>= 7
+	}
+	return append(buf, uint8(u))
 }
 
 // Subscribe registers the specified channel to receive updates for this Chunk. The specified ID must be unique to the
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/nbt"
+)
+
+func TestChunk_appendData_Split(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(1, 17, 0, block.Stone.ToDataWithMetadata(5))
+	c.SetBlock(2, 17, 0, block.Stone.ToDataWithMetadata(9))
+
+	data := c.appendData(nil, protocol.Version1_7_6, DimensionOverworld)
+	const expectSize = chunkSectionVolume + chunkSectionVolume/2 + lightDataSize + biomeDataSize
+	if len(data) != expectSize {
+		t.Fatalf("Expected %d bytes, got %d", expectSize, len(data))
+	}
+
+	// the blocks are in the section at index 1, which is the only section
+	const idx = 1<<8 | 1
+	if data[idx] != uint8(block.Stone) || data[idx+1] != uint8(block.Stone) {
+		t.Errorf("Expected block IDs %d, got %d and %d", block.Stone, data[idx], data[idx+1])
+	}
+	if meta := data[chunkSectionVolume+idx/2]; meta != 5<<4 {
+		t.Errorf("Expected metadata %x, got %x", 5<<4, meta)
+	}
+	if meta := data[chunkSectionVolume+idx/2+1]; meta != 9 {
+		t.Errorf("Expected metadata %x, got %x", 9, meta)
+	}
+}
+
+func TestChunk_appendData_Paletted(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(0, 0, 0, block.Stone.ToData())
+	c.SetBlock(1, 0, 0, block.Stone.ToDataWithMetadata(3))
+
+	data := c.appendData(nil, protocol.Version1_12_2, DimensionOverworld)
+	const longCount = chunkSectionVolume * minPaletteBits / 64
+	// bits per block, palette length, 3 palette entries, long count (2 bytes), longs
+	const longsOffset = 1 + 1 + 3 + 2
+	const expectSize = longsOffset + longCount*8 + lightDataSize + biomeDataSize
+	if len(data) != expectSize {
+		t.Fatalf("Expected %d bytes, got %d", expectSize, len(data))
+	}
+
+	if data[0] != minPaletteBits {
+		t.Errorf("Expected %d bits per block, got %d", minPaletteBits, data[0])
+	}
+	// the palette contains the blocks in the order they first appear
+	expectPalette := []byte{3, uint8(block.Stone.ToData()), uint8(block.Stone.ToDataWithMetadata(3)), 0}
+	for i, b := range expectPalette {
+		if data[1+i] != b {
+			t.Errorf("Expected palette byte %d to be %x, got %x", i, b, data[1+i])
+		}
+	}
+
+	// the longs are big-endian and the first block is stored in the least significant bits
+	if b := data[longsOffset+7]; b != 1<<4|0 {
+		t.Errorf("Expected first byte of block indices to be %x, got %x", 1<<4|0, b)
+	}
+	if b := data[longsOffset+6]; b != 2<<4|2 {
+		t.Errorf("Expected second byte of block indices to be %x, got %x", 2<<4|2, b)
+	}
+}
+
+func TestChunk_appendData_SkyLight(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(0, 0, 0, block.Stone.ToData())
+	c.SetBlock(0, 16, 0, block.Stone.ToData())
+
+	for _, v := range []protocol.Version{protocol.Version1_7_6, protocol.Version1_8, protocol.Version1_12_2} {
+		overworld := len(c.appendData(nil, v, DimensionOverworld))
+		for _, dim := range []Dimension{DimensionNether, DimensionEnd} {
+			// two sections without skylight
+			if n := len(c.appendData(nil, v, dim)); n != overworld-2*blockLightDataSize {
+				t.Errorf("%v: expected %d bytes in dimension %d, got %d", v, overworld-2*blockLightDataSize, dim, n)
+			}
+		}
+	}
+}
+
+func TestChunk_packet(t *testing.T) {
+	c := NewChunk()
+	pos := ChunkPos{X: 1, Z: 2}
+
+	pk := c.packet(pos, protocol.Version1_9, DimensionOverworld)
+	if c.packet(pos, protocol.Version1_12_2, DimensionOverworld) != pk {
+		t.Error("Expected versions using the same format to share a packet")
+	}
+	if c.packet(pos, protocol.Version1_8, DimensionOverworld) == pk {
+		t.Error("Expected versions using different formats to use different packets")
+	}
+
+	if c.packet(pos, protocol.Version1_9, DimensionNether) == pk {
+		t.Error("Expected dimensions with different light data to use different packets")
+	}
+
+	c.SetBlock(0, 0, 0, block.Stone.ToData())
+	if c.packet(pos, protocol.Version1_9, DimensionOverworld) == pk {
+		t.Error("Expected SetBlock to invalidate the cached packet")
+	}
+}
+
+func TestChunk_Block(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(3, 40, 5, block.Stone.ToDataWithMetadata(2))
+
+	if b := c.Block(3, 40, 5); b != block.Stone.ToDataWithMetadata(2) {
+		t.Errorf("Expected %v, got %v", block.Stone.ToDataWithMetadata(2), b)
+	}
+	if b := c.Block(3, 100, 5); b != 0 {
+		t.Errorf("Expected air in missing section, got %v", b)
+	}
+}
+
+func TestChunk_SetBiome(t *testing.T) {
+	c := NewChunk()
+	if b := c.Biome(4, 7); b != biome.Plains {
+		t.Errorf("Expected plains by default, got %v", b)
+	}
+
+	c.SetBiome(4, 7, 2)
+	if b := c.Biome(4, 7); b != 2 {
+		t.Errorf("Expected biome 2, got %v", b)
+	}
+
+	data := c.appendData(nil, protocol.Version1_12_2, DimensionOverworld)
+	biomes := data[len(data)-biomeDataSize:]
+	if biomes[7<<4|4] != 2 || biomes[0] != uint8(biome.Plains) {
+		t.Errorf("Expected biome data to contain the changed biome, got %v", biomes)
+	}
+}
+
+func TestChunk_SectionData(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(1, 18, 3, block.Stone.ToDataWithMetadata(4))
+	if c.SectionData(0) != nil {
+		t.Error("Expected nil for a section that does not exist")
+	}
+
+	other := NewChunk()
+	other.SetSectionData(1, c.SectionData(1))
+	if b := other.Block(1, 18, 3); b != block.Stone.ToDataWithMetadata(4) {
+		t.Errorf("Expected %v, got %v", block.Stone.ToDataWithMetadata(4), b)
+	}
+}
+
+func TestChunk_BlockEntities(t *testing.T) {
+	c := NewChunk()
+	c.SetBlockEntity(5, 10, 0, nbt.Compound{"id": "Chest"})
+	c.SetBlockEntity(0, 2, 7, nbt.Compound{"id": "Sign"})
+	c.SetBlockEntity(1, 1, 1, nbt.Compound{"id": "Furnace"})
+	c.SetBlockEntity(1, 1, 1, nil)
+
+	entities := c.BlockEntities()
+	if len(entities) != 2 {
+		t.Fatalf("Expected 2 block entities, got %d", len(entities))
+	}
+	if e := entities[0]; e.X != 0 || e.Y != 2 || e.Z != 7 || e.Data["id"] != "Sign" {
+		t.Errorf("Expected the sign first, got %+v", e)
+	}
+	if c.Clone().BlockEntity(5, 10, 0) == nil {
+		t.Error("Expected Clone to copy the block entities")
+	}
+}
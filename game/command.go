@@ -0,0 +1,87 @@
+package game
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gitfyu/mable/chat"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+// PermissionAll is a permission that grants every other permission.
+const PermissionAll = "*"
+
+// MsgUnknownCommand is displayed to players who send a command that does not exist, or any command if there is no
+// CommandHandler. It is also used by the command package, which cannot be imported by this package.
+var MsgUnknownCommand = &chat.Msg{Text: "Unknown command. Type \"/help\" for help.", Color: chat.ColorRed}
+
+// CommandHandler executes the commands that players send using the chat. It is implemented by command.Dispatcher.
+// Its functions are called from the goroutine that called Game.Run.
+type CommandHandler interface {
+	// ExecuteCommand executes a command line, without the leading slash.
+	ExecuteCommand(p *Player, line string)
+	// CompleteCommand returns suggestions for the last word of a partial command line, without the leading slash.
+	CompleteCommand(p *Player, line string) []string
+}
+
+// SetCommandHandler sets the CommandHandler that is used for commands sent by players. This function may only be
+// called before Run, or from the goroutine that called Run.
+func (g *Game) SetCommandHandler(h CommandHandler) {
+	g.commands = h
+}
+
+// HasPermission returns whether the player has been granted the specified permission, either directly or through
+// PermissionAll. An empty permission is always granted.
+func (p *Player) HasPermission(perm string) bool {
+	if perm == "" {
+		return true
+	}
+	_, ok := p.perms[perm]
+	if !ok {
+		_, ok = p.perms[PermissionAll]
+	}
+	return ok
+}
+
+// GrantPermission grants a permission to the player.
+func (p *Player) GrantPermission(perm string) {
+	p.perms[perm] = struct{}{}
+}
+
+// RevokePermission revokes a permission that was granted to the player. Note that a player who has been granted
+// PermissionAll still has every permission.
+func (p *Player) RevokePermission(perm string) {
+	delete(p.perms, perm)
+}
+
+// executeCommand executes a command that the player sent in the chat.
+func (p *Player) executeCommand(line string) {
+	if p.game.commands == nil {
+		p.SendMessage(MsgUnknownCommand, ChatPositionSystem)
+		return
+	}
+	p.game.commands.ExecuteCommand(p, line)
+}
+
+func (p *Player) handleTabComplete(pk *inbound.TabComplete) {
+	var matches []string
+	if strings.HasPrefix(pk.Text, "/") {
+		if p.game.commands != nil {
+			matches = p.game.commands.CompleteCommand(p, pk.Text[1:])
+		}
+	} else {
+		// like vanilla, the names of players are suggested in regular chat messages
+		last := strings.ToLower(pk.Text[strings.LastIndexByte(pk.Text, ' ')+1:])
+		for _, other := range p.game.Players() {
+			if strings.HasPrefix(strings.ToLower(other.Name()), last) {
+				matches = append(matches, other.Name())
+			}
+		}
+		sort.Strings(matches)
+	}
+
+	p.conn.WritePacket(&outbound.TabComplete{
+		Matches: matches,
+	})
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+type testCommandHandler struct {
+	lines []string
+}
+
+func (h *testCommandHandler) ExecuteCommand(_ *Player, line string) {
+	h.lines = append(h.lines, line)
+}
+
+func (h *testCommandHandler) CompleteCommand(_ *Player, line string) []string {
+	return []string{line}
+}
+
+func TestPlayer_executeCommand(t *testing.T) {
+	g := newTestGame()
+	h := &testCommandHandler{}
+	g.SetCommandHandler(h)
+	p, _ := newTestPlayer(g)
+	_, other := newTestPlayer(g)
+
+	p.HandlePacket(&inbound.Chat{Message: "/tp  test"})
+	if expect := []string{"tp test"}; !reflect.DeepEqual(expect, h.lines) {
+		t.Errorf("Expected %v, got %v", expect, h.lines)
+	}
+	if lastChat(other.packets) != nil {
+		t.Error("Expected the command not to be broadcast")
+	}
+}
+
+func TestPlayer_handleTabComplete(t *testing.T) {
+	g := newTestGame()
+	g.SetCommandHandler(&testCommandHandler{})
+	p, c := newTestPlayer(g)
+
+	p.HandlePacket(&inbound.TabComplete{Text: "/tp t"})
+	pk := c.packets[len(c.packets)-1].(*outbound.TabComplete)
+	if expect := []string{"tp t"}; !reflect.DeepEqual(expect, pk.Matches) {
+		t.Errorf("Expected %v, got %v", expect, pk.Matches)
+	}
+
+	p.HandlePacket(&inbound.TabComplete{Text: "hi T"})
+	pk = c.packets[len(c.packets)-1].(*outbound.TabComplete)
+	if expect := []string{"test"}; !reflect.DeepEqual(expect, pk.Matches) {
+		t.Errorf("Expected %v, got %v", expect, pk.Matches)
+	}
+}
+
+func TestPlayer_HasPermission(t *testing.T) {
+	p, _ := newTestPlayer(newTestGame())
+	if p.HasPermission("a") {
+		t.Error("Expected no permissions by default")
+	}
+	p.GrantPermission("a")
+	if !p.HasPermission("a") || p.HasPermission("b") {
+		t.Error("Expected only the granted permission")
+	}
+	p.GrantPermission(PermissionAll)
+	p.RevokePermission("a")
+	if !p.HasPermission("b") {
+		t.Error("Expected PermissionAll to grant every permission")
+	}
+}
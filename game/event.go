@@ -0,0 +1,40 @@
+package game
+
+// JoinHandler is called when a player has joined the Game, after they have been moved to the spawn of the default
+// World.
+type JoinHandler func(p *Player)
+
+// QuitHandler is called when a player leaves the Game, before they are removed from the player list.
+type QuitHandler func(p *Player)
+
+// ChatHandler is called when a player sends a chat message that is not a command, before it is broadcast. If it
+// returns false, the message is not broadcast.
+type ChatHandler func(p *Player, msg string) bool
+
+// OnJoin registers a JoinHandler. Handlers are called in the order in which they were registered. This function may
+// only be called before Run, or from the goroutine that called Run.
+func (g *Game) OnJoin(h JoinHandler) {
+	g.joinHandlers = append(g.joinHandlers, h)
+}
+
+// OnQuit registers a QuitHandler. Handlers are called in the order in which they were registered. This function may
+// only be called before Run, or from the goroutine that called Run.
+func (g *Game) OnQuit(h QuitHandler) {
+	g.quitHandlers = append(g.quitHandlers, h)
+}
+
+// OnChat registers a ChatHandler. Handlers are called in the order in which they were registered, until one of them
+// cancels the message. This function may only be called before Run, or from the goroutine that called Run.
+func (g *Game) OnChat(h ChatHandler) {
+	g.chatHandlers = append(g.chatHandlers, h)
+}
+
+// fireChat calls the ChatHandlers and returns whether the message should be broadcast.
+func (g *Game) fireChat(p *Player, msg string) bool {
+	for _, h := range g.chatHandlers {
+		if !h(p, msg) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"testing"
+
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+)
+
+func TestGame_Events(t *testing.T) {
+	g := newTestGame()
+	var events []string
+	g.OnJoin(func(p *Player) {
+		if p.World() != g.DefaultWorld() || p.Pos() != g.DefaultWorld().Spawn() {
+			t.Error("Expected the player to be spawned before the join event")
+		}
+		events = append(events, "join")
+	})
+	g.OnQuit(func(p *Player) {
+		if g.PlayerCount() != 1 {
+			t.Error("Expected the player to be removed after the quit event")
+		}
+		events = append(events, "quit")
+	})
+
+	p, _ := newTestPlayer(g)
+	g.RemovePlayer(p)
+	g.RemovePlayer(p)
+
+	if len(events) != 2 || events[0] != "join" || events[1] != "quit" {
+		t.Errorf("Expected a join and a quit event, got %v", events)
+	}
+}
+
+func TestGame_OnChat(t *testing.T) {
+	g := newTestGame()
+	g.OnChat(func(p *Player, msg string) bool {
+		return msg != "cancel"
+	})
+	sender, _ := newTestPlayer(g)
+	_, receiver := newTestPlayer(g)
+
+	sender.HandlePacket(&inbound.Chat{Message: "cancel"})
+	if lastChat(receiver.packets) != nil {
+		t.Error("Expected the message to be cancelled")
+	}
+
+	sender.HandlePacket(&inbound.Chat{Message: "hello"})
+	if lastChat(receiver.packets) == nil {
+		t.Error("Expected the message to be broadcast")
+	}
+}
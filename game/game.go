@@ -1,43 +1,127 @@
 package game
 
 import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
+
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+)
+
+const (
+	// DefaultWorldName is the name under which the default World is registered.
+	DefaultWorldName = "world"
+	// DefaultTickInterval is the tick interval used if Config.TickInterval is not set, which is the same as in vanilla.
+	DefaultTickInterval = 50 * time.Millisecond
+	// DefaultKeepAliveInterval is the keep-alive interval used if Config.KeepAliveInterval is not set.
+	DefaultKeepAliveInterval = 15 * time.Second
+	// DefaultKeepAliveTimeout is the keep-alive timeout used if Config.KeepAliveTimeout is not set.
+	DefaultKeepAliveTimeout = 30 * time.Second
 )
 
 // Config is used to configure a Game instance.
 type Config struct {
 	// MaxJobs specifies how many jobs can be queued at the same time.
 	MaxJobs int
-	// TickInterval specifies how often the game state should be updated.
+	// TickInterval specifies how often the game state should be updated. If it is zero, DefaultTickInterval is used.
 	TickInterval time.Duration
+	// KeepAliveInterval specifies how often a keep-alive is sent to players, which is used to measure their latency.
+	// If it is zero, DefaultKeepAliveInterval is used.
+	KeepAliveInterval time.Duration
+	// KeepAliveTimeout specifies how long players can take to respond to a keep-alive before they are kicked. If it is
+	// zero, DefaultKeepAliveTimeout is used.
+	KeepAliveTimeout time.Duration
+	// Brand is the name of the server software that is sent to clients, which is displayed in the debug screen. If
+	// it is empty, DefaultBrand is used.
+	Brand string
+	// ChatFormatter creates the messages that are broadcast when players chat. If it is nil, DefaultChatFormat is used.
+	ChatFormatter ChatFormatter
+	// ChatScope determines which players receive the chat messages of a player.
+	ChatScope ChatScope
 }
 
 // Game manages the state for game related things, such as
 // worlds and entities.
 type Game struct {
-	cfg    Config
-	worlds []*World
-	closed chan struct{}
-	jobs   chan func()
+	cfg Config
+	// worlds contains the registered worlds by name. It may only be accessed by the goroutine that called Run.
+	worlds       map[string]*World
+	defaultWorld *World
+	closed       chan struct{}
+	jobs         chan func()
+	// rand may only be used by the goroutine that called Run.
+	rand *rand.Rand
+	// channels contains the handlers for plugin channels, see RegisterChannel.
+	channels map[string]ChannelHandler
+	// commands executes the commands sent by players, or nil if there are no commands.
+	commands CommandHandler
+	// joinHandlers, quitHandlers and chatHandlers contain the registered event handlers.
+	joinHandlers []JoinHandler
+	quitHandlers []QuitHandler
+	chatHandlers []ChatHandler
+
+	// playersMu guards players, which may be accessed concurrently.
+	playersMu sync.RWMutex
+	players   map[ID]*Player
 }
 
-// NewGame constructs a new Game. Panics if len(worlds)==0.
+// NewGame constructs a new Game. The first World is the default World, which is registered as DefaultWorldName. The
+// other worlds are registered as DefaultWorldName followed by an underscore and their index, such as "world_1". Use
+// AddWorld to register worlds with other names. Panics if len(worlds)==0.
 func NewGame(worlds []*World, cfg Config) *Game {
 	if len(worlds) == 0 {
 		panic("no worlds specified")
 	}
-	return &Game{
-		cfg:    cfg,
-		worlds: worlds,
-		closed: make(chan struct{}),
-		jobs:   make(chan func(), cfg.MaxJobs),
+	if cfg.TickInterval == 0 {
+		cfg.TickInterval = DefaultTickInterval
+	}
+	if cfg.KeepAliveInterval == 0 {
+		cfg.KeepAliveInterval = DefaultKeepAliveInterval
 	}
+	if cfg.KeepAliveTimeout == 0 {
+		cfg.KeepAliveTimeout = DefaultKeepAliveTimeout
+	}
+	if cfg.Brand == "" {
+		cfg.Brand = DefaultBrand
+	}
+	if cfg.ChatFormatter == nil {
+		cfg.ChatFormatter = DefaultChatFormat
+	}
+
+	g := &Game{
+		cfg:          cfg,
+		worlds:       make(map[string]*World),
+		defaultWorld: worlds[0],
+		closed:       make(chan struct{}),
+		jobs:         make(chan func(), cfg.MaxJobs),
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		channels:     make(map[string]ChannelHandler),
+		players:      make(map[ID]*Player),
+	}
+	for i, w := range worlds {
+		name := DefaultWorldName
+		if i > 0 {
+			name += "_" + strconv.Itoa(i)
+		}
+		if err := g.AddWorld(name, w); err != nil {
+			panic(err)
+		}
+	}
+	return g
 }
 
 // Schedule schedules a job to be executed in the same goroutine
-// that called Run.
+// that called Run. If the Game has been closed, the job is discarded
+// instead of blocking until there is room in the queue.
 func (g *Game) Schedule(job func()) {
-	g.jobs <- job
+	select {
+	case g.jobs <- job:
+	case <-g.closed:
+	}
 }
 
 // Run will process game updates until Close is called.
@@ -59,9 +143,153 @@ func (g *Game) Run() error {
 	}
 }
 
-// DefaultWorld returns the default World.
+// DefaultWorld returns the default World, in which players spawn when they join.
 func (g *Game) DefaultWorld() *World {
-	return g.worlds[0]
+	return g.defaultWorld
+}
+
+// AddWorld registers a World under the specified name. It returns an error if the name is already in use, or if the
+// World has already been registered. This function may only be called before Run, or from the goroutine that called
+// Run.
+func (g *Game) AddWorld(name string, w *World) error {
+	if name == "" {
+		return errors.New("world name must not be empty")
+	}
+	if _, ok := g.worlds[name]; ok {
+		return fmt.Errorf("world %q already exists", name)
+	}
+	if w.name != "" {
+		return fmt.Errorf("world is already registered as %q", w.name)
+	}
+
+	w.name = name
+	g.worlds[name] = w
+	return nil
+}
+
+// RemoveWorld unregisters the World with the specified name. Players in that World are moved to the default World.
+// It returns an error if the World does not exist or if it is the default World. This function may only be called
+// from the goroutine that called Run.
+func (g *Game) RemoveWorld(name string) error {
+	w, ok := g.worlds[name]
+	if !ok {
+		return fmt.Errorf("world %q does not exist", name)
+	}
+	if w == g.defaultWorld {
+		return errors.New("the default world cannot be removed")
+	}
+
+	for _, p := range w.players() {
+		p.SetWorld(g.defaultWorld)
+	}
+	delete(g.worlds, name)
+	w.name = ""
+	return nil
+}
+
+// World returns the World with the specified name, or nil if it does not exist. This function may only be called from
+// the goroutine that called Run. Together with WorldNames, it implements command.WorldProvider.
+func (g *Game) World(name string) *World {
+	return g.worlds[name]
+}
+
+// WorldNames returns the names of all registered worlds, in alphabetical order. This function may only be called from
+// the goroutine that called Run.
+func (g *Game) WorldNames() []string {
+	names := make([]string, 0, len(g.worlds))
+	for name := range g.worlds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddPlayer registers a Player that has joined the game, adds them to the player list of all players and moves them
+// to the spawn of the default World. Afterwards, the JoinHandlers are called.
+func (g *Game) AddPlayer(p *Player) {
+	g.playersMu.Lock()
+	g.players[p.id] = p
+	g.playersMu.Unlock()
+
+	p.game = g
+	p.sendServerChannels()
+	players := g.Players()
+
+	// the new player needs to receive the entire list, including themselves
+	entries := make([]outbound.PlayerListEntry, len(players))
+	for i, other := range players {
+		entries[i] = other.playerListEntry()
+	}
+	p.sendPlayerList(outbound.PlayerListAdd, entries)
+
+	for _, other := range players {
+		if other != p {
+			other.sendPlayerList(outbound.PlayerListAdd, []outbound.PlayerListEntry{p.playerListEntry()})
+		}
+	}
+
+	p.SetWorld(g.defaultWorld)
+
+	for _, h := range g.joinHandlers {
+		h(p)
+	}
+}
+
+// RemovePlayer unregisters a Player that has left the game, and removes them from the player list of the remaining
+// players. The QuitHandlers are called before the player is removed. If the player is not registered, this function
+// does nothing.
+func (g *Game) RemovePlayer(p *Player) {
+	g.playersMu.RLock()
+	_, ok := g.players[p.id]
+	g.playersMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	for _, h := range g.quitHandlers {
+		h(p)
+	}
+
+	g.playersMu.Lock()
+	delete(g.players, p.id)
+	g.playersMu.Unlock()
+
+	g.broadcastPlayerList(outbound.PlayerListRemove, p)
+}
+
+// broadcastPlayerList sends a player list update for p to all players.
+func (g *Game) broadcastPlayerList(action outbound.PlayerListAction, p *Player) {
+	entries := []outbound.PlayerListEntry{p.playerListEntry()}
+	for _, other := range g.Players() {
+		other.sendPlayerList(action, entries)
+	}
+}
+
+// PlayerCount returns the number of players in the game. This function may be called concurrently.
+func (g *Game) PlayerCount() int {
+	g.playersMu.RLock()
+	defer g.playersMu.RUnlock()
+
+	return len(g.players)
+}
+
+// Players returns all players in the game, in no particular order. This function may be called concurrently, but
+// only the functions of the returned players that explicitly allow it may be used from a goroutine other than the one
+// that called Run.
+func (g *Game) Players() []*Player {
+	g.playersMu.RLock()
+	defer g.playersMu.RUnlock()
+
+	players := make([]*Player, 0, len(g.players))
+	for _, p := range g.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+// Done returns a channel that is closed when the Game is closed. This function may be called concurrently.
+func (g *Game) Done() <-chan struct{} {
+	return g.closed
 }
 
 // Close releases resources associated with the Game.
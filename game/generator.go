@@ -0,0 +1,78 @@
+package game
+
+import "container/list"
+
+// DefaultChunkCacheSize is the number of generated chunks that a World keeps if no other size is specified.
+const DefaultChunkCacheSize = 1024
+
+// ChunkGenerator creates the chunks of a World that do not exist. It is only called from the goroutine that called
+// Game.Run, if the World is registered in a Game.
+type ChunkGenerator interface {
+	// GenerateChunk creates the Chunk at the specified position. It may return nil, in which case the chunk is not
+	// sent to players. Every call must return a new Chunk, since the returned Chunk can be modified.
+	GenerateChunk(pos ChunkPos) *Chunk
+}
+
+// ChunkGeneratorFunc is a function that implements ChunkGenerator.
+type ChunkGeneratorFunc func(pos ChunkPos) *Chunk
+
+func (f ChunkGeneratorFunc) GenerateChunk(pos ChunkPos) *Chunk {
+	return f(pos)
+}
+
+// chunkCache contains the chunks that have been generated by a ChunkGenerator. If it is full, the chunk that has not
+// been used for the longest time is evicted, which is fine since the same chunk is generated again when it is needed.
+type chunkCache struct {
+	size int
+	// order contains the positions of the cached chunks, with the most recently used chunk at the front.
+	order   *list.List
+	entries map[ChunkPos]*list.Element
+}
+
+// cachedChunk is the value of the elements in chunkCache.order.
+type cachedChunk struct {
+	pos   ChunkPos
+	chunk *Chunk
+}
+
+func newChunkCache(size int) *chunkCache {
+	return &chunkCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[ChunkPos]*list.Element),
+	}
+}
+
+// get returns the cached chunk at pos and marks it as recently used. The second return value is false if the chunk is
+// not cached, which is different from a cached nil chunk.
+func (c *chunkCache) get(pos ChunkPos) (*Chunk, bool) {
+	e, ok := c.entries[pos]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedChunk).chunk, true
+}
+
+// put adds a chunk to the cache, evicting the least recently used chunk if the cache is full.
+func (c *chunkCache) put(pos ChunkPos, chunk *Chunk) {
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedChunk).pos)
+	}
+	c.entries[pos] = c.order.PushFront(&cachedChunk{pos: pos, chunk: chunk})
+}
+
+// remove removes the chunk at pos from the cache, if it is cached.
+func (c *chunkCache) remove(pos ChunkPos) {
+	if e, ok := c.entries[pos]; ok {
+		c.order.Remove(e)
+		delete(c.entries, pos)
+	}
+}
+
+// len returns the number of cached chunks.
+func (c *chunkCache) len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/gitfyu/mable/block"
+)
+
+// countingGenerator generates empty chunks and counts how often each chunk is generated.
+type countingGenerator map[ChunkPos]int
+
+func (g countingGenerator) GenerateChunk(pos ChunkPos) *Chunk {
+	g[pos]++
+	if pos.X < 0 {
+		return nil
+	}
+	return NewChunk()
+}
+
+func TestWorld_GetChunk_Generator(t *testing.T) {
+	predefined := NewChunk()
+	w := NewWorld(map[ChunkPos]*Chunk{{X: 0, Z: 0}: predefined})
+	gen := countingGenerator{}
+	w.SetGenerator(gen, 2)
+
+	if w.GetChunk(ChunkPos{X: 0, Z: 0}) != predefined || gen[ChunkPos{X: 0, Z: 0}] != 0 {
+		t.Error("Expected the predefined chunk to be used")
+	}
+
+	a := w.GetChunk(ChunkPos{X: 1, Z: 0})
+	if a == nil || w.GetChunk(ChunkPos{X: 1, Z: 0}) != a {
+		t.Error("Expected the generated chunk to be cached")
+	}
+	if w.GetChunk(ChunkPos{X: -1, Z: 0}) != nil || w.GetChunk(ChunkPos{X: -1, Z: 0}) != nil {
+		t.Error("Expected nil for a chunk that is not generated")
+	}
+	if gen[ChunkPos{X: -1, Z: 0}] != 1 {
+		t.Error("Expected nil chunks to be cached")
+	}
+
+	// 1,0 is the least recently used chunk, so it is evicted
+	w.GetChunk(ChunkPos{X: 2, Z: 0})
+	if w.generated.len() != 2 {
+		t.Errorf("Expected 2 cached chunks, got %d", w.generated.len())
+	}
+	if w.GetChunk(ChunkPos{X: 1, Z: 0}) == a || gen[ChunkPos{X: 1, Z: 0}] != 2 {
+		t.Error("Expected the evicted chunk to be generated again")
+	}
+}
+
+func TestWorld_SetBlocks_Generated(t *testing.T) {
+	w := NewWorld(nil)
+	gen := countingGenerator{}
+	w.SetGenerator(gen, 1)
+
+	pos := ChunkPos{X: 3, Z: 3}
+	w.SetBlock(3*16, 0, 3*16, block.Stone.ToData())
+	w.GetChunk(ChunkPos{X: 4, Z: 4})
+	w.GetChunk(ChunkPos{X: 5, Z: 5})
+
+	c := w.GetChunk(pos)
+	if c == nil || c.Block(0, 0, 0) != block.Stone.ToData() {
+		t.Error("Expected the modified chunk to be kept")
+	}
+	if gen[pos] != 1 {
+		t.Errorf("Expected the modified chunk to be generated once, got %d", gen[pos])
+	}
+	if _, ok := w.Snapshot().Chunks[pos]; !ok {
+		t.Error("Expected the modified chunk to be included in snapshots")
+	}
+}
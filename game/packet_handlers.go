@@ -1,8 +1,13 @@
 package game
 
 import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gitfyu/mable/chat"
 	"github.com/gitfyu/mable/internal/protocol/packet"
 	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
 )
 
 // HandlePacket processes a packet sent by the player.
@@ -13,13 +18,40 @@ func (p *Player) HandlePacket(pk packet.Inbound) {
 		p.handleKeepAlive(pk)
 	case *inbound.Update:
 		p.handleUpdate(pk)
+	case *inbound.TeleportConfirm:
+		p.handleTeleportConfirm(pk)
+	case *inbound.PluginMessage:
+		p.handlePluginMessage(pk)
+	case *inbound.Chat:
+		p.handleChat(pk)
+	case *inbound.TabComplete:
+		p.handleTabComplete(pk)
 	}
 }
 
 func (p *Player) handleKeepAlive(pk *inbound.KeepAlive) {
+	if !p.keepAlivePending || pk.ID != p.keepAliveID {
+		p.conn.Disconnect(&chat.Msg{Text: "Invalid keep-alive response"})
+		return
+	}
+
+	p.keepAlivePending = false
+	atomic.StoreInt64(&p.latency, int64(time.Since(p.keepAliveSent)))
+	p.game.broadcastPlayerList(outbound.PlayerListUpdateLatency, p)
+}
+
+func (p *Player) handleTeleportConfirm(pk *inbound.TeleportConfirm) {
+	if pk.ID == p.lastTeleportID {
+		p.teleportPending = false
+	}
 }
 
 func (p *Player) handleUpdate(pk *inbound.Update) {
+	if p.teleportPending {
+		// the client has not processed the last teleport yet
+		return
+	}
+
 	if pk.HasPos {
 		oldChunkPos := ChunkPosFromWorldCoords(p.pos.X, p.pos.Z)
 		newChunkPos := ChunkPosFromWorldCoords(pk.X, pk.Z)
@@ -1,13 +1,22 @@
 package game
 
 import (
+	"net"
+	"sync/atomic"
+	"time"
+
 	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol"
 	"github.com/gitfyu/mable/internal/protocol/packet"
 	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
 	"github.com/google/uuid"
 )
 
-const PlayerEyeHeight = 1.62
+// PlayerEyeHeight is the distance between a player's feet and eyes.
+const PlayerEyeHeight = outbound.PlayerEyeHeight
+
+// maxBulkChunks is the maximum number of chunks that are sent in a single BulkChunkData packet.
+const maxBulkChunks = 10
 
 // PlayerConn represents a player's network connection.
 type PlayerConn interface {
@@ -15,6 +24,11 @@ type PlayerConn interface {
 	WritePacket(pk packet.Outbound)
 	// Disconnect kicks the player from the server.
 	Disconnect(reason *chat.Msg)
+	// Version returns the protocol version of the player's client.
+	Version() protocol.Version
+	// RemoteAddr returns the address of the player. If the player connected through a proxy that forwards their
+	// address, this is the forwarded address.
+	RemoteAddr() net.Addr
 }
 
 // Player represents a player entity.
@@ -22,21 +36,51 @@ type Player struct {
 	id     ID
 	name   string
 	uid    uuid.UUID
+	props  []ProfileProperty
 	conn   PlayerConn
 	world  *World
 	pos    Pos
 	chunks map[ChunkPos]*Chunk
+	// dimension is the dimension that the client was sent in the JoinGame packet or the last Respawn packet, which may
+	// differ from the current settings of the World.
+	dimension Dimension
+
+	// lastTeleportID is the ID of the most recent teleport. Starting from 1.9, position updates are ignored until the
+	// client has confirmed this teleport, since they may still refer to the old position.
+	lastTeleportID  int32
+	teleportPending bool
+
+	// game is the Game that the player has been added to.
+	game *Game
+
+	// keepAliveID is the ID of the last keep-alive that was sent at keepAliveSent. If keepAlivePending is set, the
+	// client has not responded to it yet.
+	keepAliveID      int64
+	keepAliveSent    time.Time
+	keepAlivePending bool
+	// latency is the round-trip time of the last keep-alive in nanoseconds. It must be accessed atomically.
+	latency int64
+
+	// channels contains the plugin channels that the client has registered.
+	channels map[string]struct{}
+	// brand is the name of the client software.
+	brand string
+	// perms contains the permissions that have been granted to the player.
+	perms map[string]struct{}
 }
 
-// NewPlayer constructs a new Player.
+// NewPlayer constructs a new Player. The properties are the ones from the player's profile, such as their skin.
 // The created Player will not be associated with any World yet.
-func NewPlayer(name string, uid uuid.UUID, conn PlayerConn) *Player {
+func NewPlayer(name string, uid uuid.UUID, props []ProfileProperty, conn PlayerConn) *Player {
 	return &Player{
-		id:     newEntityID(),
-		name:   name,
-		uid:    uid,
-		conn:   conn,
-		chunks: make(map[ChunkPos]*Chunk),
+		id:       newEntityID(),
+		name:     name,
+		uid:      uid,
+		props:    props,
+		conn:     conn,
+		chunks:   make(map[ChunkPos]*Chunk),
+		channels: make(map[string]struct{}),
+		perms:    make(map[string]struct{}),
 	}
 }
 
@@ -45,21 +89,105 @@ func (p *Player) EntityID() ID {
 	return p.id
 }
 
+// Name returns the player's username. This function may be called concurrently.
+func (p *Player) Name() string {
+	return p.name
+}
+
+// UUID returns the player's UUID. This function may be called concurrently.
+func (p *Player) UUID() uuid.UUID {
+	return p.uid
+}
+
+// RemoteAddr returns the network address of the player. This function may be called concurrently.
+func (p *Player) RemoteAddr() net.Addr {
+	return p.conn.RemoteAddr()
+}
+
+// Properties returns the properties of the player's profile. The returned slice must not be modified. This function
+// may be called concurrently.
+func (p *Player) Properties() []ProfileProperty {
+	return p.props
+}
+
+// Latency returns the round-trip time of the player's connection, as measured by the last keep-alive. It returns 0
+// if it has not been measured yet. This function may be called concurrently.
+func (p *Player) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&p.latency))
+}
+
+// Pos returns the current position of the player.
+func (p *Player) Pos() Pos {
+	return p.pos
+}
+
+// World returns the World that the player is in, or nil if they are not in a World.
+func (p *Player) World() *World {
+	return p.world
+}
+
+// Kick disconnects the player from the server, displaying the specified reason. This function may be called
+// concurrently.
+func (p *Player) Kick(reason *chat.Msg) {
+	p.conn.Disconnect(reason)
+}
+
 // Close releases resources associated with the Player.
 func (p *Player) Close() error {
 	p.SetWorld(nil)
 	return nil
 }
 
-// SetWorld moves the player to a different World.
+// SetWorld moves the player to the spawn of a different World. If the player was already in a World, their client is
+// sent to the new World using a Respawn packet, which discards all chunks of the old World. A nil World removes the
+// player from their current World without notifying the client.
 func (p *Player) SetWorld(w *World) {
-	if p.world != nil {
-		p.world.RemoveEntity(p.id)
+	old := p.world
+	if old != nil {
+		old.RemoveEntity(p.id)
 	}
 
 	p.world = w
-	if w != nil {
-		w.AddEntity(p)
+	if w == nil {
+		return
+	}
+	w.AddEntity(p)
+
+	// the JoinGame packet already contains the settings of the first World
+	if old == nil {
+		p.dimension = w.settings.Dimension
+	} else {
+		p.respawn(w.settings)
+	}
+	p.Teleport(w.Spawn())
+}
+
+// respawn sends the Respawn packet for a World with the specified settings. Clients do not reload the world if the
+// dimension does not change, so in that case the player is sent to a different dimension first.
+func (p *Player) respawn(s WorldSettings) {
+	if s.Dimension == p.dimension {
+		bounce := DimensionNether
+		if p.dimension == DimensionNether {
+			bounce = DimensionOverworld
+		}
+		p.conn.WritePacket(&outbound.Respawn{
+			Dimension:  int32(bounce),
+			Difficulty: uint8(s.Difficulty),
+			Gamemode:   1,
+			LevelType:  string(s.LevelType),
+		})
+	}
+	p.conn.WritePacket(&outbound.Respawn{
+		Dimension:  int32(s.Dimension),
+		Difficulty: uint8(s.Difficulty),
+		Gamemode:   1,
+		LevelType:  string(s.LevelType),
+	})
+	p.dimension = s.Dimension
+
+	// the client has discarded all chunks, so the new ones can be sent without unloading the old ones
+	for pos := range p.chunks {
+		delete(p.chunks, pos)
 	}
 }
 
@@ -67,21 +195,94 @@ func (p *Player) SetWorld(w *World) {
 func (p *Player) Teleport(pos Pos) {
 	p.pos = pos
 	p.updateChunks()
+
+	p.lastTeleportID++
+	p.teleportPending = p.conn.Version() >= protocol.Version1_9
 	p.conn.WritePacket(&outbound.Position{
-		X:     pos.X,
-		Y:     pos.Y + PlayerEyeHeight,
-		Z:     pos.Z,
-		Yaw:   pos.Yaw,
-		Pitch: pos.Pitch,
+		X:          pos.X,
+		Y:          pos.Y,
+		Z:          pos.Z,
+		Yaw:        pos.Yaw,
+		Pitch:      pos.Pitch,
+		TeleportID: p.lastTeleportID,
 	})
 }
 
 func (p *Player) tick() {
+	p.tickKeepAlive(time.Now())
+}
+
+// tickKeepAlive sends a new keep-alive if the keep-alive interval has passed, or kicks the player if they did not
+// respond to the previous one in time.
+func (p *Player) tickKeepAlive(now time.Time) {
+	if p.game == nil {
+		return
+	}
+
+	cfg := &p.game.cfg
+	if p.keepAlivePending {
+		if now.Sub(p.keepAliveSent) > cfg.KeepAliveTimeout {
+			p.conn.Disconnect(&chat.Msg{Text: "Timed out"})
+		}
+		return
+	}
+	if now.Sub(p.keepAliveSent) < cfg.KeepAliveInterval {
+		return
+	}
+
+	// older versions encode the ID as a 32-bit integer
+	if p.conn.Version() < protocol.Version1_12_2 {
+		p.keepAliveID = int64(p.game.rand.Int31())
+	} else {
+		p.keepAliveID = p.game.rand.Int63()
+	}
+	p.keepAliveSent = now
+	p.keepAlivePending = true
 	p.conn.WritePacket(&outbound.KeepAlive{
-		ID: 0,
+		ID: p.keepAliveID,
 	})
 }
 
+// playerListEntry returns the entry for this player in the player list.
+func (p *Player) playerListEntry() outbound.PlayerListEntry {
+	props := make([]outbound.PlayerListProperty, len(p.props))
+	for i, prop := range p.props {
+		props[i] = outbound.PlayerListProperty{
+			Name:      prop.Name,
+			Value:     prop.Value,
+			Signature: prop.Signature,
+		}
+	}
+
+	return outbound.PlayerListEntry{
+		UUID:       p.uid,
+		Name:       p.name,
+		Properties: props,
+		// creative, the same as in the JoinGame packet
+		Gamemode: 1,
+		Latency:  int32(p.Latency() / time.Millisecond),
+	}
+}
+
+// sendPlayerList sends a player list update to the player. Versions older than 1.8 only support a single entry per
+// packet, so a packet is sent for each entry.
+func (p *Player) sendPlayerList(action outbound.PlayerListAction, entries []outbound.PlayerListEntry) {
+	if p.conn.Version() >= protocol.Version1_8 {
+		p.conn.WritePacket(&outbound.PlayerListItem{
+			Action:  action,
+			Entries: entries,
+		})
+		return
+	}
+
+	for _, e := range entries {
+		p.conn.WritePacket(&outbound.PlayerListItem{
+			Action:  action,
+			Entries: []outbound.PlayerListEntry{e},
+		})
+	}
+}
+
 // updateChunks updates the chunks map for the player based on their current position.
 func (p *Player) updateChunks() {
 	// TODO properly calculate view distance
@@ -91,18 +292,19 @@ func (p *Player) updateChunks() {
 	// unload old chunks
 	for pos := range p.chunks {
 		if center.Dist(pos) > viewDist {
-			p.conn.WritePacket(&outbound.ChunkData{
-				X:         pos.X,
-				Z:         pos.Z,
-				FullChunk: true,
-				Mask:      0,
-			})
+			p.unloadChunk(pos)
 			delete(p.chunks, pos)
 		}
 	}
 
-	pk := &outbound.BulkChunkData{
-		SkyLightIncluded: true,
+	// versions older than 1.9 can receive multiple chunks in a single packet
+	v := p.conn.Version()
+	dim := p.dimension
+	var bulk *outbound.BulkChunkData
+	if v < protocol.Version1_9 {
+		bulk = &outbound.BulkChunkData{
+			SkyLightIncluded: dim.hasSkyLight(),
+		}
 	}
 
 	// load new chunks
@@ -114,27 +316,74 @@ func (p *Player) updateChunks() {
 			}
 
 			c := p.world.GetChunk(pos)
-			if c != nil {
-				pk.ChunkCount++
-				pk.Meta = append(pk.Meta, outbound.BulkChunkDataMeta{
-					X:           x,
-					Z:           z,
-					SectionMask: c.sectionMask,
-				})
-				pk.Data = c.appendData(pk.Data)
-				p.chunks[pos] = c
-
-				if pk.ChunkCount == 10 {
-					p.conn.WritePacket(pk)
-					pk = &outbound.BulkChunkData{
-						SkyLightIncluded: true,
-					}
+			if c == nil {
+				continue
+			}
+			p.chunks[pos] = c
+
+			if bulk == nil {
+				// the packets are cached by the chunk, so they do not have to be encoded again for every player
+				p.conn.WritePacket(c.packet(pos, v, dim))
+				continue
+			}
+
+			bulk.ChunkCount++
+			bulk.Meta = append(bulk.Meta, outbound.BulkChunkDataMeta{
+				X:           x,
+				Z:           z,
+				SectionMask: c.sectionMask,
+			})
+			bulk.Data = c.appendData(bulk.Data, v, dim)
+			if bulk.ChunkCount == maxBulkChunks {
+				p.conn.WritePacket(bulk)
+				bulk = &outbound.BulkChunkData{
+					SkyLightIncluded: dim.hasSkyLight(),
 				}
 			}
 		}
 	}
 
-	if pk.ChunkCount > 0 {
-		p.conn.WritePacket(pk)
+	if bulk != nil && bulk.ChunkCount > 0 {
+		p.conn.WritePacket(bulk)
 	}
 }
+
+// sendBlockChanges sends changes to blocks in a chunk that the player has loaded.
+func (p *Player) sendBlockChanges(pos ChunkPos, c *Chunk, records []outbound.BlockChangeRecord) {
+	switch {
+	case len(records) == 1:
+		r := records[0]
+		p.conn.WritePacket(&outbound.BlockChange{
+			X:    pos.X<<4 | int32(r.X),
+			Y:    r.Y,
+			Z:    pos.Z<<4 | int32(r.Z),
+			Data: r.Data,
+		})
+	case len(records) <= maxMultiBlockChange:
+		p.conn.WritePacket(&outbound.MultiBlockChange{
+			X:       pos.X,
+			Z:       pos.Z,
+			Records: records,
+		})
+	default:
+		p.conn.WritePacket(c.packet(pos, p.conn.Version(), p.dimension))
+	}
+}
+
+// unloadChunk unloads the chunk at the specified position on the client.
+func (p *Player) unloadChunk(pos ChunkPos) {
+	if p.conn.Version() >= protocol.Version1_9 {
+		p.conn.WritePacket(&outbound.UnloadChunk{
+			X: pos.X,
+			Z: pos.Z,
+		})
+		return
+	}
+
+	p.conn.WritePacket(&outbound.ChunkData{
+		X:         pos.X,
+		Z:         pos.Z,
+		FullChunk: true,
+		Mask:      0,
+	})
+}
@@ -0,0 +1,191 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+	"github.com/google/uuid"
+)
+
+// testConn is a PlayerConn that records the packets that are sent to it.
+type testConn struct {
+	packets      []packet.Outbound
+	disconnected *chat.Msg
+	// version is the protocol version of the client, or Version1_8 if it is not set.
+	version protocol.Version
+}
+
+func (c *testConn) WritePacket(pk packet.Outbound) {
+	c.packets = append(c.packets, pk)
+}
+
+func (c *testConn) Disconnect(reason *chat.Msg) {
+	c.disconnected = reason
+}
+
+func (c *testConn) Version() protocol.Version {
+	if c.version == 0 {
+		return protocol.Version1_8
+	}
+	return c.version
+}
+
+func (c *testConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// lastKeepAlive returns the last keep-alive that was sent, or nil if there is none.
+func (c *testConn) lastKeepAlive() *outbound.KeepAlive {
+	for i := len(c.packets) - 1; i >= 0; i-- {
+		if k, ok := c.packets[i].(*outbound.KeepAlive); ok {
+			return k
+		}
+	}
+	return nil
+}
+
+func newTestGame() *Game {
+	return NewGame([]*World{NewWorld(nil)}, Config{})
+}
+
+// newTestPlayer creates a Player and adds them to the Game and its default World.
+func newTestPlayer(g *Game) (*Player, *testConn) {
+	c := &testConn{}
+	p := NewPlayer("test", uuid.New(), nil, c)
+	g.AddPlayer(p)
+	return p, c
+}
+
+func TestPlayer_KeepAlive(t *testing.T) {
+	p, c := newTestPlayer(newTestGame())
+
+	now := time.Now()
+	p.tickKeepAlive(now)
+	k := c.lastKeepAlive()
+	if k == nil {
+		t.Fatal("Expected a keep-alive")
+	}
+
+	// no new keep-alive should be sent while waiting for the response
+	p.tickKeepAlive(now.Add(DefaultKeepAliveInterval))
+	if c.lastKeepAlive() != k {
+		t.Error("Expected no new keep-alive")
+	}
+
+	p.keepAliveSent = time.Now().Add(-50 * time.Millisecond)
+	p.HandlePacket(&inbound.KeepAlive{ID: k.ID})
+	if c.disconnected != nil {
+		t.Fatalf("Unexpected disconnect: %s", c.disconnected)
+	}
+	if p.Latency() < 50*time.Millisecond {
+		t.Errorf("Expected a latency of at least 50ms, got %s", p.Latency())
+	}
+	pk, ok := c.packets[len(c.packets)-1].(*outbound.PlayerListItem)
+	if !ok || pk.Action != outbound.PlayerListUpdateLatency {
+		t.Errorf("Expected a latency update, got %#v", c.packets[len(c.packets)-1])
+	}
+}
+
+func TestPlayer_KeepAlive_WrongID(t *testing.T) {
+	p, c := newTestPlayer(newTestGame())
+
+	p.tickKeepAlive(time.Now())
+	p.HandlePacket(&inbound.KeepAlive{ID: c.lastKeepAlive().ID + 1})
+	if c.disconnected == nil {
+		t.Error("Expected the player to be kicked")
+	}
+}
+
+func TestPlayer_KeepAlive_Timeout(t *testing.T) {
+	p, c := newTestPlayer(newTestGame())
+
+	now := time.Now()
+	p.tickKeepAlive(now)
+	p.tickKeepAlive(now.Add(DefaultKeepAliveTimeout - time.Second))
+	if c.disconnected != nil {
+		t.Fatal("Expected the player not to be kicked yet")
+	}
+	p.tickKeepAlive(now.Add(DefaultKeepAliveTimeout + time.Second))
+	if c.disconnected == nil {
+		t.Error("Expected the player to be kicked")
+	}
+}
+
+func TestPlayer_Teleport(t *testing.T) {
+	tests := []struct {
+		version protocol.Version
+		y       float64
+	}{
+		{protocol.Version1_7_2, 64 + PlayerEyeHeight},
+		{protocol.Version1_8, 64 + PlayerEyeHeight},
+		{protocol.Version1_9, 64},
+	}
+	for _, test := range tests {
+		c := &testConn{version: test.version}
+		p := NewPlayer("test", uuid.New(), nil, c)
+		newTestGame().AddPlayer(p)
+		p.Teleport(Pos{X: 0.5, Y: 64, Z: 0.5})
+
+		pk := c.packets[len(c.packets)-1].(*outbound.Position)
+		var buf bytes.Buffer
+		if err := pk.MarshalPacket(&buf, test.version); err != nil {
+			t.Fatal(err)
+		}
+		if y := math.Float64frombits(binary.BigEndian.Uint64(buf.Bytes()[8:])); y != test.y {
+			t.Errorf("%v: expected Y %v, got %v", test.version, test.y, y)
+		}
+	}
+}
+
+func TestPlayer_updateChunks(t *testing.T) {
+	chunks := make(map[ChunkPos]*Chunk)
+	for x := int32(-2); x <= 2; x++ {
+		for z := int32(-2); z <= 2; z++ {
+			chunks[ChunkPos{X: x, Z: z}] = NewChunk()
+		}
+	}
+
+	tests := []struct {
+		version protocol.Version
+		// packets is the number of packets that are expected to be used for the 25 chunks
+		packets int
+	}{
+		{protocol.Version1_7_2, 3},
+		{protocol.Version1_8, 3},
+		{protocol.Version1_9, 25},
+	}
+	for _, test := range tests {
+		g := NewGame([]*World{NewWorld(chunks)}, Config{})
+		c := &testConn{version: test.version}
+		p := NewPlayer("test", uuid.New(), nil, c)
+		p.pos = Pos{X: 8, Z: 8}
+		p.world = g.DefaultWorld()
+		p.updateChunks()
+
+		var bulk, single int
+		for _, pk := range c.packets {
+			switch pk.(type) {
+			case *outbound.BulkChunkData:
+				bulk++
+			case *packet.Encoded:
+				single++
+			}
+		}
+		if bulk+single != test.packets || test.version < protocol.Version1_9 && single != 0 {
+			t.Errorf("%v: expected %d packets, got %d bulk and %d single chunk packets", test.version, test.packets,
+				bulk, single)
+		}
+		if len(p.chunks) != len(chunks) {
+			t.Errorf("%v: expected %d loaded chunks, got %d", test.version, len(chunks), len(p.chunks))
+		}
+	}
+}
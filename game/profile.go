@@ -0,0 +1,9 @@
+package game
+
+// ProfileProperty is a property of a player's profile, such as the "textures" property which contains their skin.
+type ProfileProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Signature is the base64 encoded signature of Value, or empty if the property is not signed.
+	Signature string `json:"signature,omitempty"`
+}
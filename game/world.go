@@ -1,19 +1,68 @@
 package game
 
+import (
+	"github.com/gitfyu/mable/block"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+	"github.com/gitfyu/mable/nbt"
+)
+
+// DefaultSpawn is the spawn of a World that is created using NewWorld.
+var DefaultSpawn = Pos{
+	X: 8,
+	Y: 16,
+	Z: 8,
+}
+
 // World represents a world within the server.
 type World struct {
+	// name is the name under which the World is registered in a Game, or empty if it is not registered.
+	name     string
 	chunks   map[ChunkPos]*Chunk
 	entities map[ID]Entity
+	spawn    Pos
+	settings WorldSettings
+	// generator creates the chunks that are not in chunks, or is nil if there are no other chunks. The generated chunks
+	// are stored in generated, until they are modified, at which point they are moved to chunks.
+	generator ChunkGenerator
+	generated *chunkCache
 }
 
-// NewWorld constructs a new World containing predefined chunks.
+// NewWorld constructs a new World containing predefined chunks, using DefaultWorldSettings.
 func NewWorld(chunks map[ChunkPos]*Chunk) *World {
 	return &World{
 		chunks:   chunks,
 		entities: make(map[ID]Entity),
+		spawn:    DefaultSpawn,
+		settings: DefaultWorldSettings,
 	}
 }
 
+// Name returns the name under which the World is registered in a Game, or an empty string if it is not registered.
+func (w *World) Name() string {
+	return w.name
+}
+
+// Settings returns the settings of the World.
+func (w *World) Settings() WorldSettings {
+	return w.settings
+}
+
+// SetSettings changes the settings of the World. Players that are already in the World are not affected until they
+// join it again.
+func (w *World) SetSettings(s WorldSettings) {
+	w.settings = s
+}
+
+// Spawn returns the position at which players join the World.
+func (w *World) Spawn() Pos {
+	return w.spawn
+}
+
+// SetSpawn changes the position at which players join the World.
+func (w *World) SetSpawn(pos Pos) {
+	w.spawn = pos
+}
+
 // AddEntity adds an Entity to the world.
 func (w *World) AddEntity(e Entity) {
 	w.entities[e.EntityID()] = e
@@ -25,9 +74,146 @@ func (w *World) RemoveEntity(id ID) {
 	delete(w.entities, id)
 }
 
-// GetChunk gets the Chunk at the specified position, or nil if it does not exist.
+// SetGenerator sets the ChunkGenerator that creates the chunks that do not exist in the World, or removes it if gen is
+// nil. Up to cacheSize generated chunks are kept in memory, or DefaultChunkCacheSize if it is zero or negative.
+// Generated chunks that are modified using SetBlocks are always kept, the others are generated again when they are
+// needed after they have been evicted. Chunks that were generated by the previous generator are discarded.
+func (w *World) SetGenerator(gen ChunkGenerator, cacheSize int) {
+	if cacheSize <= 0 {
+		cacheSize = DefaultChunkCacheSize
+	}
+
+	w.generator = gen
+	w.generated = nil
+	if gen != nil {
+		w.generated = newChunkCache(cacheSize)
+	}
+}
+
+// GetChunk gets the Chunk at the specified position. If it does not exist, it is created by the ChunkGenerator of the
+// World. If there is no ChunkGenerator, or if it did not create the Chunk, nil is returned.
 func (w *World) GetChunk(pos ChunkPos) *Chunk {
-	return w.chunks[pos]
+	if c, ok := w.chunks[pos]; ok || w.generator == nil {
+		return c
+	}
+
+	c, ok := w.generated.get(pos)
+	if !ok {
+		c = w.generator.GenerateChunk(pos)
+		w.generated.put(pos, c)
+	}
+	return c
+}
+
+// modifiableChunk returns the Chunk at the specified position for a modification. Generated chunks are moved out of
+// the cache, so the modification is not lost when the Chunk is evicted. If the Chunk does not exist, a new one is
+// created.
+func (w *World) modifiableChunk(pos ChunkPos) *Chunk {
+	c := w.chunks[pos]
+	if c != nil {
+		return c
+	}
+
+	if w.generator != nil {
+		c = w.GetChunk(pos)
+		w.generated.remove(pos)
+	}
+	if c == nil {
+		c = NewChunk()
+	}
+	if w.chunks == nil {
+		w.chunks = make(map[ChunkPos]*Chunk)
+	}
+	w.chunks[pos] = c
+	return c
+}
+
+// maxMultiBlockChange is the maximum number of changed blocks in a chunk that are sent to players individually. If more
+// blocks are changed, the entire chunk is sent again instead, like vanilla does.
+const maxMultiBlockChange = 64
+
+// BlockChange is a change to a single block in a World, see World.SetBlocks.
+type BlockChange struct {
+	X, Y, Z int32
+	Data    block.Data
+	// BlockEntity is the data of the block entity of the new block, or nil if it does not have one.
+	BlockEntity nbt.Compound
+}
+
+// SetBlock changes the block at the specified world coordinates. See SetBlocks for details.
+func (w *World) SetBlock(x, y, z int32, data block.Data) {
+	w.SetBlocks([]BlockChange{{X: x, Y: y, Z: z, Data: data}})
+}
+
+// SetBlocks changes blocks in the World and sends the changes to the players that have loaded the affected chunks.
+// Chunks that do not exist are generated or created, and changes of which Y is not within the range [0,255] are
+// ignored. The block entity at each changed position is replaced by BlockChange.BlockEntity.
+func (w *World) SetBlocks(changes []BlockChange) {
+	changed := make(map[ChunkPos][]outbound.BlockChangeRecord)
+	for _, ch := range changes {
+		if ch.Y < 0 || ch.Y > 255 {
+			continue
+		}
+
+		pos := ChunkPos{X: ch.X >> 4, Z: ch.Z >> 4}
+		c := w.modifiableChunk(pos)
+
+		rec := outbound.BlockChangeRecord{
+			X:    uint8(ch.X & 15),
+			Y:    uint8(ch.Y),
+			Z:    uint8(ch.Z & 15),
+			Data: ch.Data,
+		}
+		c.SetBlock(rec.X, rec.Y, rec.Z, rec.Data)
+		c.SetBlockEntity(rec.X, rec.Y, rec.Z, ch.BlockEntity)
+		changed[pos] = append(changed[pos], rec)
+	}
+
+	players := w.players()
+	for pos, records := range changed {
+		c := w.chunks[pos]
+		for _, p := range players {
+			// the player may have received an evicted copy of a generated chunk, which has the same blocks
+			if _, ok := p.chunks[pos]; ok {
+				p.sendBlockChanges(pos, c, records)
+			}
+		}
+	}
+}
+
+// WorldSnapshot is a copy of the state of a World at a point in time. Since it does not share any data with the World,
+// it can be used from any goroutine, for example to save the World without blocking the game.
+type WorldSnapshot struct {
+	Name     string
+	Chunks   map[ChunkPos]*Chunk
+	Spawn    Pos
+	Settings WorldSettings
+}
+
+// Snapshot copies the chunks, spawn and settings of the World. Generated chunks are only included if they have been
+// modified, since the others can be generated again.
+func (w *World) Snapshot() *WorldSnapshot {
+	chunks := make(map[ChunkPos]*Chunk, len(w.chunks))
+	for pos, c := range w.chunks {
+		chunks[pos] = c.Clone()
+	}
+	return &WorldSnapshot{
+		Name:     w.name,
+		Chunks:   chunks,
+		Spawn:    w.spawn,
+		Settings: w.settings,
+	}
+}
+
+// players returns the players in the World.
+func (w *World) players() []*Player {
+	var players []*Player
+	for _, e := range w.entities {
+		if p, ok := e.(*Player); ok {
+			players = append(players, p)
+		}
+	}
+	return players
 }
 
 // tick updates the World.
@@ -0,0 +1,50 @@
+package game
+
+// Dimension determines the sky and lighting of a World on the client.
+type Dimension int8
+
+const (
+	DimensionNether    Dimension = -1
+	DimensionOverworld Dimension = 0
+	DimensionEnd       Dimension = 1
+)
+
+// hasSkyLight returns whether the chunks of a World in the Dimension contain skylight data, which is only the case for
+// the overworld.
+func (d Dimension) hasSkyLight() bool {
+	return d == DimensionOverworld
+}
+
+// Difficulty is the difficulty displayed to clients.
+type Difficulty uint8
+
+const (
+	DifficultyPeaceful Difficulty = iota
+	DifficultyEasy
+	DifficultyNormal
+	DifficultyHard
+)
+
+// LevelType determines how the client renders the horizon of a World.
+type LevelType string
+
+const (
+	LevelTypeDefault     LevelType = "default"
+	LevelTypeFlat        LevelType = "flat"
+	LevelTypeLargeBiomes LevelType = "largeBiomes"
+	LevelTypeAmplified   LevelType = "amplified"
+)
+
+// WorldSettings contains the properties of a World that are sent to the clients of the players in it.
+type WorldSettings struct {
+	Dimension  Dimension
+	Difficulty Difficulty
+	LevelType  LevelType
+}
+
+// DefaultWorldSettings are the settings of a World that is created using NewWorld.
+var DefaultWorldSettings = WorldSettings{
+	Dimension:  DimensionOverworld,
+	Difficulty: DifficultyEasy,
+	LevelType:  LevelTypeFlat,
+}
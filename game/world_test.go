@@ -0,0 +1,210 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/internal/protocol"
+	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+	"github.com/gitfyu/mable/nbt"
+)
+
+func TestGame_AddWorld(t *testing.T) {
+	g := NewGame([]*World{NewWorld(nil), NewWorld(nil)}, Config{})
+	if expect := []string{"world", "world_1"}; !reflect.DeepEqual(expect, g.WorldNames()) {
+		t.Errorf("Expected worlds %v, got %v", expect, g.WorldNames())
+	}
+
+	nether := NewWorld(nil)
+	if err := g.AddWorld("nether", nether); err != nil {
+		t.Fatal(err)
+	}
+	if g.World("nether") != nether || nether.Name() != "nether" {
+		t.Error("Expected the world to be registered as nether")
+	}
+	if err := g.AddWorld("nether", NewWorld(nil)); err == nil {
+		t.Error("Expected an error for a duplicate name")
+	}
+	if err := g.AddWorld("other", nether); err == nil {
+		t.Error("Expected an error for a world that is already registered")
+	}
+}
+
+func TestGame_Schedule_Closed(t *testing.T) {
+	g := NewGame([]*World{NewWorld(nil)}, Config{MaxJobs: 1})
+	g.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			g.Schedule(func() {})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Schedule not to block after Close")
+	}
+}
+
+func TestGame_RemoveWorld(t *testing.T) {
+	g := newTestGame()
+	w := NewWorld(nil)
+	if err := g.AddWorld("other", w); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := newTestPlayer(g)
+	p.SetWorld(w)
+
+	if err := g.RemoveWorld(DefaultWorldName); err == nil {
+		t.Error("Expected an error when removing the default world")
+	}
+	if err := g.RemoveWorld("other"); err != nil {
+		t.Fatal(err)
+	}
+	if g.World("other") != nil || w.Name() != "" {
+		t.Error("Expected the world to be removed")
+	}
+	if p.World() != g.DefaultWorld() {
+		t.Error("Expected the player to be moved to the default world")
+	}
+	if err := g.RemoveWorld("other"); err == nil {
+		t.Error("Expected an error when removing a world that does not exist")
+	}
+}
+
+// respawns returns the dimensions of all Respawn packets that were sent.
+func respawns(c *testConn) []int32 {
+	var dims []int32
+	for _, pk := range c.packets {
+		if r, ok := pk.(*outbound.Respawn); ok {
+			dims = append(dims, r.Dimension)
+		}
+	}
+	return dims
+}
+
+func TestPlayer_SetWorld(t *testing.T) {
+	g := newTestGame()
+	p, c := newTestPlayer(g)
+	if len(respawns(c)) != 0 {
+		t.Error("Expected no Respawn when joining the first world")
+	}
+
+	pos := ChunkPosFromWorldCoords(DefaultSpawn.X, DefaultSpawn.Z)
+	chunk := NewChunk()
+	overworld := NewWorld(map[ChunkPos]*Chunk{pos: chunk})
+	nether := NewWorld(nil)
+	nether.SetSettings(WorldSettings{Dimension: DimensionNether, LevelType: LevelTypeDefault})
+
+	// the same dimension requires a bounce to another dimension
+	p.SetWorld(overworld)
+	if expect := []int32{-1, 0}; !reflect.DeepEqual(expect, respawns(c)) {
+		t.Errorf("Expected respawns %v, got %v", expect, respawns(c))
+	}
+	if p.Pos() != overworld.Spawn() {
+		t.Errorf("Expected the player at %v, got %v", overworld.Spawn(), p.Pos())
+	}
+	if p.chunks[pos] != chunk {
+		t.Error("Expected the chunks of the new world to be sent")
+	}
+
+	c.packets = nil
+	p.SetWorld(nether)
+	if expect := []int32{-1}; !reflect.DeepEqual(expect, respawns(c)) {
+		t.Errorf("Expected respawns %v, got %v", expect, respawns(c))
+	}
+	if len(overworld.players()) != 0 || len(nether.players()) != 1 {
+		t.Error("Expected the player to be moved to the nether")
+	}
+	if len(p.chunks) != 0 {
+		t.Errorf("Expected the chunks of the old world to be unloaded, got %d", len(p.chunks))
+	}
+}
+
+func TestPlayer_SetWorld_ChangedDimension(t *testing.T) {
+	g := newTestGame()
+	p, c := newTestPlayer(g)
+
+	// the client is still in the overworld, so moving to another overworld requires a bounce
+	g.DefaultWorld().SetSettings(WorldSettings{Dimension: DimensionNether, LevelType: LevelTypeDefault})
+	p.SetWorld(NewWorld(nil))
+	if expect := []int32{-1, 0}; !reflect.DeepEqual(expect, respawns(c)) {
+		t.Errorf("Expected respawns %v, got %v", expect, respawns(c))
+	}
+}
+
+func TestWorld_Snapshot(t *testing.T) {
+	c := NewChunk()
+	c.SetBlock(0, 0, 0, block.Stone.ToData())
+	w := NewWorld(map[ChunkPos]*Chunk{{X: 0, Z: 0}: c})
+
+	s := w.Snapshot()
+	c.SetBlock(0, 0, 0, block.Stone.ToDataWithMetadata(1))
+	c.SetBiome(0, 0, 2)
+
+	sc := s.Chunks[ChunkPos{X: 0, Z: 0}]
+	if b := sc.Block(0, 0, 0); b != block.Stone.ToData() {
+		t.Errorf("Expected the snapshot to be unaffected by SetBlock, got %v", b)
+	}
+	if b := sc.Biome(0, 0); b != biome.Plains {
+		t.Errorf("Expected the snapshot to be unaffected by SetBiome, got %v", b)
+	}
+	if s.Spawn != DefaultSpawn || s.Settings != DefaultWorldSettings {
+		t.Errorf("Expected the default spawn and settings, got %v and %v", s.Spawn, s.Settings)
+	}
+}
+
+func TestWorld_SetBlocks(t *testing.T) {
+	c := NewChunk()
+	w := NewWorld(map[ChunkPos]*Chunk{{X: 0, Z: 0}: c})
+	g := NewGame([]*World{w}, Config{})
+	_, conn := newTestPlayer(g)
+
+	conn.packets = nil
+	w.SetBlock(1, 2, 3, block.Stone.ToData())
+	if b := c.Block(1, 2, 3); b != block.Stone.ToData() {
+		t.Errorf("Expected stone, got %v", b)
+	}
+	if len(conn.packets) != 1 {
+		t.Fatalf("Expected 1 packet, got %d", len(conn.packets))
+	}
+	if pk, ok := conn.packets[0].(*outbound.BlockChange); !ok || pk.X != 1 || pk.Y != 2 || pk.Z != 3 {
+		t.Errorf("Expected a BlockChange at 1,2,3, got %#v", conn.packets[0])
+	}
+
+	conn.packets = nil
+	w.SetBlocks([]BlockChange{
+		{X: 4, Y: 5, Z: 6, Data: block.Stone.ToData(), BlockEntity: nbt.Compound{"id": "Chest"}},
+		{X: 7, Y: 8, Z: 9, Data: block.Stone.ToData()},
+		// not loaded by the player, so the chunk is created without sending it
+		{X: 1000, Y: 0, Z: 0, Data: block.Stone.ToData()},
+		{X: 0, Y: 256, Z: 0, Data: block.Stone.ToData()},
+	})
+	if len(conn.packets) != 1 {
+		t.Fatalf("Expected 1 packet, got %d", len(conn.packets))
+	}
+	if pk, ok := conn.packets[0].(*outbound.MultiBlockChange); !ok || len(pk.Records) != 2 {
+		t.Errorf("Expected a MultiBlockChange with 2 records, got %#v", conn.packets[0])
+	}
+	if c.BlockEntity(4, 5, 6) == nil {
+		t.Error("Expected the block entity to be set")
+	}
+	if w.GetChunk(ChunkPos{X: 62, Z: 0}) == nil {
+		t.Error("Expected the missing chunk to be created")
+	}
+
+	conn.packets = nil
+	var changes []BlockChange
+	for i := int32(0); i <= maxMultiBlockChange; i++ {
+		changes = append(changes, BlockChange{X: i & 15, Y: i >> 4, Z: 0, Data: block.Stone.ToData()})
+	}
+	w.SetBlocks(changes)
+	if len(conn.packets) != 1 || conn.packets[0] != c.packet(ChunkPos{}, protocol.Version1_8, DimensionOverworld) {
+		t.Errorf("Expected the chunk to be sent again, got %v", conn.packets)
+	}
+}
@@ -0,0 +1,37 @@
+// Package atomicfile writes files atomically, so they are never left partially written.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Write writes a file by calling write with a temporary file, which replaces the file at path once it has been written
+// and synced successfully. The temporary file is created in the same directory, so it can be renamed, and it is
+// removed if writing fails.
+func Write(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// temporary files are only readable by the owner
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
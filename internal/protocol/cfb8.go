@@ -0,0 +1,62 @@
+package protocol
+
+import "crypto/cipher"
+
+// cfb8 implements cipher.Stream using 8-bit cipher feedback mode, which is what Minecraft uses to encrypt connections.
+// The standard library only provides CFB with a segment size equal to the block size, so it cannot be used here.
+type cfb8 struct {
+	block cipher.Block
+	// register is the shift register, it always contains the last block size bytes of ciphertext.
+	register []byte
+	// out is a buffer for the output of block.Encrypt.
+	out     []byte
+	decrypt bool
+}
+
+// NewCFB8Encrypter returns a cipher.Stream which encrypts using 8-bit cipher feedback mode. The length of iv must be
+// equal to the block size.
+func NewCFB8Encrypter(block cipher.Block, iv []byte) cipher.Stream {
+	return newCFB8(block, iv, false)
+}
+
+// NewCFB8Decrypter returns a cipher.Stream which decrypts using 8-bit cipher feedback mode. The length of iv must be
+// equal to the block size.
+func NewCFB8Decrypter(block cipher.Block, iv []byte) cipher.Stream {
+	return newCFB8(block, iv, true)
+}
+
+func newCFB8(block cipher.Block, iv []byte, decrypt bool) *cfb8 {
+	if len(iv) != block.BlockSize() {
+		panic("IV length must equal block size")
+	}
+
+	x := &cfb8{
+		block:    block,
+		register: make([]byte, len(iv)),
+		out:      make([]byte, len(iv)),
+		decrypt:  decrypt,
+	}
+	copy(x.register, iv)
+	return x
+}
+
+// XORKeyStream implements cipher.Stream.
+func (x *cfb8) XORKeyStream(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("output smaller than input")
+	}
+
+	last := len(x.register) - 1
+	for i, in := range src {
+		x.block.Encrypt(x.out, x.register)
+		out := in ^ x.out[0]
+		dst[i] = out
+
+		copy(x.register, x.register[1:])
+		if x.decrypt {
+			x.register[last] = in
+		} else {
+			x.register[last] = out
+		}
+	}
+}
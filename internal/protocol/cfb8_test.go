@@ -0,0 +1,57 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// Obtained from NIST SP 800-38A, section F.3.7
+const (
+	cfb8Key        = "2b7e151628aed2a6abf7158809cf4f3c"
+	cfb8IV         = "000102030405060708090a0b0c0d0e0f"
+	cfb8Plaintext  = "6bc1bee22e409f96e93d7e117393172aae2d"
+	cfb8Ciphertext = "3b79424c9c0dd436bace9e0ed4586a4f32b9"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func Test_CFB8Encrypter(t *testing.T) {
+	block, err := aes.NewCipher(decodeHex(t, cfb8Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := decodeHex(t, cfb8Plaintext)
+	dst := make([]byte, len(src))
+	NewCFB8Encrypter(block, decodeHex(t, cfb8IV)).XORKeyStream(dst, src)
+
+	if expect := decodeHex(t, cfb8Ciphertext); !bytes.Equal(expect, dst) {
+		t.Errorf("Expected %x, got %x", expect, dst)
+	}
+}
+
+func Test_CFB8Decrypter(t *testing.T) {
+	block, err := aes.NewCipher(decodeHex(t, cfb8Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// decrypt in place and one byte at a time, the way it happens when reading from a stream
+	buf := decodeHex(t, cfb8Ciphertext)
+	s := NewCFB8Decrypter(block, decodeHex(t, cfb8IV))
+	for i := range buf {
+		s.XORKeyStream(buf[i:i+1], buf[i:i+1])
+	}
+
+	if expect := decodeHex(t, cfb8Plaintext); !bytes.Equal(expect, buf) {
+		t.Errorf("Expected %x, got %x", expect, buf)
+	}
+}
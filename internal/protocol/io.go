@@ -2,12 +2,22 @@ package protocol
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"math"
 
 	"github.com/gitfyu/mable/chat"
 )
 
+var (
+	errNegativeLength = errors.New("negative length")
+	errTooLong        = errors.New("data too long")
+)
+
+// maxArrayLength is the maximum length of a byte array if the number of bytes that are left cannot be determined, which
+// is the maximum size of a packet in the protocol.
+const maxArrayLength = 1<<21 - 1
+
 // Reader combines all interfaces needed to be able to
 // read any datatype in the Minecraft protocol.
 type Reader interface {
@@ -81,6 +91,60 @@ func ReadString(r Reader) (string, error) {
 	return string(b), nil
 }
 
+// ReadByteArray reads a byte array that is prefixed by its length as a VarInt. The length is checked before the array
+// is allocated, so a client cannot make the server allocate more memory than the size of the packet.
+func ReadByteArray(r Reader) ([]byte, error) {
+	len, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkArrayLength(r, int(len)); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, len)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ReadShortByteArray reads a byte array that is prefixed by its length as an uint16. This format is used by protocol
+// versions older than Version1_8. Like ReadByteArray, the length is checked before the array is allocated.
+func ReadShortByteArray(r Reader) ([]byte, error) {
+	len, err := ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkArrayLength(r, int(len)); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, len)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// checkArrayLength returns an error if n is not a valid length for an array that is read from r. If r reports the
+// number of unread bytes using a Len method, like bytes.Buffer and bytes.Reader, the array must fit in those bytes.
+func checkArrayLength(r io.Reader, n int) error {
+	if n < 0 {
+		return errNegativeLength
+	}
+	max := maxArrayLength
+	if l, ok := r.(interface{ Len() int }); ok {
+		max = l.Len()
+	}
+	if n > max {
+		return errTooLong
+	}
+	return nil
+}
+
 func WriteBool(w io.ByteWriter, v bool) error {
 	var err error
 	if v {
@@ -150,6 +214,19 @@ func WriteByteArray(w Writer, b []byte) error {
 	return err
 }
 
+// WriteShortByteArray writes a byte array that is prefixed by its length as an uint16. This format is used by protocol
+// versions older than Version1_8.
+func WriteShortByteArray(w Writer, b []byte) error {
+	if len(b) > math.MaxUint16 {
+		return errTooLong
+	}
+	if err := WriteUint16(w, uint16(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
 func WriteChat(w Writer, v *chat.Msg) error {
 	str, err := json.Marshal(v)
 	if err != nil {
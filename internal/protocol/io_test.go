@@ -0,0 +1,45 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestReadByteArray(t *testing.T) {
+	b, err := ReadByteArray(bytes.NewReader([]byte{0x03, 1, 2, 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{1, 2, 3}) {
+		t.Errorf("Expected [1 2 3], got %v", b)
+	}
+}
+
+func TestReadByteArray_InvalidLength(t *testing.T) {
+	tests := [][]byte{
+		// longer than the remaining bytes
+		{0x04, 1, 2, 3},
+		// 2^31-1
+		{0xff, 0xff, 0xff, 0xff, 0x07},
+		// -1
+		{0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for _, data := range tests {
+		if _, err := ReadByteArray(bytes.NewReader(data)); err == nil {
+			t.Errorf("Expected an error for %v", data)
+		}
+	}
+
+	// the remaining size of a bufio.Reader is unknown, so only the maximum length is checked
+	r := bufio.NewReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x07}))
+	if _, err := ReadByteArray(r); err != errTooLong {
+		t.Errorf("Expected errTooLong, got %v", err)
+	}
+}
+
+func TestReadShortByteArray_InvalidLength(t *testing.T) {
+	if _, err := ReadShortByteArray(bytes.NewReader([]byte{0xff, 0xff, 1, 2, 3})); err != errTooLong {
+		t.Errorf("Expected errTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package packet
+
+import (
+	"compress/zlib"
+	"io"
+	"sync"
+)
+
+// CompressionDisabled can be used as a compression threshold to disable compression.
+const CompressionDisabled = -1
+
+var (
+	// zlibWriters caches *zlib.Writer instances, since they are expensive to create.
+	zlibWriters = sync.Pool{
+		New: func() interface{} {
+			return zlib.NewWriter(nil)
+		},
+	}
+
+	// zlibReaders caches io.ReadCloser instances created by zlib.NewReader, which also implement zlib.Resetter. The
+	// pool has no New function, since zlib.NewReader needs a valid stream to read from.
+	zlibReaders sync.Pool
+)
+
+// compress appends the zlib compressed form of data to w.
+func compress(w io.Writer, data []byte) error {
+	zw := zlibWriters.Get().(*zlib.Writer)
+	defer zlibWriters.Put(zw)
+
+	zw.Reset(w)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// newDecompressor returns a reader that decompresses the zlib stream from r. It must be released using
+// releaseDecompressor after use.
+func newDecompressor(r io.Reader) (io.ReadCloser, error) {
+	if zr, ok := zlibReaders.Get().(io.ReadCloser); ok {
+		if err := zr.(zlib.Resetter).Reset(r, nil); err != nil {
+			zlibReaders.Put(zr)
+			return nil, err
+		}
+		return zr, nil
+	}
+
+	return zlib.NewReader(r)
+}
+
+// releaseDecompressor returns a reader created by newDecompressor to the pool.
+func releaseDecompressor(zr io.ReadCloser) {
+	zr.Close()
+	zlibReaders.Put(zr)
+}
@@ -0,0 +1,54 @@
+package packet
+
+import (
+	"sync"
+
+	"github.com/gitfyu/mable/internal/protocol"
+)
+
+// encodedKey identifies a single encoded form of a packet.
+type encodedKey struct {
+	version   protocol.Version
+	threshold int
+}
+
+// Encoded wraps an Outbound packet and caches its encoded form, including the packet frame and compression, for every
+// combination of protocol.Version and compression threshold it is written with. This allows a packet that is sent to
+// many clients, such as a chunk, to be encoded only once. The wrapped packet must not be modified after it has been
+// wrapped. An Encoded packet may be written by multiple Writer instances concurrently.
+type Encoded struct {
+	pk     Outbound
+	mu     sync.RWMutex
+	frames map[encodedKey][]byte
+}
+
+// NewEncoded wraps an Outbound packet, see Encoded.
+func NewEncoded(pk Outbound) *Encoded {
+	return &Encoded{
+		pk:     pk,
+		frames: make(map[encodedKey][]byte),
+	}
+}
+
+// MarshalPacket implements Outbound.MarshalPacket by marshaling the wrapped packet. Note that a Writer does not use
+// this, instead it writes the cached frame.
+func (e *Encoded) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	return e.pk.MarshalPacket(w, v)
+}
+
+// frame returns the cached frame for the specified key, if it exists.
+func (e *Encoded) frame(k encodedKey) ([]byte, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	f, ok := e.frames[k]
+	return f, ok
+}
+
+// storeFrame caches a frame for the specified key. The frame must not be modified afterwards.
+func (e *Encoded) storeFrame(k encodedKey, f []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.frames[k] = f
+}
@@ -13,12 +13,12 @@ type Handshake struct {
 }
 
 func init() {
-	packet.RegisterInbound(protocol.StateHandshake, 0x00, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StateHandshake, packet.AllVersions(0x00), func() packet.Inbound {
 		return &Handshake{}
 	})
 }
 
-func (h *Handshake) UnmarshalPacket(r protocol.Reader) error {
+func (h *Handshake) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
 	var err error
 
 	if h.ProtoVer, err = protocol.ReadVarInt(r); err != nil {
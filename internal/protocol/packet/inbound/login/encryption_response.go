@@ -0,0 +1,31 @@
+package login
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+type EncryptionResponse struct {
+	SharedSecret []byte
+	VerifyToken  []byte
+}
+
+func init() {
+	packet.RegisterInbound(protocol.StateLogin, packet.AllVersions(0x01), func() packet.Inbound {
+		return &EncryptionResponse{}
+	})
+}
+
+func (e *EncryptionResponse) UnmarshalPacket(r protocol.Reader, v protocol.Version) error {
+	readByteArray := protocol.ReadByteArray
+	if v < protocol.Version1_8 {
+		readByteArray = protocol.ReadShortByteArray
+	}
+
+	var err error
+	if e.SharedSecret, err = readByteArray(r); err != nil {
+		return err
+	}
+	e.VerifyToken, err = readByteArray(r)
+	return err
+}
@@ -10,12 +10,12 @@ type Start struct {
 }
 
 func init() {
-	packet.RegisterInbound(protocol.StateLogin, 0x00, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StateLogin, packet.AllVersions(0x00), func() packet.Inbound {
 		return &Start{}
 	})
 }
 
-func (s *Start) UnmarshalPacket(r protocol.Reader) error {
+func (s *Start) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
 	var err error
 	s.Username, err = protocol.ReadString(r)
 	return err
@@ -0,0 +1,28 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// Chat is sent when the player sends a chat message or command.
+type Chat struct {
+	Message string
+}
+
+func init() {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x01,
+		protocol.Version1_9:    0x02,
+		protocol.Version1_12:   0x03,
+		protocol.Version1_12_1: 0x02,
+	}, func() packet.Inbound {
+		return &Chat{}
+	})
+}
+
+func (c *Chat) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
+	var err error
+	c.Message, err = protocol.ReadString(r)
+	return err
+}
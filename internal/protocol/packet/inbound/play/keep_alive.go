@@ -6,17 +6,33 @@ import (
 )
 
 type KeepAlive struct {
-	ID int32
+	ID int64
 }
 
 func init() {
-	packet.RegisterInbound(protocol.StatePlay, 0x00, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x00,
+		protocol.Version1_9:    0x0B,
+		protocol.Version1_12:   0x0C,
+		protocol.Version1_12_1: 0x0B,
+	}, func() packet.Inbound {
 		return &KeepAlive{}
 	})
 }
 
-func (k *KeepAlive) UnmarshalPacket(r protocol.Reader) error {
-	var err error
-	k.ID, err = protocol.ReadVarInt(r)
-	return err
+func (k *KeepAlive) UnmarshalPacket(r protocol.Reader, v protocol.Version) error {
+	switch {
+	case v < protocol.Version1_8:
+		id, err := protocol.ReadUint32(r)
+		k.ID = int64(int32(id))
+		return err
+	case v < protocol.Version1_12_2:
+		id, err := protocol.ReadVarInt(r)
+		k.ID = int64(id)
+		return err
+	default:
+		id, err := protocol.ReadUint64(r)
+		k.ID = int64(id)
+		return err
+	}
 }
@@ -0,0 +1,41 @@
+package play
+
+import (
+	"io"
+
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// PluginMessage contains a custom payload sent by the client on a plugin channel.
+type PluginMessage struct {
+	Channel string
+	Data    []byte
+}
+
+func init() {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x17,
+		protocol.Version1_9:    0x09,
+		protocol.Version1_12:   0x0A,
+		protocol.Version1_12_1: 0x09,
+	}, func() packet.Inbound {
+		return &PluginMessage{}
+	})
+}
+
+func (m *PluginMessage) UnmarshalPacket(r protocol.Reader, v protocol.Version) error {
+	var err error
+	if m.Channel, err = protocol.ReadString(r); err != nil {
+		return err
+	}
+
+	if v < protocol.Version1_8 {
+		m.Data, err = protocol.ReadShortByteArray(r)
+		return err
+	}
+
+	// the data is not prefixed by its length, it simply fills the rest of the packet
+	m.Data, err = io.ReadAll(r)
+	return err
+}
@@ -0,0 +1,47 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// TabComplete is sent when the player presses tab in the chat. Text contains everything the player has typed.
+type TabComplete struct {
+	Text string
+}
+
+func init() {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x14,
+		protocol.Version1_9:    0x01,
+		protocol.Version1_12:   0x02,
+		protocol.Version1_12_1: 0x01,
+	}, func() packet.Inbound {
+		return &TabComplete{}
+	})
+}
+
+func (t *TabComplete) UnmarshalPacket(r protocol.Reader, v protocol.Version) error {
+	var err error
+	if t.Text, err = protocol.ReadString(r); err != nil {
+		return err
+	}
+	if v < protocol.Version1_8 {
+		return nil
+	}
+
+	if v >= protocol.Version1_9 {
+		// whether the text is always a command, which is the case in command blocks
+		if _, err := protocol.ReadBool(r); err != nil {
+			return err
+		}
+	}
+
+	// the block the player is looking at, which is not used
+	hasPos, err := protocol.ReadBool(r)
+	if err != nil || !hasPos {
+		return err
+	}
+	_, err = protocol.ReadUint64(r)
+	return err
+}
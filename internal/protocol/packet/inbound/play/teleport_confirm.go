@@ -0,0 +1,25 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// TeleportConfirm is sent by the client after it has processed a teleport.
+type TeleportConfirm struct {
+	ID int32
+}
+
+func init() {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_9: 0x00,
+	}, func() packet.Inbound {
+		return &TeleportConfirm{}
+	})
+}
+
+func (t *TeleportConfirm) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
+	var err error
+	t.ID, err = protocol.ReadVarInt(r)
+	return err
+}
@@ -12,21 +12,41 @@ type Update struct {
 }
 
 func init() {
-	packet.RegisterInbound(protocol.StatePlay, 0x03, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x03,
+		protocol.Version1_9:    0x0F,
+		protocol.Version1_12:   0x0D,
+		protocol.Version1_12_1: 0x0C,
+	}, func() packet.Inbound {
 		return &Update{}
 	})
-	packet.RegisterInbound(protocol.StatePlay, 0x04, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x04,
+		protocol.Version1_9:    0x0C,
+		protocol.Version1_12:   0x0E,
+		protocol.Version1_12_1: 0x0D,
+	}, func() packet.Inbound {
 		return &Update{HasPos: true}
 	})
-	packet.RegisterInbound(protocol.StatePlay, 0x05, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x05,
+		protocol.Version1_9:    0x0E,
+		protocol.Version1_12:   0x10,
+		protocol.Version1_12_1: 0x0F,
+	}, func() packet.Inbound {
 		return &Update{HasLook: true}
 	})
-	packet.RegisterInbound(protocol.StatePlay, 0x06, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StatePlay, packet.IDs{
+		protocol.Version1_7_2:  0x06,
+		protocol.Version1_9:    0x0D,
+		protocol.Version1_12:   0x0F,
+		protocol.Version1_12_1: 0x0E,
+	}, func() packet.Inbound {
 		return &Update{HasPos: true, HasLook: true}
 	})
 }
 
-func (p *Update) UnmarshalPacket(r protocol.Reader) error {
+func (p *Update) UnmarshalPacket(r protocol.Reader, v protocol.Version) error {
 	var err error
 	if p.HasPos {
 		if p.X, err = protocol.ReadFloat64(r); err != nil {
@@ -35,6 +55,12 @@ func (p *Update) UnmarshalPacket(r protocol.Reader) error {
 		if p.Y, err = protocol.ReadFloat64(r); err != nil {
 			return err
 		}
+		if v < protocol.Version1_8 {
+			// skip the Y coordinate of the player's head
+			if _, err = protocol.ReadFloat64(r); err != nil {
+				return err
+			}
+		}
 		if p.Z, err = protocol.ReadFloat64(r); err != nil {
 			return err
 		}
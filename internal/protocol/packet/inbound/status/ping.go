@@ -10,12 +10,12 @@ type Ping struct {
 }
 
 func init() {
-	packet.RegisterInbound(protocol.StateStatus, 0x01, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StateStatus, packet.AllVersions(0x01), func() packet.Inbound {
 		return &Ping{}
 	})
 }
 
-func (p *Ping) UnmarshalPacket(r protocol.Reader) error {
+func (p *Ping) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
 	t, err := protocol.ReadUint64(r)
 	p.Time = int64(t)
 	return err
@@ -9,11 +9,11 @@ type Request struct{}
 
 func init() {
 	r := Request{}
-	packet.RegisterInbound(protocol.StateStatus, 0x00, func() packet.Inbound {
+	packet.RegisterInbound(protocol.StateStatus, packet.AllVersions(0x00), func() packet.Inbound {
 		return r
 	})
 }
 
-func (Request) UnmarshalPacket(protocol.Reader) error {
+func (Request) UnmarshalPacket(protocol.Reader, protocol.Version) error {
 	return nil
 }
@@ -3,16 +3,17 @@ package login
 import (
 	"github.com/gitfyu/mable/chat"
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type Disconnect struct {
 	Reason *chat.Msg
 }
 
-func (Disconnect) PacketID() uint {
-	return 0x00
+func init() {
+	packet.RegisterOutbound(&Disconnect{}, packet.AllVersions(0x00))
 }
 
-func (d *Disconnect) MarshalPacket(w protocol.Writer) error {
+func (d *Disconnect) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
 	return protocol.WriteChat(w, d.Reason)
 }
@@ -0,0 +1,34 @@
+package login
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+type EncryptionRequest struct {
+	ServerID    string
+	PublicKey   []byte
+	VerifyToken []byte
+}
+
+func init() {
+	packet.RegisterOutbound(&EncryptionRequest{}, packet.AllVersions(0x01))
+}
+
+func (e *EncryptionRequest) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if err := protocol.WriteString(w, e.ServerID); err != nil {
+		return err
+	}
+
+	if v < protocol.Version1_8 {
+		if err := protocol.WriteShortByteArray(w, e.PublicKey); err != nil {
+			return err
+		}
+		return protocol.WriteShortByteArray(w, e.VerifyToken)
+	}
+
+	if err := protocol.WriteByteArray(w, e.PublicKey); err != nil {
+		return err
+	}
+	return protocol.WriteByteArray(w, e.VerifyToken)
+}
@@ -0,0 +1,20 @@
+package login
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+type SetCompression struct {
+	Threshold int32
+}
+
+func init() {
+	packet.RegisterOutbound(&SetCompression{}, packet.IDs{
+		protocol.Version1_8: 0x03,
+	})
+}
+
+func (s *SetCompression) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
+	return protocol.WriteVarInt(w, s.Threshold)
+}
@@ -1,7 +1,10 @@
 package login
 
 import (
+	"strings"
+
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 	"github.com/google/uuid"
 )
 
@@ -10,12 +13,18 @@ type Success struct {
 	Username string
 }
 
-func (Success) PacketID() uint {
-	return 0x02
+func init() {
+	packet.RegisterOutbound(&Success{}, packet.AllVersions(0x02))
 }
 
-func (s *Success) MarshalPacket(w protocol.Writer) error {
-	if err := protocol.WriteString(w, s.UUID.String()); err != nil {
+func (s *Success) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	id := s.UUID.String()
+	if v == protocol.Version1_7_2 {
+		// 1.7.2 expects the UUID without hyphens
+		id = strings.ReplaceAll(id, "-", "")
+	}
+
+	if err := protocol.WriteString(w, id); err != nil {
 		return err
 	}
 	return protocol.WriteString(w, s.Username)
@@ -0,0 +1,51 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// BlockChange changes a single block on the client.
+type BlockChange struct {
+	X    int32
+	Y    uint8
+	Z    int32
+	Data block.Data
+}
+
+func init() {
+	packet.RegisterOutbound(&BlockChange{}, packet.IDs{
+		protocol.Version1_7_2: 0x23,
+		protocol.Version1_9:   0x0B,
+	})
+}
+
+func (b *BlockChange) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if v >= protocol.Version1_8 {
+		if err := protocol.WriteUint64(w, encodePosition(b.X, b.Y, b.Z)); err != nil {
+			return err
+		}
+		return protocol.WriteVarInt(w, int32(b.Data))
+	}
+
+	if err := protocol.WriteUint32(w, uint32(b.X)); err != nil {
+		return err
+	}
+	if err := w.WriteByte(b.Y); err != nil {
+		return err
+	}
+	if err := protocol.WriteUint32(w, uint32(b.Z)); err != nil {
+		return err
+	}
+	if err := protocol.WriteVarInt(w, int32(b.Data.Type())); err != nil {
+		return err
+	}
+	return w.WriteByte(b.Data.Metadata())
+}
+
+// encodePosition encodes a block position in the format used starting from 1.8, which stores X in the upper 26 bits, Y
+// in the next 12 bits and Z in the lower 26 bits.
+func encodePosition(x int32, y uint8, z int32) uint64 {
+	return uint64(x)&0x3FFFFFF<<38 | uint64(y)<<26 | uint64(z)&0x3FFFFFF
+}
@@ -2,6 +2,7 @@ package play
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type BulkChunkDataMeta struct {
@@ -9,6 +10,8 @@ type BulkChunkDataMeta struct {
 	SectionMask uint16
 }
 
+// BulkChunkData sends multiple chunks at once. The format of Data depends on the protocol version. Versions older than
+// 1.8 do not support block IDs above 255, so the add bitmasks are always empty.
 type BulkChunkData struct {
 	SkyLightIncluded bool
 	ChunkCount       int32
@@ -16,11 +19,18 @@ type BulkChunkData struct {
 	Data             []byte
 }
 
-func (BulkChunkData) PacketID() uint {
-	return 0x26
+func init() {
+	packet.RegisterOutbound(&BulkChunkData{}, packet.IDs{
+		protocol.Version1_7_2: 0x26,
+		protocol.Version1_9:   packet.NoID,
+	})
 }
 
-func (c *BulkChunkData) MarshalPacket(w protocol.Writer) error {
+func (c *BulkChunkData) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if v < protocol.Version1_8 {
+		return c.marshalCompressed(w)
+	}
+
 	if err := protocol.WriteBool(w, c.SkyLightIncluded); err != nil {
 		return err
 	}
@@ -43,3 +53,42 @@ func (c *BulkChunkData) MarshalPacket(w protocol.Writer) error {
 	_, err := w.Write(c.Data)
 	return err
 }
+
+// marshalCompressed writes the packet in the format used by versions older than 1.8, where the data is compressed and
+// the metadata follows it.
+func (c *BulkChunkData) marshalCompressed(w protocol.Writer) error {
+	if err := protocol.WriteUint16(w, uint16(c.ChunkCount)); err != nil {
+		return err
+	}
+
+	data, err := compressChunkData(c.Data)
+	if err != nil {
+		return err
+	}
+	if err := protocol.WriteUint32(w, uint32(len(data))); err != nil {
+		return err
+	}
+	if err := protocol.WriteBool(w, c.SkyLightIncluded); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	for i := range c.Meta {
+		if err := protocol.WriteUint32(w, uint32(c.Meta[i].X)); err != nil {
+			return err
+		}
+		if err := protocol.WriteUint32(w, uint32(c.Meta[i].Z)); err != nil {
+			return err
+		}
+		if err := protocol.WriteUint16(w, c.Meta[i].SectionMask); err != nil {
+			return err
+		}
+		// add bitmask
+		if err := protocol.WriteUint16(w, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// ChatPosition determines where a Chat message is displayed.
+type ChatPosition uint8
+
+const (
+	ChatPositionChat ChatPosition = iota
+	ChatPositionSystem
+	ChatPositionActionBar
+)
+
+// Chat displays a message to the player. Versions older than 1.8 do not support positions, all messages are
+// displayed in the chat.
+type Chat struct {
+	Message  *chat.Msg
+	Position ChatPosition
+}
+
+func init() {
+	packet.RegisterOutbound(&Chat{}, packet.IDs{
+		protocol.Version1_7_2: 0x02,
+		protocol.Version1_9:   0x0F,
+	})
+}
+
+// Droppable implements packet.Droppable. Action bar messages may be dropped, since they are only displayed briefly.
+func (c *Chat) Droppable() bool {
+	return c.Position == ChatPositionActionBar
+}
+
+func (c *Chat) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if err := protocol.WriteChat(w, c.Message); err != nil {
+		return err
+	}
+	if v < protocol.Version1_8 {
+		return nil
+	}
+	return w.WriteByte(uint8(c.Position))
+}
@@ -1,9 +1,16 @@
 package play
 
 import (
+	"bytes"
+	"compress/zlib"
+
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
+// ChunkData sends a single chunk. The format of Data depends on the protocol version. Versions older than 1.8 do not
+// support block IDs above 255, so the add bitmask is always empty. Starting from 1.9, a chunk is unloaded using
+// UnloadChunk instead of an empty ChunkData packet.
 type ChunkData struct {
 	X, Z      int32
 	FullChunk bool
@@ -11,11 +18,14 @@ type ChunkData struct {
 	Data      []byte
 }
 
-func (ChunkData) PacketID() uint {
-	return 0x21
+func init() {
+	packet.RegisterOutbound(&ChunkData{}, packet.IDs{
+		protocol.Version1_7_2: 0x21,
+		protocol.Version1_9:   0x20,
+	})
 }
 
-func (c *ChunkData) MarshalPacket(w protocol.Writer) error {
+func (c *ChunkData) MarshalPacket(w protocol.Writer, v protocol.Version) error {
 	if err := protocol.WriteUint32(w, uint32(c.X)); err != nil {
 		return err
 	}
@@ -25,8 +35,57 @@ func (c *ChunkData) MarshalPacket(w protocol.Writer) error {
 	if err := protocol.WriteBool(w, c.FullChunk); err != nil {
 		return err
 	}
+	if v >= protocol.Version1_9 {
+		return c.marshalPaletted(w, v)
+	}
 	if err := protocol.WriteUint16(w, c.Mask); err != nil {
 		return err
 	}
+
+	if v < protocol.Version1_8 {
+		// add bitmask
+		if err := protocol.WriteUint16(w, 0); err != nil {
+			return err
+		}
+		data, err := compressChunkData(c.Data)
+		if err != nil {
+			return err
+		}
+		if err := protocol.WriteUint32(w, uint32(len(data))); err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
 	return protocol.WriteByteArray(w, c.Data)
 }
+
+// marshalPaletted writes the remainder of the packet in the format used starting from 1.9, in which the bitmask is a
+// VarInt and the packet ends with a list of block entities.
+func (c *ChunkData) marshalPaletted(w protocol.Writer, v protocol.Version) error {
+	if err := protocol.WriteVarInt(w, int32(c.Mask)); err != nil {
+		return err
+	}
+	if err := protocol.WriteByteArray(w, c.Data); err != nil {
+		return err
+	}
+	if v < protocol.Version1_9_4 {
+		return nil
+	}
+	// block entities are not supported yet
+	return protocol.WriteVarInt(w, 0)
+}
+
+// compressChunkData compresses chunk data using zlib, as required by versions older than 1.8.
+func compressChunkData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -3,16 +3,20 @@ package play
 import (
 	"github.com/gitfyu/mable/chat"
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type Disconnect struct {
 	Reason *chat.Msg
 }
 
-func (Disconnect) PacketID() uint {
-	return 0x40
+func init() {
+	packet.RegisterOutbound(&Disconnect{}, packet.IDs{
+		protocol.Version1_7_2: 0x40,
+		protocol.Version1_9:   0x1A,
+	})
 }
 
-func (s *Disconnect) MarshalPacket(w protocol.Writer) error {
+func (s *Disconnect) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
 	return protocol.WriteChat(w, s.Reason)
 }
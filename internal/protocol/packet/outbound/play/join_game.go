@@ -2,6 +2,7 @@ package play
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type JoinGame struct {
@@ -14,19 +15,28 @@ type JoinGame struct {
 	ReduceDbgInfo bool
 }
 
-func (JoinGame) PacketID() uint {
-	return 0x01
+func init() {
+	packet.RegisterOutbound(&JoinGame{}, packet.IDs{
+		protocol.Version1_7_2: 0x01,
+		protocol.Version1_9:   0x23,
+	})
 }
 
-func (c *JoinGame) MarshalPacket(w protocol.Writer) error {
+func (c *JoinGame) MarshalPacket(w protocol.Writer, v protocol.Version) error {
 	if err := protocol.WriteUint32(w, uint32(c.EntityID)); err != nil {
 		return err
 	}
 	if err := w.WriteByte(c.Gamemode); err != nil {
 		return err
 	}
-	if err := w.WriteByte(uint8(c.Dimension)); err != nil {
-		return err
+	if v < protocol.Version1_9_1 {
+		if err := w.WriteByte(uint8(c.Dimension)); err != nil {
+			return err
+		}
+	} else {
+		if err := protocol.WriteUint32(w, uint32(c.Dimension)); err != nil {
+			return err
+		}
 	}
 	if err := w.WriteByte(c.Difficulty); err != nil {
 		return err
@@ -37,5 +47,8 @@ func (c *JoinGame) MarshalPacket(w protocol.Writer) error {
 	if err := protocol.WriteString(w, c.LevelType); err != nil {
 		return err
 	}
+	if v < protocol.Version1_8 {
+		return nil
+	}
 	return protocol.WriteBool(w, c.ReduceDbgInfo)
 }
@@ -2,16 +2,27 @@ package play
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type KeepAlive struct {
-	ID int
+	ID int64
 }
 
-func (_ KeepAlive) PacketID() uint {
-	return 0x00
+func init() {
+	packet.RegisterOutbound(&KeepAlive{}, packet.IDs{
+		protocol.Version1_7_2: 0x00,
+		protocol.Version1_9:   0x1F,
+	})
 }
 
-func (k *KeepAlive) MarshalPacket(w protocol.Writer) error {
-	return protocol.WriteVarInt(w, int32(k.ID))
+func (k *KeepAlive) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	switch {
+	case v < protocol.Version1_8:
+		return protocol.WriteUint32(w, uint32(k.ID))
+	case v < protocol.Version1_12_2:
+		return protocol.WriteVarInt(w, int32(k.ID))
+	default:
+		return protocol.WriteUint64(w, uint64(k.ID))
+	}
 }
@@ -0,0 +1,69 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// MultiBlockChange changes multiple blocks within a single chunk on the client.
+type MultiBlockChange struct {
+	// X and Z are the coordinates of the chunk.
+	X, Z    int32
+	Records []BlockChangeRecord
+}
+
+// BlockChangeRecord is a single change in a MultiBlockChange. The coordinates are relative to the chunk.
+type BlockChangeRecord struct {
+	X, Y, Z uint8
+	Data    block.Data
+}
+
+func init() {
+	packet.RegisterOutbound(&MultiBlockChange{}, packet.IDs{
+		protocol.Version1_7_2: 0x22,
+		protocol.Version1_9:   0x10,
+	})
+}
+
+func (m *MultiBlockChange) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if err := protocol.WriteUint32(w, uint32(m.X)); err != nil {
+		return err
+	}
+	if err := protocol.WriteUint32(w, uint32(m.Z)); err != nil {
+		return err
+	}
+
+	if v < protocol.Version1_8 {
+		// each record is an int containing the position in the upper 16 bits and the block in the lower 16 bits
+		if err := protocol.WriteUint16(w, uint16(len(m.Records))); err != nil {
+			return err
+		}
+		if err := protocol.WriteUint32(w, uint32(len(m.Records)*4)); err != nil {
+			return err
+		}
+		for _, r := range m.Records {
+			rec := uint32(r.X&15)<<28 | uint32(r.Z&15)<<24 | uint32(r.Y)<<16 | uint32(r.Data)
+			if err := protocol.WriteUint32(w, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := protocol.WriteVarInt(w, int32(len(m.Records))); err != nil {
+		return err
+	}
+	for _, r := range m.Records {
+		if err := w.WriteByte(r.X<<4 | r.Z&15); err != nil {
+			return err
+		}
+		if err := w.WriteByte(r.Y); err != nil {
+			return err
+		}
+		if err := protocol.WriteVarInt(w, int32(r.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
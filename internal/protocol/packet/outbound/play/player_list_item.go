@@ -0,0 +1,137 @@
+package play
+
+import (
+	"errors"
+
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	"github.com/google/uuid"
+)
+
+// PlayerListAction specifies how a PlayerListItem modifies the player list.
+type PlayerListAction int32
+
+const (
+	PlayerListAdd           PlayerListAction = 0
+	PlayerListUpdateLatency PlayerListAction = 2
+	PlayerListRemove        PlayerListAction = 4
+)
+
+var errSingleEntryOnly = errors.New("versions older than 1.8 only support a single entry per player list packet")
+
+// PlayerListProperty is a property of a player's profile, such as their skin.
+type PlayerListProperty struct {
+	Name, Value, Signature string
+}
+
+// PlayerListEntry is a single player in a PlayerListItem. Which fields are used depends on the action.
+type PlayerListEntry struct {
+	UUID       uuid.UUID
+	Name       string
+	Properties []PlayerListProperty
+	Gamemode   int32
+	// Latency is the ping of the player in milliseconds.
+	Latency int32
+}
+
+// PlayerListItem updates the player list (tab list). Versions older than 1.8 identify players by name instead of UUID
+// and only support a single entry per packet.
+type PlayerListItem struct {
+	Action  PlayerListAction
+	Entries []PlayerListEntry
+}
+
+func init() {
+	packet.RegisterOutbound(&PlayerListItem{}, packet.IDs{
+		protocol.Version1_7_2:  0x38,
+		protocol.Version1_9:    0x2D,
+		protocol.Version1_12_1: 0x2E,
+	})
+}
+
+// Droppable implements packet.Droppable. Latency updates may be dropped, since they will be sent again later.
+func (p *PlayerListItem) Droppable() bool {
+	return p.Action == PlayerListUpdateLatency
+}
+
+func (p *PlayerListItem) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if v < protocol.Version1_8 {
+		return p.marshalLegacy(w)
+	}
+
+	if err := protocol.WriteVarInt(w, int32(p.Action)); err != nil {
+		return err
+	}
+	if err := protocol.WriteVarInt(w, int32(len(p.Entries))); err != nil {
+		return err
+	}
+
+	for i := range p.Entries {
+		e := &p.Entries[i]
+		if _, err := w.Write(e.UUID[:]); err != nil {
+			return err
+		}
+
+		var err error
+		switch p.Action {
+		case PlayerListAdd:
+			err = e.marshalAdd(w)
+		case PlayerListUpdateLatency:
+			err = protocol.WriteVarInt(w, e.Latency)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *PlayerListEntry) marshalAdd(w protocol.Writer) error {
+	if err := protocol.WriteString(w, e.Name); err != nil {
+		return err
+	}
+	if err := protocol.WriteVarInt(w, int32(len(e.Properties))); err != nil {
+		return err
+	}
+	for _, prop := range e.Properties {
+		if err := protocol.WriteString(w, prop.Name); err != nil {
+			return err
+		}
+		if err := protocol.WriteString(w, prop.Value); err != nil {
+			return err
+		}
+		if err := protocol.WriteBool(w, prop.Signature != ""); err != nil {
+			return err
+		}
+		if prop.Signature != "" {
+			if err := protocol.WriteString(w, prop.Signature); err != nil {
+				return err
+			}
+		}
+	}
+	if err := protocol.WriteVarInt(w, e.Gamemode); err != nil {
+		return err
+	}
+	if err := protocol.WriteVarInt(w, e.Latency); err != nil {
+		return err
+	}
+	// no display name
+	return protocol.WriteBool(w, false)
+}
+
+// marshalLegacy writes the packet in the format used by versions older than 1.8, which consists of the name of the
+// player, whether they should be shown and their latency.
+func (p *PlayerListItem) marshalLegacy(w protocol.Writer) error {
+	if len(p.Entries) != 1 {
+		return errSingleEntryOnly
+	}
+
+	e := &p.Entries[0]
+	if err := protocol.WriteString(w, e.Name); err != nil {
+		return err
+	}
+	if err := protocol.WriteBool(w, p.Action != PlayerListRemove); err != nil {
+		return err
+	}
+	return protocol.WriteUint16(w, uint16(e.Latency))
+}
@@ -0,0 +1,32 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// PluginMessage sends a custom payload to the client on a plugin channel.
+type PluginMessage struct {
+	Channel string
+	Data    []byte
+}
+
+func init() {
+	packet.RegisterOutbound(&PluginMessage{}, packet.IDs{
+		protocol.Version1_7_2: 0x3F,
+		protocol.Version1_9:   0x18,
+	})
+}
+
+func (m *PluginMessage) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	if err := protocol.WriteString(w, m.Channel); err != nil {
+		return err
+	}
+
+	if v < protocol.Version1_8 {
+		return protocol.WriteShortByteArray(w, m.Data)
+	}
+
+	_, err := w.Write(m.Data)
+	return err
+}
@@ -2,23 +2,40 @@ package play
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
+// PlayerEyeHeight is the distance between a player's feet and eyes.
+const PlayerEyeHeight = 1.62
+
+// Position teleports the player. Y is the position of the player's feet. Starting from 1.9, the client confirms the
+// teleport by sending a TeleportConfirm packet containing TeleportID.
 type Position struct {
 	X, Y, Z    float64
 	Yaw, Pitch float32
 	Flags      uint8
+	TeleportID int32
 }
 
-func (_ Position) PacketID() uint {
-	return 0x08
+func init() {
+	packet.RegisterOutbound(&Position{}, packet.IDs{
+		protocol.Version1_7_2:  0x08,
+		protocol.Version1_9:    0x2E,
+		protocol.Version1_12_1: 0x2F,
+	})
 }
 
-func (p *Position) MarshalPacket(w protocol.Writer) error {
+func (p *Position) MarshalPacket(w protocol.Writer, v protocol.Version) error {
+	y := p.Y
+	if v < protocol.Version1_9 {
+		// 1.7 expects the position of the player's eyes, and 1.8 clients have always been sent the same value
+		y += PlayerEyeHeight
+	}
+
 	if err := protocol.WriteFloat64(w, p.X); err != nil {
 		return err
 	}
-	if err := protocol.WriteFloat64(w, p.Y); err != nil {
+	if err := protocol.WriteFloat64(w, y); err != nil {
 		return err
 	}
 	if err := protocol.WriteFloat64(w, p.Z); err != nil {
@@ -30,5 +47,15 @@ func (p *Position) MarshalPacket(w protocol.Writer) error {
 	if err := protocol.WriteFloat32(w, p.Pitch); err != nil {
 		return err
 	}
-	return w.WriteByte(p.Flags)
+	if v < protocol.Version1_8 {
+		// older versions do not support relative positions, instead there is an on-ground flag
+		return protocol.WriteBool(w, false)
+	}
+	if err := w.WriteByte(p.Flags); err != nil {
+		return err
+	}
+	if v < protocol.Version1_9 {
+		return nil
+	}
+	return protocol.WriteVarInt(w, p.TeleportID)
 }
@@ -0,0 +1,38 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// Respawn moves the player to a different dimension. The client discards all loaded chunks and waits for a Position
+// packet. If the dimension is the same as the current one, some clients do not reload the world properly, so a
+// Respawn to a different dimension should be sent first.
+type Respawn struct {
+	Dimension  int32
+	Difficulty uint8
+	Gamemode   uint8
+	LevelType  string
+}
+
+func init() {
+	packet.RegisterOutbound(&Respawn{}, packet.IDs{
+		protocol.Version1_7_2:  0x07,
+		protocol.Version1_9:    0x33,
+		protocol.Version1_12:   0x34,
+		protocol.Version1_12_1: 0x35,
+	})
+}
+
+func (r *Respawn) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
+	if err := protocol.WriteUint32(w, uint32(r.Dimension)); err != nil {
+		return err
+	}
+	if err := w.WriteByte(r.Difficulty); err != nil {
+		return err
+	}
+	if err := w.WriteByte(r.Gamemode); err != nil {
+		return err
+	}
+	return protocol.WriteString(w, r.LevelType)
+}
@@ -0,0 +1,30 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// TabComplete responds to a TabComplete packet from the client. Each match replaces the last word of the text.
+type TabComplete struct {
+	Matches []string
+}
+
+func init() {
+	packet.RegisterOutbound(&TabComplete{}, packet.IDs{
+		protocol.Version1_7_2: 0x3A,
+		protocol.Version1_9:   0x0E,
+	})
+}
+
+func (t *TabComplete) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
+	if err := protocol.WriteVarInt(w, int32(len(t.Matches))); err != nil {
+		return err
+	}
+	for _, m := range t.Matches {
+		if err := protocol.WriteString(w, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
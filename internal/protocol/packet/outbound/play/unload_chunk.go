@@ -0,0 +1,24 @@
+package play
+
+import (
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+// UnloadChunk unloads a chunk on the client. Older versions use an empty ChunkData packet instead.
+type UnloadChunk struct {
+	X, Z int32
+}
+
+func init() {
+	packet.RegisterOutbound(&UnloadChunk{}, packet.IDs{
+		protocol.Version1_9: 0x1D,
+	})
+}
+
+func (u *UnloadChunk) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
+	if err := protocol.WriteUint32(w, uint32(u.X)); err != nil {
+		return err
+	}
+	return protocol.WriteUint32(w, uint32(u.Z))
+}
@@ -2,16 +2,17 @@ package status
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type Pong struct {
 	Time int64
 }
 
-func (Pong) PacketID() uint {
-	return 0x01
+func init() {
+	packet.RegisterOutbound(&Pong{}, packet.AllVersions(0x01))
 }
 
-func (p *Pong) MarshalPacket(w protocol.Writer) error {
+func (p *Pong) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
 	return protocol.WriteUint64(w, uint64(p.Time))
 }
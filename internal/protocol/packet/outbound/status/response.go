@@ -2,16 +2,17 @@ package status
 
 import (
 	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
 )
 
 type Response struct {
 	Content string
 }
 
-func (Response) PacketID() uint {
-	return 0x00
+func init() {
+	packet.RegisterOutbound(&Response{}, packet.AllVersions(0x00))
 }
 
-func (r *Response) MarshalPacket(w protocol.Writer) error {
+func (r *Response) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
 	return protocol.WriteString(w, r.Content)
 }
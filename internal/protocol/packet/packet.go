@@ -1,34 +1,137 @@
 package packet
 
 import (
+	"math"
+	"reflect"
+	"sort"
+
 	"github.com/gitfyu/mable/internal/protocol"
 )
 
-// Inbound represents a packet sent from the client.
+// NoID can be used in IDs to indicate that a packet no longer exists starting from a version.
+const NoID = ^uint(0)
+
+// Inbound represents a packet sent from the client. The protocol.Version is the version of the client, which
+// determines the layout of the packet.
 type Inbound interface {
-	UnmarshalPacket(r protocol.Reader) error
+	UnmarshalPacket(r protocol.Reader, v protocol.Version) error
 }
 
-// Outbound represents a packet sent from the server.
+// Outbound represents a packet sent from the server. The protocol.Version is the version of the client, which
+// determines the layout of the packet. The ID of the packet must be registered using RegisterOutbound.
 type Outbound interface {
-	PacketID() uint
-	MarshalPacket(w protocol.Writer) error
+	MarshalPacket(w protocol.Writer, v protocol.Version) error
+}
+
+// Droppable is implemented by Outbound packets that may be dropped when a client cannot keep up with the server,
+// because losing them does not leave the client in an inconsistent state.
+type Droppable interface {
+	Outbound
+	// Droppable returns whether this packet may be dropped.
+	Droppable() bool
+}
+
+// IDs maps protocol versions to packet IDs. Each entry specifies the ID that a packet uses starting from that version,
+// until the version of the next entry. A packet does not exist in versions before the first entry.
+type IDs map[protocol.Version]uint
+
+// AllVersions returns IDs for a packet that has the same ID in every version. This must also be used for packets that
+// are read before the version of the client is known.
+func AllVersions(id uint) IDs {
+	return IDs{0: id}
 }
 
-var idToPacket = make(map[uint]func() Inbound)
+// idRange is a packet ID that is used by the versions in the range [from,to).
+type idRange struct {
+	from, to protocol.Version
+	id       uint
+}
+
+// ranges converts the IDs to a list of ranges, sorted by version.
+func (ids IDs) ranges() []idRange {
+	versions := make([]protocol.Version, 0, len(ids))
+	for v := range ids {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+
+	ranges := make([]idRange, 0, len(versions))
+	for i, v := range versions {
+		if ids[v] == NoID {
+			continue
+		}
+
+		to := protocol.Version(math.MaxInt32)
+		if i+1 < len(versions) {
+			to = versions[i+1]
+		}
+		ranges = append(ranges, idRange{
+			from: v,
+			to:   to,
+			id:   ids[v],
+		})
+	}
+	return ranges
+}
+
+// inboundKey identifies an ID within a protocol.State.
+type inboundKey struct {
+	state protocol.State
+	id    uint
+}
+
+// inboundEntry is a registered Inbound packet.
+type inboundEntry struct {
+	from, to protocol.Version
+	supplier func() Inbound
+}
+
+var (
+	idToPacket = make(map[inboundKey][]inboundEntry)
+	packetToID = make(map[reflect.Type][]idRange)
+)
+
+// RegisterInbound registers a supplier function that creates an Inbound packet for a specific protocol.State and the
+// specified IDs.
+func RegisterInbound(state protocol.State, ids IDs, supplier func() Inbound) {
+	for _, r := range ids.ranges() {
+		k := inboundKey{state: state, id: r.id}
+		idToPacket[k] = append(idToPacket[k], inboundEntry{
+			from:     r.from,
+			to:       r.to,
+			supplier: supplier,
+		})
+	}
+}
+
+// RegisterOutbound registers the IDs for the type of the specified Outbound packet. The packet is only used to
+// determine its type.
+func RegisterOutbound(pk Outbound, ids IDs) {
+	packetToID[reflect.TypeOf(pk)] = ids.ranges()
+}
+
+// createInbound creates an Inbound packet for the specified protocol.State, protocol.Version and ID, or nil in case no
+// instance could be created.
+func createInbound(state protocol.State, v protocol.Version, id uint) Inbound {
+	for _, e := range idToPacket[inboundKey{state: state, id: id}] {
+		if v >= e.from && v < e.to {
+			return e.supplier()
+		}
+	}
 
-// RegisterInbound registers a supplier function that creates an Inbound packet for a specific protocol.State and id.
-func RegisterInbound(state protocol.State, id uint, supplier func() Inbound) {
-	idToPacket[id<<2|uint(state)] = supplier
+	return nil
 }
 
-// createInbound creates an Inbound packet for the specified protocol.State and ID, or nil in case no instance could be
-// created.
-func createInbound(state protocol.State, id uint) Inbound {
-	f, ok := idToPacket[id<<2|uint(state)]
-	if !ok {
-		return nil
+// outboundID returns the ID of an Outbound packet for the specified protocol.Version. If the packet is not registered
+// or it does not exist in that version, false is returned.
+func outboundID(pk Outbound, v protocol.Version) (uint, bool) {
+	for _, r := range packetToID[reflect.TypeOf(pk)] {
+		if v >= r.from && v < r.to {
+			return r.id, true
+		}
 	}
 
-	return f()
+	return 0, false
 }
@@ -0,0 +1,41 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/gitfyu/mable/internal/protocol"
+)
+
+type versionedPacket struct{}
+
+func (*versionedPacket) MarshalPacket(protocol.Writer, protocol.Version) error {
+	return nil
+}
+
+func Test_outboundID(t *testing.T) {
+	RegisterOutbound(&versionedPacket{}, IDs{
+		protocol.Version1_7_6: 0x01,
+		protocol.Version1_9:   0x02,
+		protocol.Version1_12:  NoID,
+	})
+
+	tests := []struct {
+		v      protocol.Version
+		id     uint
+		exists bool
+	}{
+		{protocol.Version1_7_2, 0, false},
+		{protocol.Version1_7_6, 0x01, true},
+		{protocol.Version1_8, 0x01, true},
+		{protocol.Version1_9, 0x02, true},
+		{protocol.Version1_11_1, 0x02, true},
+		{protocol.Version1_12, 0, false},
+		{protocol.Version1_12_2, 0, false},
+	}
+	for _, test := range tests {
+		id, ok := outboundID(&versionedPacket{}, test.v)
+		if ok != test.exists || id != test.id {
+			t.Errorf("Version %s: expected (%d, %v), got (%d, %v)", test.v, test.id, test.exists, id, ok)
+		}
+	}
+}
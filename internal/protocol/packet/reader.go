@@ -3,6 +3,7 @@ package packet
 import (
 	"bufio"
 	"bytes"
+	"crypto/cipher"
 	"errors"
 	"fmt"
 	"io"
@@ -11,7 +12,8 @@ import (
 )
 
 var (
-	errTooLarge = errors.New("packet exceeds maximum size")
+	errTooLarge       = errors.New("packet exceeds maximum size")
+	errBadCompression = errors.New("badly compressed packet")
 )
 
 // ReaderConfig is used to configure settings for a Reader.
@@ -25,39 +27,72 @@ type Reader struct {
 	reader  *bufio.Reader
 	cfg     ReaderConfig
 	readBuf bytes.Buffer
+	// compressedBuf is used to store compressed packets before they are decompressed into readBuf.
+	compressedBuf bytes.Buffer
+	// threshold is the compression threshold, or CompressionDisabled if compression is not enabled.
+	threshold int
 }
 
 // NewReader constructs a new Reader that reads from the provided io.Reader.
 func NewReader(r io.Reader, cfg ReaderConfig) *Reader {
 	return &Reader{
-		reader: bufio.NewReader(r),
-		cfg:    cfg,
+		reader:    bufio.NewReader(r),
+		cfg:       cfg,
+		threshold: CompressionDisabled,
 	}
 }
 
-// ReadPacket reads a single packet. It returns nil for unknown packets.
-func (r *Reader) ReadPacket(state protocol.State) (pk Inbound, err error) {
+// EnableEncryption causes all data that is read after this call to be decrypted using the specified cipher.Stream.
+// Data that was already buffered, but not read yet, will also be decrypted.
+func (r *Reader) EnableEncryption(s cipher.Stream) {
+	r.reader = bufio.NewReader(cipher.StreamReader{
+		S: s,
+		R: r.reader,
+	})
+}
+
+// EnableCompression causes all packets that are read after this call to use the compressed packet format. Packets
+// with a size of at least threshold bytes must be compressed by the client.
+func (r *Reader) EnableCompression(threshold int) {
+	r.threshold = threshold
+}
+
+// Peek returns the next n bytes without advancing the reader. It can be used to detect data that does not use the
+// regular packet format, such as the legacy server list ping.
+func (r *Reader) Peek(n int) ([]byte, error) {
+	return r.reader.Peek(n)
+}
+
+// ReadPacket reads a single packet for a client using the specified protocol.Version. It returns nil for unknown
+// packets.
+func (r *Reader) ReadPacket(state protocol.State, v protocol.Version) (pk Inbound, err error) {
 	size, err := protocol.ReadVarInt(r.reader)
 	if err != nil {
 		return nil, fmt.Errorf("reading packet size: %w", err)
 	}
-	if int(size) > r.cfg.MaxSize {
+	if size < 0 || int(size) > r.cfg.MaxSize {
 		return nil, errTooLarge
 	}
 
-	id, err := protocol.ReadVarInt(r.reader)
-	if err != nil {
-		return nil, fmt.Errorf("reading packet ID: %w", err)
+	if r.threshold < 0 {
+		err = r.readToBuf(int64(size))
+	} else {
+		err = r.readCompressedToBuf(int64(size))
 	}
-	if err := r.readToBuf(int64(size) - int64(protocol.VarIntSize(id))); err != nil {
+	if err != nil {
 		return nil, fmt.Errorf("reading packet body: %w", err)
 	}
 
-	pk = createInbound(state, uint(id))
+	id, err := protocol.ReadVarInt(&r.readBuf)
+	if err != nil {
+		return nil, fmt.Errorf("reading packet ID: %w", err)
+	}
+
+	pk = createInbound(state, v, uint(id))
 	if pk == nil {
 		return nil, nil
 	}
-	if err := pk.UnmarshalPacket(&r.readBuf); err != nil {
+	if err := pk.UnmarshalPacket(&r.readBuf, v); err != nil {
 		return nil, fmt.Errorf("bad packet 0x%x: %w", id, err)
 	}
 	return pk, nil
@@ -65,12 +100,72 @@ func (r *Reader) ReadPacket(state protocol.State) (pk Inbound, err error) {
 
 // readToBuf reads exactly n bytes to the internal buffer.
 func (r *Reader) readToBuf(n int64) error {
-	r.readBuf.Reset()
+	return r.readN(&r.readBuf, n)
+}
+
+// readN resets buf and reads exactly n bytes to it.
+func (r *Reader) readN(buf *bytes.Buffer, n int64) error {
+	buf.Reset()
 
 	lr := io.LimitedReader{
 		R: r.reader,
 		N: n,
 	}
-	_, err := r.readBuf.ReadFrom(&lr)
-	return err
+	if _, err := buf.ReadFrom(&lr); err != nil {
+		return err
+	}
+	if lr.N > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// readCompressedToBuf reads a packet in the compressed format, of which the size is n bytes, and stores the
+// uncompressed data in the internal buffer.
+func (r *Reader) readCompressedToBuf(n int64) error {
+	dataSize, err := protocol.ReadVarInt(r.reader)
+	if err != nil {
+		return err
+	}
+	n -= int64(protocol.VarIntSize(dataSize))
+	if n < 0 {
+		return errBadCompression
+	}
+
+	// uncompressed packet
+	if dataSize == 0 {
+		return r.readToBuf(n)
+	}
+
+	if dataSize < 0 || int(dataSize) > r.cfg.MaxSize {
+		return errTooLarge
+	}
+	if int(dataSize) < r.threshold {
+		return errBadCompression
+	}
+
+	// the compressed data is read into a separate buffer first, since the decompressor can work more efficiently with
+	// an io.ByteReader and it guarantees that the entire packet is consumed, even if it is badly compressed
+	if err := r.readN(&r.compressedBuf, n); err != nil {
+		return err
+	}
+
+	zr, err := newDecompressor(&r.compressedBuf)
+	if err != nil {
+		return err
+	}
+	defer releaseDecompressor(zr)
+
+	r.readBuf.Reset()
+	r.readBuf.Grow(int(dataSize))
+	if _, err := io.CopyN(&r.readBuf, zr, int64(dataSize)); err != nil {
+		return err
+	}
+
+	// the compressed stream must not contain more data than specified by dataSize
+	var extra [1]byte
+	if k, _ := zr.Read(extra[:]); k > 0 {
+		return errBadCompression
+	}
+	return nil
 }
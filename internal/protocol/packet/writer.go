@@ -2,6 +2,8 @@ package packet
 
 import (
 	"bytes"
+	"crypto/cipher"
+	"fmt"
 	"io"
 
 	"github.com/gitfyu/mable/internal/protocol"
@@ -15,32 +17,120 @@ type Writer struct {
 	// dataBuf is a buffer used to store encoded packet data,
 	// cached for performance.
 	dataBuf bytes.Buffer
+	// compressedBuf is a buffer used to store compressed packet data.
+	compressedBuf bytes.Buffer
+	// threshold is the compression threshold, or CompressionDisabled if compression is not enabled.
+	threshold int
 }
 
 // NewWriter constructs a new Writer.
 func NewWriter(w io.Writer) *Writer {
 	return &Writer{
-		out: w,
+		out:       w,
+		threshold: CompressionDisabled,
 	}
 }
 
-// WritePacket adds a single packet to the internal buffer, which will be written the next
-// time that Flush is called.
-func (w *Writer) WritePacket(pk Outbound) error {
+// EnableEncryption causes all data that is flushed after this call to be encrypted using the specified cipher.Stream.
+func (w *Writer) EnableEncryption(s cipher.Stream) {
+	w.out = cipher.StreamWriter{
+		S: s,
+		W: w.out,
+	}
+}
+
+// EnableCompression causes all packets that are written after this call to use the compressed packet format. Packets
+// with a size of at least threshold bytes will be compressed.
+func (w *Writer) EnableCompression(threshold int) {
+	w.threshold = threshold
+}
+
+// WritePacket adds a single packet for a client using the specified protocol.Version to the internal buffer, which
+// will be written the next time that Flush is called.
+func (w *Writer) WritePacket(pk Outbound, v protocol.Version) error {
+	if e, ok := pk.(*Encoded); ok {
+		return w.writeEncoded(e, v)
+	}
+
+	id, ok := outboundID(pk, v)
+	if !ok {
+		return fmt.Errorf("packet %T does not exist in version %s", pk, v)
+	}
+
 	w.dataBuf.Reset()
 
 	// 1. Encode the packet ID + content
-	protocol.WriteVarInt(&w.dataBuf, int32(pk.PacketID()))
-	if err := pk.MarshalPacket(&w.dataBuf); err != nil {
+	protocol.WriteVarInt(&w.dataBuf, int32(id))
+	if err := pk.MarshalPacket(&w.dataBuf, v); err != nil {
 		return err
 	}
 
 	// 2. Write the length of the encoded data, followed by the data itself
-	protocol.WriteVarInt(&w.buf, int32(w.dataBuf.Len()))
-	w.buf.Write(w.dataBuf.Bytes())
+	if w.threshold < 0 {
+		protocol.WriteVarInt(&w.buf, int32(w.dataBuf.Len()))
+		w.buf.Write(w.dataBuf.Bytes())
+		return nil
+	}
+
+	return w.writeCompressed()
+}
+
+// writeEncoded writes the cached frame of an Encoded packet to the internal buffer. If the packet has not been encoded
+// for the protocol.Version and compression threshold yet, it will be encoded and the result will be cached.
+func (w *Writer) writeEncoded(e *Encoded, v protocol.Version) error {
+	k := encodedKey{
+		version:   v,
+		threshold: w.threshold,
+	}
+	if f, ok := e.frame(k); ok {
+		w.buf.Write(f)
+		return nil
+	}
+
+	start := w.buf.Len()
+	if err := w.WritePacket(e.pk, v); err != nil {
+		return err
+	}
+
+	f := make([]byte, w.buf.Len()-start)
+	copy(f, w.buf.Bytes()[start:])
+	e.storeFrame(k, f)
 	return nil
 }
 
+// writeCompressed writes the contents of dataBuf to the internal buffer using the compressed packet format.
+func (w *Writer) writeCompressed() error {
+	size := w.dataBuf.Len()
+	if size < w.threshold {
+		// a data length of 0 indicates that the packet is not compressed
+		protocol.WriteVarInt(&w.buf, int32(size+protocol.VarIntSize(0)))
+		protocol.WriteVarInt(&w.buf, 0)
+		w.buf.Write(w.dataBuf.Bytes())
+		return nil
+	}
+
+	w.compressedBuf.Reset()
+	if err := compress(&w.compressedBuf, w.dataBuf.Bytes()); err != nil {
+		return err
+	}
+
+	protocol.WriteVarInt(&w.buf, int32(w.compressedBuf.Len()+protocol.VarIntSize(int32(size))))
+	protocol.WriteVarInt(&w.buf, int32(size))
+	w.buf.Write(w.compressedBuf.Bytes())
+	return nil
+}
+
+// WriteEncoded adds packets that have already been encoded by another Writer to the internal buffer. The packets must
+// have been encoded for the same protocol.Version and compression threshold as the packets written by this Writer.
+func (w *Writer) WriteEncoded(b []byte) {
+	w.buf.Write(b)
+}
+
+// Buffered returns the number of bytes that have been written to the internal buffer, but not flushed yet.
+func (w *Writer) Buffered() int {
+	return w.buf.Len()
+}
+
 // Writes the internal buffer to the io.Writer that was used to construct this Writer.
 func (w *Writer) Flush() error {
 	_, err := w.out.Write(w.buf.Bytes())
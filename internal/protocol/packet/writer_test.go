@@ -0,0 +1,124 @@
+package packet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gitfyu/mable/internal/protocol"
+)
+
+const testPacketID = 0x7F
+
+type testPacket struct {
+	Data []byte
+}
+
+func init() {
+	RegisterInbound(protocol.StatePlay, AllVersions(testPacketID), func() Inbound {
+		return &testPacket{}
+	})
+	RegisterOutbound(&testPacket{}, AllVersions(testPacketID))
+}
+
+func (p *testPacket) MarshalPacket(w protocol.Writer, _ protocol.Version) error {
+	return protocol.WriteByteArray(w, p.Data)
+}
+
+func (p *testPacket) UnmarshalPacket(r protocol.Reader, _ protocol.Version) error {
+	var err error
+	p.Data, err = protocol.ReadByteArray(r)
+	return err
+}
+
+func testRoundTrip(t *testing.T, threshold int) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	r := NewReader(&buf, ReaderConfig{MaxSize: 1 << 16})
+	if threshold != CompressionDisabled {
+		w.EnableCompression(threshold)
+		r.EnableCompression(threshold)
+	}
+
+	sizes := []int{0, 1, 63, 64, 65, 1000, 10000}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i % 7)
+		}
+		if err := w.WritePacket(&testPacket{Data: data}, protocol.Version1_8); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range sizes {
+		pk, err := r.ReadPacket(protocol.StatePlay, protocol.Version1_8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := pk.(*testPacket)
+		if !ok {
+			t.Fatalf("Expected *testPacket, got %T", pk)
+		}
+		if len(got.Data) != size {
+			t.Errorf("Expected %d bytes, got %d", size, len(got.Data))
+		}
+	}
+}
+
+func TestWriter_WritePacket(t *testing.T) {
+	testRoundTrip(t, CompressionDisabled)
+}
+
+func TestWriter_WritePacket_Compressed(t *testing.T) {
+	testRoundTrip(t, 64)
+}
+
+func TestReader_ReadPacket_BadCompression(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.EnableCompression(0)
+	w.WritePacket(&testPacket{Data: make([]byte, 10)}, protocol.Version1_8)
+	w.Flush()
+
+	// the packet is smaller than the threshold of the reader, so it should not have been compressed
+	r := NewReader(&buf, ReaderConfig{MaxSize: 1 << 16})
+	r.EnableCompression(64)
+	if _, err := r.ReadPacket(protocol.StatePlay, protocol.Version1_8); err == nil {
+		t.Error("Expected error")
+	}
+}
+
+func TestWriter_WritePacket_Encoded(t *testing.T) {
+	pk := &testPacket{Data: make([]byte, 100)}
+	e := NewEncoded(pk)
+
+	for _, threshold := range []int{CompressionDisabled, 64} {
+		var expect, got bytes.Buffer
+		w := NewWriter(&expect)
+		w.EnableCompression(threshold)
+		w.WritePacket(pk, protocol.Version1_8)
+		w.Flush()
+
+		// the second write uses the cached frame
+		for i := 0; i < 2; i++ {
+			got.Reset()
+			w := NewWriter(&got)
+			w.EnableCompression(threshold)
+			if err := w.WritePacket(e, protocol.Version1_8); err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+
+			if !bytes.Equal(expect.Bytes(), got.Bytes()) {
+				t.Errorf("Threshold %d, write %d: expected %x, got %x", threshold, i, expect.Bytes(), got.Bytes())
+			}
+		}
+	}
+
+	if len(e.frames) != 2 {
+		t.Errorf("Expected 2 cached frames, got %d", len(e.frames))
+	}
+}
@@ -0,0 +1,74 @@
+package protocol
+
+// Version represents a protocol version, which identifies the Minecraft version of a client.
+type Version int32
+
+const (
+	Version1_7_2  Version = 4
+	Version1_7_6  Version = 5
+	Version1_8    Version = 47
+	Version1_9    Version = 107
+	Version1_9_1  Version = 108
+	Version1_9_2  Version = 109
+	Version1_9_4  Version = 110
+	Version1_10   Version = 210
+	Version1_11   Version = 315
+	Version1_11_1 Version = 316
+	Version1_12   Version = 335
+	Version1_12_1 Version = 338
+	Version1_12_2 Version = 340
+)
+
+// supportedVersions contains all supported versions in ascending order.
+var supportedVersions = []Version{
+	Version1_7_2,
+	Version1_7_6,
+	Version1_8,
+	Version1_9,
+	Version1_9_1,
+	Version1_9_2,
+	Version1_9_4,
+	Version1_10,
+	Version1_11,
+	Version1_11_1,
+	Version1_12,
+	Version1_12_1,
+	Version1_12_2,
+}
+
+// versionNames contains the names of all supported versions.
+var versionNames = map[Version]string{
+	Version1_7_2:  "1.7.2",
+	Version1_7_6:  "1.7.6",
+	Version1_8:    "1.8",
+	Version1_9:    "1.9",
+	Version1_9_1:  "1.9.1",
+	Version1_9_2:  "1.9.2",
+	Version1_9_4:  "1.9.4",
+	Version1_10:   "1.10",
+	Version1_11:   "1.11",
+	Version1_11_1: "1.11.1",
+	Version1_12:   "1.12",
+	Version1_12_1: "1.12.1",
+	Version1_12_2: "1.12.2",
+}
+
+// SupportedVersions returns all supported versions in ascending order. The returned slice must not be modified.
+func SupportedVersions() []Version {
+	return supportedVersions
+}
+
+// IsSupported returns whether clients using this protocol version can join the server.
+func (v Version) IsSupported() bool {
+	_, ok := versionNames[v]
+	return ok
+}
+
+// String returns the name of the oldest Minecraft version that uses this protocol version, or "unknown" if the
+// version is not supported.
+func (v Version) String() string {
+	if name, ok := versionNames[v]; ok {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gitfyu/mable/game"
+	"github.com/google/uuid"
+)
+
+var (
+	errNotAuthenticated = errors.New("player has not joined through the session server")
+
+	sessionClient = &http.Client{
+		Timeout: 10 * time.Second,
+	}
+)
+
+// sessionProfile is the response body of the session server's hasJoined endpoint.
+type sessionProfile struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Properties []game.ProfileProperty `json:"properties"`
+}
+
+// serverHash computes the hash that identifies a login session, in the same way as the vanilla server. This is a SHA-1
+// digest of the server ID, shared secret and public key, formatted as a signed hexadecimal number.
+func serverHash(serverID string, secret, publicKey []byte) string {
+	h := sha1.New()
+	h.Write([]byte(serverID))
+	h.Write(secret)
+	h.Write(publicKey)
+	sum := h.Sum(nil)
+
+	n := new(big.Int).SetBytes(sum)
+	if sum[0]&0x80 != 0 {
+		// the digest is interpreted as a two's complement number
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(sum)*8)))
+	}
+	return n.Text(16)
+}
+
+// hasJoined asks the session server whether the player with the specified username has joined the session
+// identified by hash, and returns the player's profile if so.
+func (s *Server) hasJoined(username, hash string) (*profile, error) {
+	q := url.Values{}
+	q.Set("username", username)
+	q.Set("serverId", hash)
+
+	resp, err := sessionClient.Get(strings.TrimSuffix(s.cfg.SessionServer, "/") +
+		"/session/minecraft/hasJoined?" + q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return nil, errNotAuthenticated
+	default:
+		return nil, fmt.Errorf("unexpected session server response: %s", resp.Status)
+	}
+
+	var sp sessionProfile
+	if err := json.NewDecoder(resp.Body).Decode(&sp); err != nil {
+		return nil, fmt.Errorf("decoding session server response: %w", err)
+	}
+	id, err := uuid.Parse(sp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("bad UUID from session server: %w", err)
+	}
+
+	return &profile{
+		name:       sp.Name,
+		id:         id,
+		properties: sp.Properties,
+	}, nil
+}
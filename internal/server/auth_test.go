@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Obtained from https://wiki.vg/Protocol_Encryption#Authentication
+var serverHashTests = map[string]string{
+	"Notch": "4ed1f46bbe04bc756bcb17c0c7ce3e4632f06a48",
+	"jeb_":  "-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1",
+	"simon": "88e16a1019277b15d58faf0541e11910eb756f6",
+}
+
+func Test_serverHash(t *testing.T) {
+	for in, expect := range serverHashTests {
+		if got := serverHash(in, nil, nil); got != expect {
+			t.Errorf("Expected %s, got %s", expect, got)
+		}
+	}
+}
+
+func Test_hasJoined(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/session/minecraft/hasJoined" || q.Get("serverId") != "abc" ||
+			q.Get("username") != "test123" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		fmt.Fprint(w, `{"id":"be4c4b88c56b3b93aec44bc0d038a924","name":"Test123",`+
+			`"properties":[{"name":"textures","value":"dGVzdA==","signature":"c2ln"}]}`)
+	}))
+	defer ts.Close()
+
+	s := &Server{
+		cfg: Config{
+			SessionServer: ts.URL,
+		},
+	}
+
+	p, err := s.hasJoined("test123", "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.name != "Test123" {
+		t.Errorf("Expected name Test123, got %s", p.name)
+	}
+	if p.id.String() != "be4c4b88-c56b-3b93-aec4-4bc0d038a924" {
+		t.Errorf("Unexpected UUID %s", p.id)
+	}
+	if len(p.properties) != 1 || p.properties[0].Name != "textures" || p.properties[0].Signature != "c2ln" {
+		t.Errorf("Unexpected properties %v", p.properties)
+	}
+
+	if _, err := s.hasJoined("test123", "wrong"); err != errNotAuthenticated {
+		t.Errorf("Expected %v, got %v", errNotAuthenticated, err)
+	}
+}
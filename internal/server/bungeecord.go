@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gitfyu/mable/game"
+	"github.com/google/uuid"
+)
+
+var errNoForwardedData = errors.New("handshake does not contain forwarded data")
+
+// forwardedData contains the information that BungeeCord adds to the server address in the handshake if IP forwarding
+// is enabled.
+type forwardedData struct {
+	// host is the server address that the client used to connect to the proxy.
+	host       string
+	ip         net.IP
+	id         uuid.UUID
+	properties []game.ProfileProperty
+}
+
+// parseForwardedData parses the server address from a handshake that was sent by BungeeCord. The address consists of
+// the original address, the player's IP address, UUID and optionally their profile properties encoded as JSON, all
+// separated by null characters.
+func parseForwardedData(addr string) (*forwardedData, error) {
+	parts := strings.Split(addr, "\x00")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, errNoForwardedData
+	}
+
+	f := &forwardedData{
+		host: parts[0],
+		ip:   net.ParseIP(parts[1]),
+	}
+	if f.ip == nil {
+		return nil, fmt.Errorf("bad forwarded IP address %q", parts[1])
+	}
+
+	var err error
+	if f.id, err = uuid.Parse(parts[2]); err != nil {
+		return nil, fmt.Errorf("bad forwarded UUID: %w", err)
+	}
+
+	if len(parts) == 4 {
+		if err := json.Unmarshal([]byte(parts[3]), &f.properties); err != nil {
+			return nil, fmt.Errorf("bad forwarded properties: %w", err)
+		}
+	}
+
+	return f, nil
+}
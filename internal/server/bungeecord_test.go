@@ -0,0 +1,41 @@
+package server
+
+import (
+	"testing"
+)
+
+func Test_parseForwardedData(t *testing.T) {
+	f, err := parseForwardedData("mc.example.com\x00203.0.113.7\x00be4c4b88c56b3b93aec44bc0d038a924\x00" +
+		`[{"name":"textures","value":"dGVzdA==","signature":"c2ln"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.host != "mc.example.com" {
+		t.Errorf("Expected host mc.example.com, got %s", f.host)
+	}
+	if f.ip.String() != "203.0.113.7" {
+		t.Errorf("Expected IP 203.0.113.7, got %s", f.ip)
+	}
+	if f.id.String() != "be4c4b88-c56b-3b93-aec4-4bc0d038a924" {
+		t.Errorf("Unexpected UUID %s", f.id)
+	}
+	if len(f.properties) != 1 || f.properties[0].Value != "dGVzdA==" {
+		t.Errorf("Unexpected properties %v", f.properties)
+	}
+}
+
+func Test_parseForwardedData_Invalid(t *testing.T) {
+	tests := []string{
+		"mc.example.com",
+		"mc.example.com\x00not an ip\x00be4c4b88c56b3b93aec44bc0d038a924",
+		"mc.example.com\x00203.0.113.7\x00not a uuid",
+		"mc.example.com\x00203.0.113.7\x00be4c4b88c56b3b93aec44bc0d038a924\x00{",
+	}
+
+	for _, test := range tests {
+		if _, err := parseForwardedData(test); err == nil {
+			t.Errorf("Expected error for %q", test)
+		}
+	}
+}
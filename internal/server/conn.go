@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/aes"
 	"errors"
 	"net"
 	"sync/atomic"
@@ -16,95 +17,290 @@ import (
 
 // conn represents a client connection.
 type conn struct {
-	serv       *Server
-	logger     log.Logger
-	conn       net.Conn
+	// stats is the first field to guarantee 64-bit alignment for atomic operations.
+	stats  ConnStats
+	serv   *Server
+	logger log.Logger
+	conn   net.Conn
+	// addr is the address of the client. This can differ from conn.RemoteAddr if the client connected through a
+	// proxy.
+	addr net.Addr
+	// forwarded contains the data forwarded by BungeeCord, or nil if forwarding is not used.
+	forwarded *forwardedData
+	// version is the protocol version of the client. It is set after the handshake, before any version dependent
+	// packets are read or written.
+	version    protocol.Version
 	state      protocol.State
 	reader     *packet.Reader
 	writer     *packet.Writer
-	writeQueue chan packet.Outbound
-	closed     int32
-	flushed    chan struct{}
+	writeQueue chan interface{}
+	// spill contains the packets that did not fit in writeQueue if Config.QueuePolicy is QueueSpill, nil otherwise.
+	spill *spillBuffer
+	// slow is set to 1 when the client is being kicked because it cannot keep up.
+	slow   int32
+	closed int32
+	// closing is closed when Close is called, after which no more packets will be queued.
+	closing chan struct{}
+	flushed chan struct{}
 }
 
 func newConn(s *Server, c net.Conn) *conn {
+	queueSize := s.cfg.WriteQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultWriteQueueSize
+	}
+	var spill *spillBuffer
+	if s.cfg.QueuePolicy == QueueSpill {
+		spill = newSpillBuffer(s.cfg.SpillBufferSize)
+	}
+
 	return &conn{
 		serv: s,
 		logger: log.Logger{
 			Name:     "CLIENT " + c.RemoteAddr().String(),
 			MinLevel: s.logger.MinLevel,
+			Level:    s.logger.Level,
 		},
 		conn:  c,
+		addr:  c.RemoteAddr(),
 		state: protocol.StateHandshake,
 		reader: packet.NewReader(c, packet.ReaderConfig{
 			MaxSize: s.cfg.MaxPacketSize,
 		}),
 		writer:     packet.NewWriter(c),
-		writeQueue: make(chan packet.Outbound, 100), // TODO configurable size
+		writeQueue: make(chan interface{}, queueSize),
+		spill:      spill,
+		closing:    make(chan struct{}),
 		flushed:    make(chan struct{}),
 	}
 }
 
+// writerFunc is a function that can be queued in conn.writeQueue to modify the packet.Writer, for example to enable
+// encryption. Since it is executed by conn.dispatchPackets, it will run after all previously queued packets have been
+// written.
+type writerFunc func(w *packet.Writer)
+
+// ConnStats contains counters for the data written to a connection. The counters of a connection are updated
+// atomically, use Server.Connections to obtain a copy.
+type ConnStats struct {
+	// Packets is the number of packets that have been written.
+	Packets uint64
+	// Flushes is the number of times that buffered packets were written to the underlying connection, which is
+	// roughly the number of write syscalls.
+	Flushes uint64
+	// Bytes is the number of bytes that have been flushed, after compression.
+	Bytes uint64
+	// QueuePeak is the highest number of packets that were in the write queue at once.
+	QueuePeak uint64
+	// Dropped is the number of packets that were dropped because the write queue was full.
+	Dropped uint64
+	// Spilled is the number of bytes that were spilled because the write queue was full.
+	Spilled uint64
+}
+
+// load returns a copy of the stats which can safely be read.
+func (s *ConnStats) load() ConnStats {
+	return ConnStats{
+		Packets:   atomic.LoadUint64(&s.Packets),
+		Flushes:   atomic.LoadUint64(&s.Flushes),
+		Bytes:     atomic.LoadUint64(&s.Bytes),
+		QueuePeak: atomic.LoadUint64(&s.QueuePeak),
+		Dropped:   atomic.LoadUint64(&s.Dropped),
+		Spilled:   atomic.LoadUint64(&s.Spilled),
+	}
+}
+
 // dispatchPackets reads packets from conn.writeQueue and dispatches them until the connection is closed or an error
 // occurs. When Close is called, this function will still dispatch packets that have been queued but not sent yet.
+// Instead of flushing every packet separately, all packets that are queued at the same time are written at once.
 func (c *conn) dispatchPackets() {
-	var err error
-	for p := range c.writeQueue {
-		if err = c.conn.SetWriteDeadline(time.Now().Add(time.Second * time.Duration(c.serv.cfg.Timeout))); err != nil {
-			break
+	err := c.dispatchLoop()
+	close(c.flushed)
+
+	if err != nil {
+		c.logger.Debug("Failed to dispatch packet(s)").Err(err).Log()
+		c.Close()
+	}
+}
+
+func (c *conn) dispatchLoop() error {
+	for {
+		var closing bool
+		select {
+		case v := <-c.writeQueue:
+			if err := c.dispatch(v); err != nil {
+				return err
+			}
+		case <-c.closing:
+			closing = true
 		}
 
-		c.writer.WritePacket(p)
+		closing, err := c.drainQueue(closing)
+		if err != nil {
+			return err
+		}
+		if err := c.flush(); err != nil {
+			return err
+		}
+		if closing {
+			return nil
+		}
+	}
+}
 
-		// TODO in the future, as an optimization flushing should probs not be done for every packet
-		if err = c.writer.Flush(); err != nil {
-			break
+// drainQueue dispatches packets from conn.writeQueue until it is empty, or until the current flush window has passed if
+// Config.FlushWindow is set. Spilled packets are dispatched after the queue is empty. It returns true if the
+// connection is being closed, in which case the flush window is skipped.
+func (c *conn) drainQueue(closing bool) (bool, error) {
+	var window <-chan time.Time
+	if d := c.serv.cfg.FlushWindow; d > 0 && !closing {
+		newTimer := c.serv.flushTimer
+		if newTimer == nil {
+			newTimer = newFlushTimer
 		}
+		ch, stop := newTimer(d)
+		defer stop()
+		window = ch
 	}
 
-	close(c.flushed)
+	for {
+		select {
+		case v := <-c.writeQueue:
+			if err := c.dispatch(v); err != nil {
+				return closing, err
+			}
+			continue
+		default:
+		}
 
-	if err != nil {
-		c.logger.Debug("Failed to dispatch packet(s)").Err(err).Log()
-		c.Close()
+		if window == nil {
+			if c.spill != nil {
+				return closing, c.takeSpilled()
+			}
+			return closing, nil
+		}
+
+		select {
+		case v := <-c.writeQueue:
+			if err := c.dispatch(v); err != nil {
+				return closing, err
+			}
+		case <-window:
+			window = nil
+		case <-c.closing:
+			window = nil
+			closing = true
+		}
 	}
 }
 
+// newFlushTimer returns a channel that receives a value at the end of the current flush window of length d, which ends
+// at the next multiple of d, and a function that stops the timer.
+func newFlushTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	now := time.Now()
+	t := time.NewTimer(now.Truncate(d).Add(d).Sub(now))
+	return t.C, t.Stop
+}
+
+// dispatch handles a single value from conn.writeQueue. Packets are only written to the buffer of conn.writer, so
+// conn.flush must be called to send them.
+func (c *conn) dispatch(v interface{}) error {
+	p, ok := v.(packet.Outbound)
+	if !ok {
+		// the function may change how data is written, for example by enabling encryption, so packets that were
+		// written before must be sent the old way
+		if err := c.flush(); err != nil {
+			return err
+		}
+		v.(writerFunc)(c.writer)
+		return nil
+	}
+
+	if err := c.writer.WritePacket(p, c.version); err != nil {
+		// the packet was not written, but the connection is still usable
+		c.logger.Debug("Failed to write packet").Err(err).Log()
+		return nil
+	}
+	atomic.AddUint64(&c.stats.Packets, 1)
+	return nil
+}
+
+// flush sends all buffered packets to the client.
+func (c *conn) flush() error {
+	n := c.writer.Buffered()
+	if n == 0 {
+		return nil
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(time.Second * time.Duration(c.serv.cfg.Timeout))); err != nil {
+		return err
+	}
+	if err := c.writer.Flush(); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&c.stats.Flushes, 1)
+	atomic.AddUint64(&c.stats.Bytes, uint64(n))
+	return nil
+}
+
 func (c *conn) handle() error {
 	go c.dispatchPackets()
 
-	s, ver, err := handleHandshake(c)
+	legacy, err := isLegacyPing(c)
 	if err != nil {
 		return err
 	}
+	if legacy {
+		return handleLegacyPing(c)
+	}
 
-	c.state = s
-	switch s {
+	h, err := handleHandshake(c)
+	if err != nil {
+		return err
+	}
+
+	c.version = protocol.Version(h.ProtoVer)
+	c.state = protocol.State(h.NextState)
+	switch c.state {
 	case protocol.StateStatus:
-		return handleStatus(c)
+		return handleStatus(c, h)
 	case protocol.StateLogin:
-		if ver != 47 {
-			c.Disconnect(&chat.Msg{Text: "Please use Minecraft 1.8."})
+		if !c.version.IsSupported() {
+			c.Disconnect(&chat.Msg{Text: "Please use Minecraft " + supportedVersionNames + "."})
 			return nil
 		}
 
-		username, id, err := handleLogin(c)
+		if c.serv.cfg.BungeeCord {
+			f, err := parseForwardedData(h.Addr)
+			if err != nil {
+				c.Disconnect(&chat.Msg{
+					Text: "If you wish to use IP forwarding, please enable it in your BungeeCord config as well!",
+				})
+				return err
+			}
+			c.setForwardedData(f)
+		}
+
+		p, err := handleLogin(c)
 		if err != nil {
 			return err
 		}
+		defer c.serv.releasePlayerSlot()
 
-		c.logger.Name = "PLAYER " + username
+		c.logger.Name = "PLAYER " + p.name
 		c.logger.Info("Logged in").
-			Stringer("id", id).
+			Stringer("id", p.id).
+			Stringer("addr", c.addr).
 			Log()
 
 		c.state = protocol.StatePlay
 		defer func() {
 			c.logger.Info("Disconnected").
-				Stringer("id", id).
+				Stringer("id", p.id).
 				Log()
 		}()
-		return handlePlay(c, username, id)
+		return handlePlay(c, p)
 	default:
 		return errors.New("unknown state")
 	}
@@ -119,11 +315,32 @@ func (c *conn) Close() error {
 		return nil
 	}
 
-	close(c.writeQueue)
+	close(c.closing)
 	<-c.flushed
+
+	stats := c.stats.load()
+	c.logger.Trace("Connection closed").
+		Uint("packets", stats.Packets).
+		Uint("flushes", stats.Flushes).
+		Uint("bytes", stats.Bytes).
+		Uint("queue_peak", stats.QueuePeak).
+		Uint("dropped", stats.Dropped).
+		Uint("spilled", stats.Spilled).
+		Log()
 	return c.conn.Close()
 }
 
+// RemoteAddr returns the address of the client. If the client connected through a proxy that forwards their address,
+// this is the forwarded address.
+func (c *conn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// Version returns the protocol version of the client.
+func (c *conn) Version() protocol.Version {
+	return c.version
+}
+
 // IsOpen returns whether the connection is still open
 func (c *conn) IsOpen() bool {
 	return atomic.LoadInt32(&c.closed) == 0
@@ -135,12 +352,55 @@ func (c *conn) readPacket() (packet.Inbound, error) {
 		return nil, err
 	}
 
-	return c.reader.ReadPacket(c.state)
+	return c.reader.ReadPacket(c.state, c.version)
+}
+
+// setForwardedData stores the data that was forwarded by BungeeCord and updates the client's address to the forwarded
+// one.
+func (c *conn) setForwardedData(f *forwardedData) {
+	port := 0
+	if tcp, ok := c.addr.(*net.TCPAddr); ok {
+		port = tcp.Port
+	}
+
+	c.forwarded = f
+	c.addr = &net.TCPAddr{
+		IP:   f.ip,
+		Port: port,
+	}
+	c.logger.Name = "CLIENT " + c.addr.String()
 }
 
-// WritePacket writes a single packet to the client. This function may be called concurrently.
-func (c *conn) WritePacket(pk packet.Outbound) {
-	c.writeQueue <- pk
+// enableEncryption enables encryption using the specified shared secret. Packets that are read after this call will be
+// decrypted, packets that are written after this call will be encrypted.
+func (c *conn) enableEncryption(secret []byte) error {
+	decBlock, err := aes.NewCipher(secret)
+	if err != nil {
+		return err
+	}
+	encBlock, err := aes.NewCipher(secret)
+	if err != nil {
+		return err
+	}
+
+	c.reader.EnableEncryption(protocol.NewCFB8Decrypter(decBlock, secret))
+	enc := protocol.NewCFB8Encrypter(encBlock, secret)
+	c.queueWriterFunc(func(w *packet.Writer) {
+		w.EnableEncryption(enc)
+	}, false)
+	return nil
+}
+
+// enableCompression sends the compression threshold to the client and enables compression for all packets that are
+// read or written after this call.
+func (c *conn) enableCompression(threshold int) {
+	c.WritePacket(&login.SetCompression{
+		Threshold: int32(threshold),
+	})
+	c.queueWriterFunc(func(w *packet.Writer) {
+		w.EnableCompression(threshold)
+	}, true)
+	c.reader.EnableCompression(threshold)
 }
 
 // Disconnect kicks the player with a specified reason.
@@ -149,16 +409,25 @@ func (c *conn) Disconnect(reason *chat.Msg) {
 		Stringer("reason", reason).
 		Log()
 
+	if pk := c.disconnectPacket(reason); pk != nil {
+		c.WritePacket(pk)
+	}
+	c.Close()
+}
+
+// disconnectPacket returns the packet that kicks the client in its current state, or nil if the state does not have
+// such a packet.
+func (c *conn) disconnectPacket(reason *chat.Msg) packet.Outbound {
 	switch c.state {
 	case protocol.StatePlay:
-		c.WritePacket(&play.Disconnect{
+		return &play.Disconnect{
 			Reason: reason,
-		})
+		}
 	case protocol.StateLogin:
-		c.WritePacket(&login.Disconnect{
+		return &login.Disconnect{
 			Reason: reason,
-		})
+		}
+	default:
+		return nil
 	}
-
-	c.Close()
 }
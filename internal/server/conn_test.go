@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/play"
+	"github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
+	"github.com/gitfyu/mable/log"
+)
+
+// countingConn is a net.Conn that records all written data and counts the number of writes.
+type countingConn struct {
+	net.Conn
+	writes uint64
+	mu     sync.Mutex
+	data   bytes.Buffer
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	atomic.AddUint64(&c.writes, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data.Write(b)
+}
+
+func (c *countingConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (c *countingConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+func (c *countingConn) Close() error {
+	return nil
+}
+
+func newTestConn(cfg Config) (*conn, *countingConn) {
+	cfg.Timeout = 5
+	s := &Server{
+		cfg: cfg,
+		logger: log.Logger{
+			MinLevel: log.ErrorLevel,
+		},
+	}
+
+	nc := &countingConn{}
+	c := newConn(s, nc)
+	c.version = protocol.Version1_8
+	return c, nc
+}
+
+func TestConn_dispatchPackets_Batch(t *testing.T) {
+	c, nc := newTestConn(Config{})
+
+	const count = 50
+	for i := 0; i < count; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+
+	go c.dispatchPackets()
+	c.Close()
+
+	stats := c.stats.load()
+	if stats.Packets != count {
+		t.Errorf("Expected %d packets, got %d", count, stats.Packets)
+	}
+	if stats.Flushes != 1 || nc.writes != 1 {
+		t.Errorf("Expected a single flush, got %d flushes and %d writes", stats.Flushes, nc.writes)
+	}
+}
+
+func TestServer_Connections(t *testing.T) {
+	c, _ := newTestConn(Config{})
+	c.serv.conns = map[*conn]struct{}{c: {}}
+	c.WritePacket(&play.KeepAlive{})
+	go c.dispatchPackets()
+	c.Close()
+
+	info := c.serv.Connections()
+	if len(info) != 1 {
+		t.Fatalf("Expected 1 connection, got %d", len(info))
+	}
+	if info[0].Stats.Packets != 1 || info[0].Stats.Flushes != 1 {
+		t.Errorf("Expected 1 packet and 1 flush, got %+v", info[0].Stats)
+	}
+}
+
+// waitFor calls cond until it returns true, or fails the test if that takes too long.
+func TestServer_reservePlayerSlot(t *testing.T) {
+	c, _ := newTestConn(Config{MaxPlayers: 2})
+	s := c.serv
+	if !s.reservePlayerSlot() || !s.reservePlayerSlot() {
+		t.Fatal("Expected two slots to be available")
+	}
+	if s.reservePlayerSlot() {
+		t.Error("Expected the server to be full")
+	}
+
+	s.releasePlayerSlot()
+	if !s.reservePlayerSlot() {
+		t.Error("Expected a released slot to be available again")
+	}
+
+	s.SetMaxPlayers(1)
+	if s.reservePlayerSlot() {
+		t.Error("Expected the server to be full after lowering the maximum")
+	}
+}
+
+func waitFor(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+	for start := time.Now(); !cond(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal(msg)
+		}
+	}
+}
+
+func TestConn_dispatchPackets_FlushWindow(t *testing.T) {
+	c, nc := newTestConn(Config{
+		FlushWindow: time.Hour,
+	})
+	windows := make(chan time.Time)
+	c.serv.flushTimer = func(time.Duration) (<-chan time.Time, func() bool) {
+		return windows, func() bool { return true }
+	}
+	go c.dispatchPackets()
+
+	// the window has not passed yet, so nothing should be sent until it ends
+	for i := 0; i < 10; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+	waitFor(t, "Expected the packets to be dispatched", func() bool {
+		return c.stats.load().Packets == 10
+	})
+	if writes := atomic.LoadUint64(&nc.writes); writes != 0 {
+		t.Errorf("Expected no writes before the flush window, got %d", writes)
+	}
+
+	windows <- time.Time{}
+	waitFor(t, "Expected a write at the end of the flush window", func() bool {
+		return atomic.LoadUint64(&nc.writes) == 1
+	})
+
+	c.Close()
+	if nc.writes != 1 {
+		t.Errorf("Expected a single write, got %d", nc.writes)
+	}
+}
+
+// droppablePacket is a packet that may be dropped.
+type droppablePacket struct {
+	play.KeepAlive
+}
+
+func (*droppablePacket) Droppable() bool {
+	return true
+}
+
+func TestConn_WritePacket_Drop(t *testing.T) {
+	c, _ := newTestConn(Config{
+		WriteQueueSize: 1,
+		QueuePolicy:    QueueDrop,
+	})
+
+	c.WritePacket(&play.KeepAlive{})
+	c.WritePacket(&droppablePacket{})
+	if c.stats.Dropped != 1 {
+		t.Errorf("Expected 1 dropped packet, got %d", c.stats.Dropped)
+	}
+	if c.slow != 0 {
+		t.Error("Expected the client not to be kicked")
+	}
+
+	c.WritePacket(&play.KeepAlive{})
+	if atomic.LoadInt32(&c.slow) == 0 {
+		t.Error("Expected the client to be kicked")
+	}
+
+	go c.dispatchPackets()
+	c.Close()
+}
+
+func TestConn_WritePacket_Spill(t *testing.T) {
+	c, nc := newTestConn(Config{
+		WriteQueueSize: 2,
+		QueuePolicy:    QueueSpill,
+	})
+
+	// the dispatcher is not running yet, so everything except the first 2 packets will be spilled
+	const count = 10
+	for i := 0; i < count; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+	if c.stats.Spilled == 0 {
+		t.Error("Expected packets to be spilled")
+	}
+
+	go c.dispatchPackets()
+	c.Close()
+
+	r := packet.NewReader(&nc.data, packet.ReaderConfig{MaxSize: 1 << 16})
+	for i := 0; i < count; i++ {
+		pk, err := r.ReadPacket(protocol.StatePlay, c.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the client-side keep alive packet has the same ID and layout in 1.8
+		if id := pk.(*inbound.KeepAlive).ID; id != int64(i) {
+			t.Errorf("Expected packet %d, got %d", i, id)
+		}
+	}
+}
+
+func TestConn_enableCompression_Spill(t *testing.T) {
+	c, nc := newTestConn(Config{
+		WriteQueueSize: 2,
+		QueuePolicy:    QueueSpill,
+	})
+
+	// the SetCompression packet and the function that enables compression are spilled after the first packets, so
+	// they must only take effect after those packets have been sent
+	const before, after = 5, 3
+	for i := 0; i < before; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+	c.enableCompression(256)
+	for i := before; i < before+after; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+
+	go c.dispatchPackets()
+	c.Close()
+
+	r := packet.NewReader(&nc.data, packet.ReaderConfig{MaxSize: 1 << 16})
+	readKeepAlive := func(i int) {
+		pk, err := r.ReadPacket(protocol.StatePlay, c.version)
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if k, ok := pk.(*inbound.KeepAlive); !ok || k.ID != int64(i) {
+			t.Fatalf("Expected keep-alive %d, got %#v", i, pk)
+		}
+	}
+	for i := 0; i < before; i++ {
+		readKeepAlive(i)
+	}
+	// SetCompression
+	if _, err := r.ReadPacket(protocol.StatePlay, c.version); err != nil {
+		t.Fatal(err)
+	}
+	r.EnableCompression(256)
+	for i := before; i < before+after; i++ {
+		readKeepAlive(i)
+	}
+}
+
+func TestConn_WritePacket_SpillLimit(t *testing.T) {
+	c, _ := newTestConn(Config{
+		WriteQueueSize:  1,
+		QueuePolicy:     QueueSpill,
+		SpillBufferSize: 16,
+	})
+
+	for i := 0; i < 20; i++ {
+		c.WritePacket(&play.KeepAlive{ID: int64(i)})
+	}
+	if atomic.LoadInt32(&c.slow) == 0 {
+		t.Error("Expected the client to be kicked")
+	}
+
+	go c.dispatchPackets()
+	c.Close()
+}
+
+func benchmarkDispatch(b *testing.B, batch int) {
+	c, nc := newTestConn(Config{})
+	go c.dispatchPackets()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < batch; j++ {
+			c.WritePacket(&play.KeepAlive{ID: int64(j)})
+		}
+	}
+	c.Close()
+	b.StopTimer()
+
+	b.ReportMetric(float64(nc.writes)/float64(b.N*batch), "writes/packet")
+}
+
+func BenchmarkConn_dispatchPackets_1(b *testing.B) {
+	benchmarkDispatch(b, 1)
+}
+
+func BenchmarkConn_dispatchPackets_10(b *testing.B) {
+	benchmarkDispatch(b, 10)
+}
+
+func BenchmarkConn_dispatchPackets_100(b *testing.B) {
+	benchmarkDispatch(b, 100)
+}
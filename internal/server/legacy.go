@@ -0,0 +1,115 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/gitfyu/mable/status"
+)
+
+const (
+	// legacyPingID is the first byte sent by clients older than 1.7 when they ping the server. A handshake can also
+	// start with this byte, if its length is 126 modulo 128, so the next bytes must be checked as well.
+	legacyPingID = 0xFE
+	// legacyPingPayload is the byte that follows legacyPingID in pings from clients since 1.4. A handshake with a
+	// length of 254 bytes starts with the same two bytes, followed by its packet ID 0x00.
+	legacyPingPayload = 0x01
+	// legacyPluginMessageID is the byte that follows legacyPingPayload in pings from 1.6 clients. Clients from 1.4 and
+	// 1.5 do not send anything after legacyPingPayload.
+	legacyPluginMessageID = 0xFA
+	// legacyPingTimeout is how long to wait for more data after a client sent legacyPingID. Clients older than 1.6 wait
+	// for the response after sending the first bytes of a ping, so they are only recognized once no more data arrives.
+	legacyPingTimeout = 500 * time.Millisecond
+	// legacyKickID is the ID of the packet used to respond to a legacy ping.
+	legacyKickID = 0xFF
+	// legacyProtocol is the protocol version sent in the legacy ping response. This is the same value that the vanilla
+	// server uses, which tells old clients that they are incompatible.
+	legacyProtocol = 127
+)
+
+// isLegacyPing checks whether the client is sending a legacy server list ping, without consuming any data.
+func isLegacyPing(c *conn) (bool, error) {
+	timeout := time.Second * time.Duration(c.serv.cfg.Timeout)
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return false, err
+	}
+	if b, err := c.reader.Peek(1); err != nil || b[0] != legacyPingID {
+		return false, err
+	}
+
+	if err := c.conn.SetReadDeadline(time.Now().Add(legacyPingTimeout)); err != nil {
+		return false, err
+	}
+	legacy, err := detectLegacyPing(c.reader.Peek(3))
+	if legacy || err != nil {
+		return legacy, err
+	}
+	// the client sent a regular handshake, which is read using the regular timeout
+	return false, c.conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// detectLegacyPing checks whether the first three bytes sent by a client, and the error that occurred while reading
+// them, belong to a legacy server list ping. This is the case if they are legacyPingID, legacyPingPayload and
+// legacyPluginMessageID (1.6), or if the client stopped sending data after legacyPingPayload (1.4 and 1.5) or after
+// legacyPingID (older than 1.4). If the client only stopped sending data because of a timeout, the error is discarded,
+// since a slow client may still send a regular handshake.
+func detectLegacyPing(b []byte, err error) (bool, error) {
+	if len(b) == 3 {
+		return b[0] == legacyPingID && b[1] == legacyPingPayload && b[2] == legacyPluginMessageID, nil
+	}
+
+	var netErr net.Error
+	timeout := errors.As(err, &netErr) && netErr.Timeout()
+	if !timeout && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	legacy := len(b) == 1 && b[0] == legacyPingID || len(b) == 2 && b[0] == legacyPingID && b[1] == legacyPingPayload
+	if legacy || timeout {
+		return legacy, nil
+	}
+	return false, err
+}
+
+// handleLegacyPing responds to a server list ping from a client older than 1.7. Clients from 1.4 until 1.6 send 0xFE
+// followed by 0x01 (and some additional data in 1.6, which can be ignored), even older clients only send 0xFE. Both are
+// answered in the format introduced in 1.4, which is understood by all of these clients.
+func handleLegacyPing(c *conn) error {
+	resp := c.serv.statusResponse(&status.Request{
+		Legacy:     true,
+		RemoteAddr: c.addr,
+	})
+	str := strings.Join([]string{
+		"§1",
+		strconv.Itoa(legacyProtocol),
+		resp.Version.Name,
+		resp.Description.String(),
+		strconv.Itoa(resp.Players.Online),
+		strconv.Itoa(resp.Players.Max),
+	}, "\x00")
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(time.Second * time.Duration(c.serv.cfg.Timeout))); err != nil {
+		return err
+	}
+
+	// The response is written directly, since it does not use the regular packet format. This is safe because
+	// nothing has been written to writeQueue yet.
+	_, err := c.conn.Write(encodeLegacyKick(str))
+	return err
+}
+
+// encodeLegacyKick encodes a legacy kick packet, containing a string encoded as UTF-16BE prefixed by its length in
+// code units.
+func encodeLegacyKick(str string) []byte {
+	chars := utf16.Encode([]rune(str))
+	b := make([]byte, 0, 3+len(chars)*2)
+	b = append(b, legacyKickID, byte(len(chars)>>8), byte(len(chars)))
+	for _, ch := range chars {
+		b = append(b, byte(ch>>8), byte(ch))
+	}
+	return b
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gitfyu/mable/internal/protocol"
+)
+
+func Test_encodeLegacyKick(t *testing.T) {
+	got := encodeLegacyKick("§1\x00127")
+	expect := []byte{
+		0xFF, 0x00, 0x06,
+		0x00, 0xA7, 0x00, '1', 0x00, 0x00, 0x00, '1', 0x00, '2', 0x00, '7',
+	}
+
+	if !bytes.Equal(expect, got) {
+		t.Errorf("Expected %x, got %x", expect, got)
+	}
+}
+
+func Test_detectLegacyPing(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		err    error
+		legacy bool
+	}{
+		{[]byte{0xFE, 0x01, 0xFA}, nil, true},
+		{[]byte{0xFE, 0x01}, io.EOF, true},
+		{[]byte{0xFE, 0x01}, os.ErrDeadlineExceeded, true},
+		{[]byte{0xFE}, io.EOF, true},
+		{[]byte{0xFE}, os.ErrDeadlineExceeded, true},
+		// a handshake of which the length is 126 modulo 128
+		{[]byte{0xFE, 0x02, 0x00}, nil, false},
+		{[]byte{0xFE, 0x02}, os.ErrDeadlineExceeded, false},
+		{[]byte{0x10, 0x00, 0x2F}, nil, false},
+	}
+	for _, test := range tests {
+		legacy, err := detectLegacyPing(test.data, test.err)
+		if err != nil {
+			t.Errorf("%x: %v", test.data, err)
+		}
+		if legacy != test.legacy {
+			t.Errorf("%x: expected %v, got %v", test.data, test.legacy, legacy)
+		}
+	}
+
+	if _, err := detectLegacyPing([]byte{0x10}, io.EOF); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func Test_detectLegacyPing_LongHandshake(t *testing.T) {
+	// the length of this handshake is 254, which is encoded as 0xFE 0x01, like a legacy ping
+	var body bytes.Buffer
+	body.WriteByte(0x00)
+	protocol.WriteVarInt(&body, 47)
+	protocol.WriteString(&body, strings.Repeat("a", 247))
+	protocol.WriteUint16(&body, 25565)
+	protocol.WriteVarInt(&body, 2)
+	var handshake bytes.Buffer
+	protocol.WriteVarInt(&handshake, int32(body.Len()))
+	handshake.Write(body.Bytes())
+
+	b := handshake.Bytes()
+	if b[0] != legacyPingID || b[1] != legacyPingPayload {
+		t.Fatalf("Expected the handshake to start with 0xFE 0x01, got %x", b[:2])
+	}
+	if legacy, err := detectLegacyPing(b[:3], nil); legacy || err != nil {
+		t.Errorf("Expected a regular handshake, got %v, %v", legacy, err)
+	}
+}
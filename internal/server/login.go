@@ -1,28 +1,147 @@
 package server
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
+	"fmt"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/protocol"
 	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/login"
 	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/login"
+	"github.com/gitfyu/mable/login"
 	"github.com/google/uuid"
 )
 
-// handleLogin processes the login sequence. Currently, it only supports offline ('cracked') mode. It returns the
-// player's username and UUID.
-func handleLogin(c *conn) (string, uuid.UUID, error) {
+const (
+	// sharedSecretSize is the size of the AES key that is used for encryption, in bytes.
+	sharedSecretSize = 16
+	// verifyTokenSize is the size of the token that the client must encrypt to prove it used the right public key.
+	verifyTokenSize = 4
+)
+
+var (
+	errBadVerifyToken = errors.New("verify token mismatch")
+	errLoginDenied    = errors.New("login denied")
+	errServerFull     = errors.New("server is full")
+)
+
+// msgServerFull is displayed to players that are kicked because Config.MaxPlayers players are already online.
+const msgServerFull = "The server is full!"
+
+// profile contains the identity of a player that has logged in.
+type profile struct {
+	name       string
+	id         uuid.UUID
+	properties []game.ProfileProperty
+}
+
+// handleLogin processes the login sequence. If BungeeCord forwarding is used, the player's identity is taken from the
+// forwarded data. Otherwise, if online mode is enabled, the player will be authenticated using the session server and
+// encryption will be enabled, else an offline ('cracked') UUID will be generated. Afterwards, the player is kicked if
+// Config.LoginHandler denies them or if the server is full. It returns the player's profile, after which the caller
+// must call Server.releasePlayerSlot once the player disconnects.
+func handleLogin(c *conn) (*profile, error) {
 	username, err := readLoginStart(c)
 	if err != nil {
-		return "", uuid.Nil, err
+		return nil, err
 	}
 
-	// TODO implement authenticated login
+	var p *profile
+	if c.forwarded != nil {
+		// the proxy is responsible for authentication
+		p = &profile{
+			name:       username,
+			id:         c.forwarded.id,
+			properties: c.forwarded.properties,
+		}
+	} else if c.serv.cfg.OnlineMode {
+		if p, err = authenticate(c, username); err != nil {
+			return nil, err
+		}
+	} else {
+		p = &profile{
+			name: username,
+			id:   generateOfflineUUID(username),
+		}
+	}
+
+	if h := c.serv.cfg.LoginHandler; h != nil {
+		reason := h(&login.Request{
+			Name:            p.name,
+			UUID:            p.id,
+			Properties:      p.properties,
+			ProtocolVersion: int32(c.version),
+			RemoteAddr:      c.addr,
+		})
+		if reason != nil {
+			c.Disconnect(reason)
+			return nil, fmt.Errorf("%w: %s", errLoginDenied, reason.String())
+		}
+	}
+	if !c.serv.reservePlayerSlot() {
+		c.Disconnect(&chat.Msg{Text: msgServerFull})
+		return nil, errServerFull
+	}
+
+	// compression is not supported by clients older than 1.8
+	if t := c.serv.cfg.CompressionThreshold; t >= 0 && c.version >= protocol.Version1_8 {
+		c.enableCompression(t)
+	}
 
-	id := generateOfflineUUID(username)
 	c.WritePacket(&outbound.Success{
-		UUID:     id,
-		Username: username,
+		UUID:     p.id,
+		Username: p.name,
 	})
-	return username, id, nil
+	return p, nil
+}
+
+// authenticate performs the encryption handshake with the client and verifies the session using the session server.
+// If this succeeds, encryption will be enabled for the remainder of the connection.
+func authenticate(c *conn, username string) (*profile, error) {
+	token := make([]byte, verifyTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	c.WritePacket(&outbound.EncryptionRequest{
+		ServerID:    "",
+		PublicKey:   c.serv.publicKey,
+		VerifyToken: token,
+	})
+
+	resp, err := readEncryptionResponse(c)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := rsa.DecryptPKCS1v15(rand.Reader, c.serv.privateKey, resp.SharedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting shared secret: %w", err)
+	}
+	if len(secret) != sharedSecretSize {
+		return nil, errors.New("invalid shared secret size")
+	}
+	respToken, err := rsa.DecryptPKCS1v15(rand.Reader, c.serv.privateKey, resp.VerifyToken)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting verify token: %w", err)
+	}
+	if !bytes.Equal(token, respToken) {
+		return nil, errBadVerifyToken
+	}
+
+	if err := c.enableEncryption(secret); err != nil {
+		return nil, err
+	}
+
+	p, err := c.serv.hasJoined(username, serverHash("", secret, c.serv.publicKey))
+	if err != nil {
+		c.Disconnect(&chat.Msg{Text: "Failed to verify username!"})
+		return nil, fmt.Errorf("authenticating %s: %w", username, err)
+	}
+	return p, nil
 }
 
 func readLoginStart(c *conn) (string, error) {
@@ -37,3 +156,16 @@ func readLoginStart(c *conn) (string, error) {
 
 	return l.Username, nil
 }
+
+func readEncryptionResponse(c *conn) (*inbound.EncryptionResponse, error) {
+	pk, err := c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	resp, ok := pk.(*inbound.EncryptionResponse)
+	if !ok {
+		return nil, errors.New("expected encryption response")
+	}
+
+	return resp, nil
+}
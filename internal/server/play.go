@@ -1,36 +1,36 @@
 package server
 
 import (
+	"math"
+
 	"github.com/gitfyu/mable/game"
 	"github.com/gitfyu/mable/internal/protocol/packet/outbound/play"
-	"github.com/google/uuid"
 )
 
 // handlePlay creates the player and handles all packets until the connection is closed.
-func handlePlay(c *conn, username string, id uuid.UUID) error {
+func handlePlay(c *conn, prof *profile) error {
 	g := c.serv.game
-	p := game.NewPlayer(username, id, c)
+	p := game.NewPlayer(prof.name, prof.id, prof.properties, c)
 
 	defer g.Schedule(func() {
+		g.RemovePlayer(p)
 		p.Close()
 	})
 
+	_, maxPlayers := c.serv.statusConfig()
 	g.Schedule(func() {
-		p.SetWorld(g.DefaultWorld())
+		settings := g.DefaultWorld().Settings()
 		c.WritePacket(&play.JoinGame{
 			EntityID:      int(p.EntityID()),
 			Gamemode:      1,
-			Dimension:     0,
-			Difficulty:    1,
-			MaxPlayers:    0,
-			LevelType:     "flat",
+			Dimension:     int8(settings.Dimension),
+			Difficulty:    uint8(settings.Difficulty),
+			MaxPlayers:    uint8(clamp(maxPlayers, 0, math.MaxUint8)),
+			LevelType:     string(settings.LevelType),
 			ReduceDbgInfo: false,
 		})
-		p.Teleport(game.Pos{
-			X: 8,
-			Y: 16,
-			Z: 8,
-		})
+		// the player list can only be sent after JoinGame
+		g.AddPlayer(p)
 	})
 
 	for c.IsOpen() {
@@ -46,3 +46,14 @@ func handlePlay(c *conn, username string, id uuid.UUID) error {
 
 	return nil
 }
+
+// clamp limits v to the range [min,max].
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://www.haproxy.org/download/2.5/doc/proxy-protocol.txt
+
+const (
+	// proxyV1MaxLength is the maximum length of a version 1 header, including the CRLF.
+	proxyV1MaxLength = 107
+
+	proxyV2HeaderSize = 16
+	proxyV2CmdLocal   = 0x0
+	proxyV2CmdProxy   = 0x1
+	proxyV2FamTCP4    = 0x11
+	proxyV2FamTCP6    = 0x21
+)
+
+var (
+	proxyV1Signature = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errNoProxyHeader  = errors.New("missing PROXY protocol header")
+	errBadProxyHeader = errors.New("malformed PROXY protocol header")
+)
+
+// proxyConn is a net.Conn of which the PROXY protocol header has already been read.
+type proxyConn struct {
+	net.Conn
+	// r contains the data that was buffered while reading the header, followed by the rest of the connection.
+	r    *bufio.Reader
+	addr net.Addr
+}
+
+// Read implements net.Conn.Read.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the client address from the PROXY protocol header.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// parseTrustedProxies parses a list of CIDR notation IP networks.
+func parseTrustedProxies(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("bad trusted proxy: %w", err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// isTrustedProxy checks whether a connection from the specified address may send a PROXY protocol header.
+func (s *Server) isTrustedProxy(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, n := range s.trustedProxies {
+		if n.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// readProxyHeader reads the PROXY protocol header from c, and returns a net.Conn that reports the address from the
+// header as its remote address.
+func readProxyHeader(c net.Conn, timeout time.Duration) (net.Conn, error) {
+	if err := c.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(c)
+	addr, err := parseProxyHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if addr == nil {
+		// the proxy did not provide an address, for example because it is a health check
+		addr = c.RemoteAddr()
+	}
+
+	return &proxyConn{
+		Conn: c,
+		r:    r,
+		addr: addr,
+	}, nil
+}
+
+// parseProxyHeader reads a version 1 or 2 PROXY protocol header. It returns the source address from the header, or nil
+// if the header does not contain one.
+func parseProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	sig, err := r.Peek(len(proxyV1Signature))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(sig, proxyV1Signature) {
+		return parseProxyV1(r)
+	}
+
+	sig, err = r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(sig, proxyV2Signature) {
+		return parseProxyV2(r)
+	}
+
+	return nil, errNoProxyHeader
+}
+
+// parseProxyV1 reads a version 1 (text) header, such as "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func parseProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+
+		if b == '\n' {
+			break
+		}
+		if len(line) == proxyV1MaxLength {
+			return nil, errBadProxyHeader
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errBadProxyHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errBadProxyHeader
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (ip.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, errBadProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errBadProxyHeader
+	}
+
+	return &net.TCPAddr{
+		IP:   ip,
+		Port: int(port),
+	}, nil
+}
+
+// parseProxyV2 reads a version 2 (binary) header.
+func parseProxyV2(r *bufio.Reader) (net.Addr, error) {
+	var hdr [proxyV2HeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	if hdr[12]>>4 != 2 {
+		return nil, errBadProxyHeader
+	}
+	cmd := hdr[12] & 0xF
+	fam := hdr[13]
+
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	switch cmd {
+	case proxyV2CmdLocal:
+		return nil, nil
+	case proxyV2CmdProxy:
+	default:
+		return nil, errBadProxyHeader
+	}
+
+	var ipLen int
+	switch fam {
+	case proxyV2FamTCP4:
+		ipLen = net.IPv4len
+	case proxyV2FamTCP6:
+		ipLen = net.IPv6len
+	default:
+		// other protocols, such as UDP or unix sockets, are not useful here
+		return nil, nil
+	}
+
+	// the body contains the source address, destination address, source port and destination port, possibly followed
+	// by TLVs which are ignored
+	if len(body) < ipLen*2+4 {
+		return nil, errBadProxyHeader
+	}
+	ip := make(net.IP, ipLen)
+	copy(ip, body[:ipLen])
+
+	return &net.TCPAddr{
+		IP:   ip,
+		Port: int(binary.BigEndian.Uint16(body[ipLen*2:])),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+type proxyHeaderTest struct {
+	name string
+	data []byte
+	// addr is the expected address, or empty if no address is expected.
+	addr string
+}
+
+func proxyV2Header(cmd, fam byte, body []byte) []byte {
+	b := append([]byte{}, proxyV2Signature...)
+	b = append(b, 0x20|cmd, fam, byte(len(body)>>8), byte(len(body)))
+	return append(b, body...)
+}
+
+var proxyHeaderTests = []proxyHeaderTest{
+	{
+		name: "v1 TCP4",
+		data: []byte("PROXY TCP4 203.0.113.7 192.168.0.11 56324 25565\r\n"),
+		addr: "203.0.113.7:56324",
+	},
+	{
+		name: "v1 TCP6",
+		data: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 25565\r\n"),
+		addr: "[2001:db8::1]:56324",
+	},
+	{
+		name: "v1 UNKNOWN",
+		data: []byte("PROXY UNKNOWN\r\n"),
+	},
+	{
+		name: "v2 TCP4",
+		data: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, []byte{
+			203, 0, 113, 7,
+			192, 168, 0, 11,
+			0xdc, 0x04,
+			0x63, 0xdd,
+		}),
+		addr: "203.0.113.7:56324",
+	},
+	{
+		name: "v2 TCP4 with TLV",
+		data: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, []byte{
+			203, 0, 113, 7,
+			192, 168, 0, 11,
+			0xdc, 0x04,
+			0x63, 0xdd,
+			0x04, 0x00, 0x01, 0x00,
+		}),
+		addr: "203.0.113.7:56324",
+	},
+	{
+		name: "v2 TCP6",
+		data: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP6, append(append(
+			net.ParseIP("2001:db8::1").To16(),
+			net.ParseIP("2001:db8::2").To16()...),
+			0xdc, 0x04, 0x63, 0xdd),
+		),
+		addr: "[2001:db8::1]:56324",
+	},
+	{
+		name: "v2 LOCAL",
+		data: proxyV2Header(proxyV2CmdLocal, 0, nil),
+	},
+}
+
+func Test_parseProxyHeader(t *testing.T) {
+	const trailer = "trailer"
+
+	for _, test := range proxyHeaderTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(append(test.data, trailer...)))
+			addr, err := parseProxyHeader(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.addr == "" {
+				if addr != nil {
+					t.Errorf("Expected no address, got %s", addr)
+				}
+			} else if addr == nil || addr.String() != test.addr {
+				t.Errorf("Expected %s, got %v", test.addr, addr)
+			}
+
+			// the data following the header must not be consumed
+			rest, _ := io.ReadAll(r)
+			if string(rest) != trailer {
+				t.Errorf("Expected remaining data %q, got %q", trailer, rest)
+			}
+		})
+	}
+}
+
+func Test_parseProxyHeader_Invalid(t *testing.T) {
+	tests := [][]byte{
+		[]byte("\x10\x00\x2f\x02\x09localhost\x63\xdd\x02"),
+		[]byte("PROXY TCP4 203.0.113.7 192.168.0.11 56324\r\n"),
+		[]byte("PROXY TCP4 2001:db8::1 2001:db8::2 56324 25565\r\n"),
+		[]byte("PROXY TCP4 203.0.113.7 192.168.0.11 56324 25565\n"),
+		proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, []byte{203, 0, 113, 7}),
+	}
+
+	for _, test := range tests {
+		if _, err := parseProxyHeader(bufio.NewReader(bytes.NewReader(test))); err == nil {
+			t.Errorf("Expected error for %q", test)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol/packet"
+)
+
+const (
+	defaultWriteQueueSize  = 100
+	defaultSpillBufferSize = 1 << 20
+)
+
+var (
+	defaultSlowClientReason = &chat.Msg{Text: "Your connection cannot keep up with the server"}
+
+	errUnknownQueuePolicy = errors.New("unknown queue policy")
+)
+
+// QueuePolicy determines what happens when a packet is sent to a client of which the write queue is full, which
+// usually means that the client is not reading data fast enough. With every policy except QueueBlock, sending a
+// packet never blocks the caller.
+type QueuePolicy int
+
+const (
+	// QueueBlock waits until there is room in the queue. A single stalled client can block the caller until the
+	// connection times out.
+	QueueBlock QueuePolicy = iota
+	// QueueDrop drops packets that implement packet.Droppable. Other packets are handled like QueueKick.
+	QueueDrop
+	// QueueKick kicks the client using Config.SlowClientReason.
+	QueueKick
+	// QueueSpill encodes packets into a buffer that is bounded by Config.SpillBufferSize, which is sent once the
+	// queue has been drained. If the buffer is full, the client is kicked like QueueKick.
+	QueueSpill
+)
+
+var queuePolicyNames = []string{
+	"block",
+	"drop",
+	"kick",
+	"spill",
+}
+
+// ParseQueuePolicy parses the name of a QueuePolicy, such as "spill".
+func ParseQueuePolicy(str string) (QueuePolicy, error) {
+	for i, name := range queuePolicyNames {
+		if strings.EqualFold(str, name) {
+			return QueuePolicy(i), nil
+		}
+	}
+	return 0, errUnknownQueuePolicy
+}
+
+// String returns the name of the QueuePolicy.
+func (p QueuePolicy) String() string {
+	if p < 0 || int(p) >= len(queuePolicyNames) {
+		return "unknown"
+	}
+	return queuePolicyNames[p]
+}
+
+// spillBuffer holds packets that did not fit in the write queue of a conn, in encoded form.
+type spillBuffer struct {
+	// mu must be held while packets are spilled, and while the dispatcher takes the spilled packets. This guarantees
+	// that the spilled packets are sent after all packets in the write queue.
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writer *packet.Writer
+	limit  int
+	// funcs contains the writerFunc values that were spilled, in the order in which they were spilled.
+	funcs []spilledFunc
+}
+
+// spilledFunc is a writerFunc that did not fit in the write queue. It must be executed after the packets that were
+// spilled before it, and before the packets that were spilled after it.
+type spilledFunc struct {
+	// offset is the length of spillBuffer.buf at the time the function was spilled.
+	offset int
+	fn     writerFunc
+}
+
+// empty returns whether nothing has been spilled.
+func (s *spillBuffer) empty() bool {
+	return s.buf.Len() == 0 && len(s.funcs) == 0
+}
+
+func newSpillBuffer(limit int) *spillBuffer {
+	if limit <= 0 {
+		limit = defaultSpillBufferSize
+	}
+
+	s := &spillBuffer{
+		limit: limit,
+	}
+	s.writer = packet.NewWriter(&s.buf)
+	return s
+}
+
+// WritePacket writes a single packet to the client. This function may be called concurrently. If the write queue is
+// full, Config.QueuePolicy determines what happens.
+func (c *conn) WritePacket(pk packet.Outbound) {
+	if atomic.LoadInt32(&c.slow) != 0 {
+		// the client is being kicked, so there is no point in sending more packets
+		return
+	}
+
+	switch c.serv.cfg.QueuePolicy {
+	case QueueBlock:
+		c.enqueue(pk)
+	case QueueSpill:
+		c.writeOrSpill(pk)
+	default:
+		if c.tryEnqueue(pk) {
+			return
+		}
+		if d, ok := pk.(packet.Droppable); ok && c.serv.cfg.QueuePolicy == QueueDrop && d.Droppable() {
+			atomic.AddUint64(&c.stats.Dropped, 1)
+			return
+		}
+		c.kickSlow()
+	}
+}
+
+// enqueue adds a value to conn.writeQueue, blocking until there is room. If the connection is closed before that,
+// the value is discarded.
+func (c *conn) enqueue(v interface{}) {
+	select {
+	case c.writeQueue <- v:
+		c.updateQueuePeak()
+	case <-c.closing:
+	}
+}
+
+// tryEnqueue adds a value to conn.writeQueue if there is room, and returns whether it did.
+func (c *conn) tryEnqueue(v interface{}) bool {
+	select {
+	case c.writeQueue <- v:
+		c.updateQueuePeak()
+		return true
+	default:
+		return false
+	}
+}
+
+// updateQueuePeak records the current length of conn.writeQueue if it is the highest length so far.
+func (c *conn) updateQueuePeak() {
+	n := uint64(len(c.writeQueue))
+	for {
+		peak := atomic.LoadUint64(&c.stats.QueuePeak)
+		if n <= peak || atomic.CompareAndSwapUint64(&c.stats.QueuePeak, peak, n) {
+			return
+		}
+	}
+}
+
+// writeOrSpill queues a packet, or spills it if the queue is full. Once packets have been spilled, all further
+// packets are spilled as well until the dispatcher has taken them, to preserve the order of the packets.
+func (c *conn) writeOrSpill(pk packet.Outbound) {
+	s := c.spill
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.empty() && c.tryEnqueue(pk) {
+		return
+	}
+
+	n := s.buf.Len()
+	if err := s.writer.WritePacket(pk, c.version); err != nil {
+		c.logger.Debug("Failed to write packet").Err(err).Log()
+		return
+	}
+	if err := s.writer.Flush(); err != nil {
+		// writing to a bytes.Buffer does not fail
+		panic(err)
+	}
+	atomic.AddUint64(&c.stats.Spilled, uint64(s.buf.Len()-n))
+
+	if s.buf.Len() > s.limit {
+		c.kickSlow()
+	}
+}
+
+// queueWriterFunc queues fn behind all packets that have been written so far. If packets have been spilled, fn is
+// spilled as well, so it is executed after those packets have been sent. If encoding is true, fn changes how packets
+// are encoded, for example by enabling compression, so it is also applied to the writer of the spill buffer. Functions
+// that change how data is flushed, such as enabling encryption, must not be applied to that writer, since spilled
+// packets are flushed by conn.writer.
+func (c *conn) queueWriterFunc(fn writerFunc, encoding bool) {
+	s := c.spill
+	if s == nil {
+		c.enqueue(fn)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// packets that are spilled after this point are sent after fn has been executed
+	if encoding {
+		fn(s.writer)
+	}
+	if s.empty() && c.tryEnqueue(fn) {
+		return
+	}
+	s.funcs = append(s.funcs, spilledFunc{
+		offset: s.buf.Len(),
+		fn:     fn,
+	})
+}
+
+// takeSpilled adds all spilled packets to conn.writer and executes the spilled functions between them, after
+// dispatching the packets that are still queued.
+func (c *conn) takeSpilled() error {
+	s := c.spill
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.empty() {
+		return nil
+	}
+
+	// no packets can be queued while the lock is held, so after this loop all remaining packets have been spilled
+	for {
+		select {
+		case v := <-c.writeQueue:
+			if err := c.dispatch(v); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+		break
+	}
+
+	data := s.buf.Bytes()
+	start := 0
+	for _, f := range s.funcs {
+		c.writer.WriteEncoded(data[start:f.offset])
+		start = f.offset
+		if err := c.dispatch(f.fn); err != nil {
+			return err
+		}
+	}
+	c.writer.WriteEncoded(data[start:])
+
+	s.buf.Reset()
+	s.funcs = nil
+	return nil
+}
+
+// kickSlow kicks the client because it cannot keep up with the server. The Disconnect packet is queued behind all
+// other packets by a separate goroutine, so the caller is never blocked.
+func (c *conn) kickSlow() {
+	if !atomic.CompareAndSwapInt32(&c.slow, 0, 1) {
+		return
+	}
+
+	c.logger.Warn("Client cannot keep up, kicking").
+		Int("queued", int64(len(c.writeQueue))).
+		Stringer("policy", c.serv.cfg.QueuePolicy).
+		Log()
+
+	reason := c.serv.cfg.SlowClientReason
+	if reason == nil {
+		reason = defaultSlowClientReason
+	}
+	go func() {
+		if pk := c.disconnectPacket(reason); pk != nil {
+			c.enqueue(pk)
+		}
+		c.Close()
+	}()
+}
@@ -1,11 +1,19 @@
 package server
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"net"
 	"runtime/debug"
+	"sync"
+	"time"
 
+	"github.com/gitfyu/mable/chat"
 	"github.com/gitfyu/mable/game"
 	"github.com/gitfyu/mable/log"
+	"github.com/gitfyu/mable/login"
+	"github.com/gitfyu/mable/status"
 )
 
 // Config is used to configure a Server.
@@ -14,30 +22,142 @@ type Config struct {
 	MaxPacketSize int
 	Timeout       int
 	LogLevel      string
+	// OnlineMode enables authentication of players using the session server.
+	OnlineMode bool
+	// SessionServer is the base URL of the session server that is used to authenticate players if OnlineMode is set,
+	// such as https://sessionserver.mojang.com.
+	SessionServer string
+	// CompressionThreshold is the minimum size in bytes of a packet before it is compressed. A negative value disables
+	// compression. Compression is never used for clients older than 1.8, since they do not support it.
+	CompressionThreshold int
+	// BungeeCord enables BungeeCord IP forwarding, which allows the server to receive the client's real IP address and
+	// UUID from the proxy. Clients that do not connect through the proxy will be rejected if this is enabled.
+	BungeeCord bool
+	// ProxyProtocol enables the PROXY protocol (version 1 and 2), which allows a load balancer to send the client's
+	// real address. Only connections from ProxyTrusted are expected to send a PROXY protocol header, other connections
+	// are handled as regular connections.
+	ProxyProtocol bool
+	// ProxyTrusted is a list of networks in CIDR notation, such as 10.0.0.0/8, from which PROXY protocol headers are
+	// accepted.
+	ProxyTrusted []string
+	// MaxPlayers is the maximum number of players that can be online at the same time, which is also displayed in the
+	// server list. Players that log in while the server is full are kicked. It can be changed at runtime using
+	// Server.SetMaxPlayers, which does not affect players that are already online.
+	MaxPlayers int
+	// MOTD is the description displayed in the server list. It can be changed at runtime using Server.SetMOTD.
+	MOTD *chat.Msg
+	// Favicon is the path to a 64x64 PNG image to display in the server list, or empty to use the default icon.
+	Favicon string
+	// StatusHandler can optionally be used to customize the information displayed in the server list.
+	StatusHandler status.Handler
+	// LoginHandler can optionally be used to decide which players may join the server.
+	LoginHandler login.Handler
+	// FlushWindow is the maximum time that packets are buffered before they are sent to a client. Flushes are aligned
+	// to multiples of FlushWindow, so setting it to the tick interval results in roughly one write per tick for each
+	// client. If it is zero, packets are sent as soon as the write queue has been drained.
+	FlushWindow time.Duration
+	// WriteQueueSize is the number of packets that can be queued for a client before QueuePolicy is applied. If it is
+	// zero, a default size of 100 is used.
+	WriteQueueSize int
+	// QueuePolicy determines what happens when a packet is sent to a client of which the write queue is full.
+	QueuePolicy QueuePolicy
+	// SpillBufferSize is the maximum number of bytes that can be spilled for a client if QueuePolicy is QueueSpill,
+	// before the client is kicked. If it is zero, a default size of 1 MiB is used.
+	SpillBufferSize int
+	// SlowClientReason is displayed to clients that are kicked because they cannot keep up with the server. If it is
+	// nil, a default message is used.
+	SlowClientReason *chat.Msg
 }
 
 type Server struct {
-	cfg      Config
+	// cfgMu guards the fields of cfg that can be changed while the server is running, see SetMOTD and SetMaxPlayers.
+	// It also guards players, so it can be compared to Config.MaxPlayers.
+	cfgMu sync.RWMutex
+	cfg   Config
+	// players is the number of players that have logged in and have not disconnected yet.
+	players  int
 	listener net.Listener
 	logger   log.Logger
 	game     *game.Game
+	// privateKey is used to exchange the shared secret with clients in online mode, nil otherwise.
+	privateKey *rsa.PrivateKey
+	// publicKey is the DER encoded public key belonging to privateKey.
+	publicKey []byte
+	// trustedProxies contains the parsed networks from Config.ProxyTrusted.
+	trustedProxies []*net.IPNet
+	// favicon is the data URI of the image loaded from Config.Favicon.
+	favicon string
+	// flushTimer creates the timers that end the flush windows of connections. If it is nil, newFlushTimer is used. It
+	// can be replaced in tests, so they do not depend on the clock.
+	flushTimer func(d time.Duration) (<-chan time.Time, func() bool)
+
+	// connsMu guards conns.
+	connsMu sync.Mutex
+	// conns contains the connections that are currently being handled.
+	conns map[*conn]struct{}
+}
+
+// ConnInfo describes a connection to the server.
+type ConnInfo struct {
+	// Addr is the remote address of the connection. For connections that use the PROXY protocol, this is the address
+	// that was sent by the proxy.
+	Addr  net.Addr
+	Stats ConnStats
 }
 
 func NewServer(cfg Config, g *game.Game) (*Server, error) {
+	s := &Server{
+		cfg: cfg,
+		logger: log.Logger{
+			Name:  "SERVER",
+			Level: log.NewLevelVar(log.LevelFromString(cfg.LogLevel)),
+		},
+		game:  g,
+		conns: make(map[*conn]struct{}),
+	}
+
+	if cfg.OnlineMode {
+		if err := s.generateKeyPair(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Favicon != "" {
+		var err error
+		if s.favicon, err = status.LoadFavicon(cfg.Favicon); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ProxyProtocol {
+		var err error
+		if s.trustedProxies, err = parseTrustedProxies(cfg.ProxyTrusted); err != nil {
+			return nil, err
+		}
+	}
+
 	l, err := net.Listen("tcp", cfg.Addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Server{
-		cfg:      cfg,
-		listener: l,
-		logger: log.Logger{
-			Name:     "SERVER",
-			MinLevel: log.LevelFromString(cfg.LogLevel),
-		},
-		game: g,
-	}, nil
+	s.listener = l
+	return s, nil
+}
+
+// generateKeyPair generates the RSA key pair that is used for online mode authentication.
+func (s *Server) generateKeyPair() error {
+	// The vanilla server and client use 1024-bit keys as well
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		return err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	s.privateKey = key
+	s.publicKey = pub
+	return nil
 }
 
 // Addr returns the address that the server is listening on.
@@ -45,6 +165,58 @@ func (s *Server) Addr() net.Addr {
 	return s.listener.Addr()
 }
 
+// LogLevel returns the minimum level of the loggers used by the server. It can be changed at runtime, or shared with
+// other loggers.
+func (s *Server) LogLevel() *log.LevelVar {
+	return s.logger.Level
+}
+
+// SetMOTD changes the description displayed in the server list. This function may be called concurrently.
+func (s *Server) SetMOTD(motd *chat.Msg) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	s.cfg.MOTD = motd
+}
+
+// SetMaxPlayers changes the maximum number of players that can be online at the same time. This function may be
+// called concurrently.
+func (s *Server) SetMaxPlayers(n int) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	s.cfg.MaxPlayers = n
+}
+
+// reservePlayerSlot counts a player that is logging in towards Config.MaxPlayers, unless the server is full, in which
+// case it returns false. The slot must be released using releasePlayerSlot once the player disconnects.
+func (s *Server) reservePlayerSlot() bool {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	if s.players >= s.cfg.MaxPlayers {
+		return false
+	}
+	s.players++
+	return true
+}
+
+// releasePlayerSlot releases a slot that was reserved using reservePlayerSlot.
+func (s *Server) releasePlayerSlot() {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+
+	s.players--
+}
+
+// statusConfig returns the current MOTD and maximum number of players.
+func (s *Server) statusConfig() (*chat.Msg, int) {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+
+	return s.cfg.MOTD, s.cfg.MaxPlayers
+}
+
 // ListenAndServe will run the Server
 func (s *Server) ListenAndServe() error {
 	for {
@@ -65,12 +237,38 @@ func (s *Server) handleConn(c net.Conn) {
 		}
 	}()
 
+	if s.cfg.ProxyProtocol && s.isTrustedProxy(c.RemoteAddr()) {
+		pc, err := readProxyHeader(c, time.Second*time.Duration(s.cfg.Timeout))
+		if err != nil {
+			s.logger.Debug("Bad PROXY protocol header").
+				Err(err).
+				Stringer("src", c.RemoteAddr()).
+				Log()
+			c.Close()
+			return
+		}
+
+		s.logger.Trace("Read PROXY protocol header").
+			Stringer("proxy", c.RemoteAddr()).
+			Stringer("src", pc.RemoteAddr()).
+			Log()
+		c = pc
+	}
+
 	s.logger.Debug("New connection").
 		Stringer("src", c.RemoteAddr()).
 		Log()
 
 	h := newConn(s, c)
-	defer h.Close()
+	s.connsMu.Lock()
+	s.conns[h] = struct{}{}
+	s.connsMu.Unlock()
+	defer func() {
+		h.Close()
+		s.connsMu.Lock()
+		delete(s.conns, h)
+		s.connsMu.Unlock()
+	}()
 
 	if err := h.handle(); err != nil {
 		s.logger.Debug("Connection error").
@@ -84,6 +282,22 @@ func (s *Server) handleConn(c net.Conn) {
 	}
 }
 
+// Connections returns information about all connections that are currently open, including their stats. This function
+// may be called concurrently.
+func (s *Server) Connections() []ConnInfo {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	info := make([]ConnInfo, 0, len(s.conns))
+	for c := range s.conns {
+		info = append(info, ConnInfo{
+			Addr:  c.conn.RemoteAddr(),
+			Stats: c.stats.load(),
+		})
+	}
+	return info
+}
+
 // Close stops the server.
 func (s *Server) Close() error {
 	return s.listener.Close()
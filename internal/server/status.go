@@ -1,22 +1,91 @@
 package server
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/internal/protocol/packet/inbound/handshake"
 	inbound "github.com/gitfyu/mable/internal/protocol/packet/inbound/status"
 	outbound "github.com/gitfyu/mable/internal/protocol/packet/outbound/status"
+	"github.com/gitfyu/mable/status"
+)
+
+const (
+	// supportedVersionNames is the range of supported versions, which is displayed to clients that use an unsupported
+	// protocol version.
+	supportedVersionNames = "1.7.2-1.12.2"
+	// statusSampleSize is the maximum number of players in status.Players.Sample, the same as in vanilla.
+	statusSampleSize = 12
 )
 
-// TODO implement a way to properly generate the JSON response in the future
-const defaultResponse = `{"version":{"name":"1.7.6-1.8.9","protocol":47},"players":{"max":0,"online":0},"description":{"text":"Hello world"}}`
+// statusResponse generates the information to display in the server list for a client, based on the current state of
+// the game.
+func (s *Server) statusResponse(req *status.Request) *status.Response {
+	// supported clients are sent their own protocol version, so they are displayed as compatible
+	ver := protocol.Version1_8
+	if v := protocol.Version(req.ProtocolVersion); v.IsSupported() {
+		ver = v
+	}
+
+	motd, maxPlayers := s.statusConfig()
+	resp := &status.Response{
+		Version: status.Version{
+			Name:     supportedVersionNames,
+			Protocol: int32(ver),
+		},
+		Players: status.Players{
+			Max: maxPlayers,
+		},
+		Description: motd,
+		Favicon:     s.favicon,
+	}
+	if resp.Description == nil {
+		resp.Description = &chat.Msg{}
+	}
+
+	players := s.game.Players()
+	resp.Players.Online = len(players)
+	if !req.Legacy {
+		for i := 0; i < len(players) && i < statusSampleSize; i++ {
+			resp.Players.Sample = append(resp.Players.Sample, status.PlayerSample{
+				Name: players[i].Name(),
+				ID:   players[i].UUID(),
+			})
+		}
+	}
+
+	if s.cfg.StatusHandler != nil {
+		s.cfg.StatusHandler(req, resp)
+	}
+	return resp
+}
 
 // handleStatus processes the status flow.
-func handleStatus(c *conn) error {
+func handleStatus(c *conn, h *handshake.Handshake) error {
 	if err := readStatusRequest(c); err != nil {
 		return err
 	}
 
+	// the address may contain additional data, for example if it was sent by BungeeCord or a Forge client
+	addr := h.Addr
+	if i := strings.IndexByte(addr, 0); i >= 0 {
+		addr = addr[:i]
+	}
+
+	content, err := json.Marshal(c.serv.statusResponse(&status.Request{
+		Addr:            addr,
+		Port:            h.Port,
+		ProtocolVersion: h.ProtoVer,
+		RemoteAddr:      c.addr,
+	}))
+	if err != nil {
+		return err
+	}
 	c.WritePacket(&outbound.Response{
-		Content: defaultResponse,
+		Content: string(content),
 	})
 
 	time, err := readStatusPing(c)
@@ -35,7 +104,7 @@ func readStatusRequest(c *conn) error {
 	if err != nil {
 		return err
 	}
-	if _, ok := pk.(*inbound.Request); ok {
+	if _, ok := pk.(inbound.Request); !ok {
 		return errors.New("expected status request")
 	}
 
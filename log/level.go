@@ -1,6 +1,9 @@
 package log
 
-import "strings"
+import (
+	"strings"
+	"sync/atomic"
+)
 
 // Level represents a logging level, with TraceLevel being the lowest and ErrorLevel the highest. The default Level (its
 // zero value) is InfoLevel.
@@ -46,3 +49,24 @@ func LevelFromString(str string) Level {
 func (l Level) String() string {
 	return levelStrings[l+2]
 }
+
+// LevelVar is a Level that can be changed while it is used by multiple Loggers, which allows adjusting the logging
+// level at runtime. Its zero value is InfoLevel. All functions may be called concurrently.
+type LevelVar struct {
+	lvl int32
+}
+
+// NewLevelVar creates a LevelVar with the specified initial Level.
+func NewLevelVar(lvl Level) *LevelVar {
+	return &LevelVar{lvl: int32(lvl)}
+}
+
+// Level returns the current Level.
+func (v *LevelVar) Level() Level {
+	return Level(atomic.LoadInt32(&v.lvl))
+}
+
+// Set changes the current Level.
+func (v *LevelVar) Set(lvl Level) {
+	atomic.StoreInt32(&v.lvl, int32(lvl))
+}
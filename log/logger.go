@@ -22,6 +22,10 @@ type Logger struct {
 
 	// MinLevel is the minimum New that will be logged, messages with a lower level will be dropped
 	MinLevel Level
+
+	// Level can optionally be set to share a minimum level between multiple Loggers that can be changed at runtime,
+	// in which case MinLevel is ignored
+	Level *LevelVar
 }
 
 // Trace is a shortcut for New(TraceLevel, msg).
@@ -49,11 +53,19 @@ func (l *Logger) Error(msg string) *Msg {
 	return l.New(ErrorLevel, msg)
 }
 
-// New creates a new Msg with the specified Level. If lvl is below MinLevel, this function does nothing and returns nil.
-// The message will not be written until Msg.Log is called.
+// New creates a new Msg with the specified Level. If lvl is below the minimum level, this function does nothing and
+// returns nil. The message will not be written until Msg.Log is called.
 func (l *Logger) New(lvl Level, msg string) *Msg {
-	if lvl < l.MinLevel {
+	if lvl < l.minLevel() {
 		return nil
 	}
 	return createMsg(lvl, l.Name, msg)
 }
+
+// minLevel returns the current minimum level of the Logger.
+func (l *Logger) minLevel() Level {
+	if l.Level != nil {
+		return l.Level.Level()
+	}
+	return l.MinLevel
+}
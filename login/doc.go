@@ -0,0 +1,2 @@
+// Package login contains types that can be used to decide which players may join the server.
+package login
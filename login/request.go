@@ -0,0 +1,29 @@
+package login
+
+import (
+	"net"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/google/uuid"
+)
+
+// Request contains information about a player that is logging in. The identity of the player has already been
+// verified if online mode or IP forwarding is used.
+type Request struct {
+	// Name is the username of the player.
+	Name string
+	// UUID is the UUID of the player. In offline mode, this UUID is derived from Name.
+	UUID uuid.UUID
+	// Properties are the properties of the player's profile, such as their skin.
+	Properties []game.ProfileProperty
+	// ProtocolVersion is the protocol version of the client.
+	ProtocolVersion int32
+	// RemoteAddr is the address of the client. If the player connected through a proxy that forwards their address,
+	// this is the forwarded address.
+	RemoteAddr net.Addr
+}
+
+// Handler decides whether a player may join the server. It returns nil to allow the player to join, or the reason
+// that is displayed to the player when they are denied. A Handler may be called concurrently.
+type Handler func(req *Request) *chat.Msg
@@ -0,0 +1,18 @@
+/*
+Package nbt implements the Named Binary Tag format, which Minecraft uses to store worlds and schematics.
+
+Tags are decoded into regular Go values, and Write encodes the same values. Compounds are decoded as Compound and lists
+as List, the other tags use the following types:
+	TagByte      int8
+	TagShort     int16
+	TagInt       int32
+	TagLong      int64
+	TagFloat     float32
+	TagDouble    float64
+	TagByteArray []byte
+	TagString    string
+	TagIntArray  []int32
+	TagLongArray []int64
+Files are usually compressed using gzip or zlib, which must be handled by the caller.
+*/
+package nbt
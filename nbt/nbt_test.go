@@ -0,0 +1,92 @@
+package nbt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	c := Compound{
+		"byte":      int8(-1),
+		"short":     int16(300),
+		"int":       int32(-70000),
+		"long":      int64(1) << 40,
+		"float":     float32(1.5),
+		"double":    2.25,
+		"bytes":     []byte{1, 2, 3},
+		"string":    "hello",
+		"ints":      []int32{1, -2},
+		"longs":     []int64{1 << 33},
+		"empty":     List{},
+		"compounds": List{Compound{"a": int8(1)}, Compound{}},
+		"nested":    Compound{"list": List{"a", "b"}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "root", c); err != nil {
+		t.Fatal(err)
+	}
+	name, got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "root" {
+		t.Errorf("Expected name root, got %q", name)
+	}
+	if !reflect.DeepEqual(c, got) {
+		t.Errorf("Expected %v, got %v", c, got)
+	}
+}
+
+func TestRead(t *testing.T) {
+	// the hello_world.nbt example from the specification
+	data := []byte{
+		0x0a, 0x00, 0x0b, 'h', 'e', 'l', 'l', 'o', ' ', 'w', 'o', 'r', 'l', 'd',
+		0x08, 0x00, 0x04, 'n', 'a', 'm', 'e', 0x00, 0x09, 'B', 'a', 'n', 'a', 'n', 'r', 'a', 'm', 'a',
+		0x00,
+	}
+	name, c, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "hello world" {
+		t.Errorf("Expected name %q, got %q", "hello world", name)
+	}
+	if s, _ := c.String("name"); s != "Bananrama" {
+		t.Errorf("Expected Bananrama, got %q", s)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := map[string][]byte{
+		"not a compound": {0x08, 0x00, 0x00, 0x00, 0x00},
+		"truncated":      {0x0a, 0x00, 0x00, 0x01, 0x00, 0x01, 'a'},
+		"unknown type":   {0x0a, 0x00, 0x00, 0x0d, 0x00, 0x01, 'a', 0x00},
+		"negative array": {0x0a, 0x00, 0x00, 0x07, 0x00, 0x01, 'a', 0xff, 0xff, 0xff, 0xff, 0x00},
+		// the lengths are valid, but the data is missing
+		"truncated array": {0x0a, 0x00, 0x00, 0x07, 0x00, 0x01, 'a', 0x00, 0xff, 0xff, 0xff},
+		"truncated list":  {0x0a, 0x00, 0x00, 0x09, 0x00, 0x01, 'a', 0x0a, 0x00, 0xff, 0xff, 0xff},
+	}
+	for name, data := range tests {
+		if _, _, err := Read(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCompound_Int(t *testing.T) {
+	c := Compound{"b": int8(-3), "l": int64(5), "s": "x"}
+	if v, ok := c.Int("b"); !ok || v != -3 {
+		t.Errorf("Expected -3, got %d", v)
+	}
+	if v, ok := c.Int("l"); !ok || v != 5 {
+		t.Errorf("Expected 5, got %d", v)
+	}
+	if _, ok := c.Int("s"); ok {
+		t.Error("Expected a string not to be an integer")
+	}
+	if _, ok := c.Int("missing"); ok {
+		t.Error("Expected a missing tag not to be an integer")
+	}
+}
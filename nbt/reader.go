@@ -0,0 +1,259 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// maxDepth is the maximum nesting depth of lists and compounds, the same as in vanilla.
+	maxDepth = 512
+	// maxArrayLength is the maximum number of elements in an array or list, which prevents corrupt data from causing
+	// huge allocations.
+	maxArrayLength = 1 << 24
+	// maxInitialCapacity is the maximum number of elements that are allocated for an array or list before its
+	// elements have been read. Larger arrays and lists grow while they are read, so a short header with a huge length
+	// cannot cause a huge allocation.
+	maxInitialCapacity = 512
+)
+
+var (
+	errNotCompound = errors.New("root tag is not a compound")
+	errTooDeep     = errors.New("tags are nested too deeply")
+)
+
+// Read reads an uncompressed root compound and returns its name and value.
+func Read(r io.Reader) (string, Compound, error) {
+	d := decoder{r: r}
+
+	t, err := d.readByte()
+	if err != nil {
+		return "", nil, err
+	}
+	if TagType(t) != TagCompound {
+		return "", nil, errNotCompound
+	}
+
+	name, err := d.readString()
+	if err != nil {
+		return "", nil, err
+	}
+	c, err := d.readCompound(0)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, c, nil
+}
+
+// decoder reads tags from an io.Reader.
+type decoder struct {
+	r   io.Reader
+	buf [8]byte
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	b := d.buf[:n]
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (d *decoder) readByte() (uint8, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) readUint16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *decoder) readUint32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (d *decoder) readUint64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *decoder) readString() (string, error) {
+	n, err := d.readUint16()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readLength reads the length of an array or list.
+func (d *decoder) readLength() (int, error) {
+	n, err := d.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if int32(n) < 0 || n > maxArrayLength {
+		return 0, fmt.Errorf("invalid length %d", int32(n))
+	}
+	return int(n), nil
+}
+
+// initialCapacity returns the capacity that is allocated for an array or list of length n, see maxInitialCapacity.
+func initialCapacity(n int) int {
+	if n > maxInitialCapacity {
+		return maxInitialCapacity
+	}
+	return n
+}
+
+func (d *decoder) readCompound(depth int) (Compound, error) {
+	if depth >= maxDepth {
+		return nil, errTooDeep
+	}
+
+	c := make(Compound)
+	for {
+		t, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if TagType(t) == TagEnd {
+			return c, nil
+		}
+
+		name, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.readPayload(TagType(t), depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		c[name] = v
+	}
+}
+
+func (d *decoder) readList(depth int) (List, error) {
+	if depth >= maxDepth {
+		return nil, errTooDeep
+	}
+
+	t, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	if TagType(t) == TagEnd && n > 0 {
+		return nil, errors.New("list of end tags")
+	}
+
+	l := make(List, 0, initialCapacity(n))
+	for i := 0; i < n; i++ {
+		v, err := d.readPayload(TagType(t), depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		l = append(l, v)
+	}
+	return l, nil
+}
+
+// readPayload reads the value of a tag of the specified type.
+func (d *decoder) readPayload(t TagType, depth int) (interface{}, error) {
+	switch t {
+	case TagByte:
+		v, err := d.readByte()
+		return int8(v), err
+	case TagShort:
+		v, err := d.readUint16()
+		return int16(v), err
+	case TagInt:
+		v, err := d.readUint32()
+		return int32(v), err
+	case TagLong:
+		v, err := d.readUint64()
+		return int64(v), err
+	case TagFloat:
+		v, err := d.readUint32()
+		return math.Float32frombits(v), err
+	case TagDouble:
+		v, err := d.readUint64()
+		return math.Float64frombits(v), err
+	case TagByteArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, initialCapacity(n)))
+		if _, err := io.CopyN(buf, d.r, int64(n)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case TagString:
+		return d.readString()
+	case TagList:
+		return d.readList(depth)
+	case TagCompound:
+		return d.readCompound(depth)
+	case TagIntArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int32, 0, initialCapacity(n))
+		for i := 0; i < n; i++ {
+			v, err := d.readUint32()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, int32(v))
+		}
+		return a, nil
+	case TagLongArray:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int64, 0, initialCapacity(n))
+		for i := 0; i < n; i++ {
+			v, err := d.readUint64()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, int64(v))
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown tag type %d", t)
+	}
+}
@@ -0,0 +1,90 @@
+package nbt
+
+// TagType identifies the type of a tag.
+type TagType uint8
+
+const (
+	TagEnd TagType = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+// Compound is a set of named tags.
+type Compound map[string]interface{}
+
+// List is a list of unnamed tags, which all have the same type.
+type List []interface{}
+
+// Compound returns the compound with the specified name. It returns false if the tag does not exist or has a different
+// type.
+func (c Compound) Compound(name string) (Compound, bool) {
+	v, ok := c[name].(Compound)
+	return v, ok
+}
+
+// List returns the list with the specified name. It returns false if the tag does not exist or has a different type.
+func (c Compound) List(name string) (List, bool) {
+	v, ok := c[name].(List)
+	return v, ok
+}
+
+// ByteArray returns the byte array with the specified name. It returns false if the tag does not exist or has a
+// different type.
+func (c Compound) ByteArray(name string) ([]byte, bool) {
+	v, ok := c[name].([]byte)
+	return v, ok
+}
+
+// IntArray returns the int array with the specified name. It returns false if the tag does not exist or has a
+// different type.
+func (c Compound) IntArray(name string) ([]int32, bool) {
+	v, ok := c[name].([]int32)
+	return v, ok
+}
+
+// String returns the string with the specified name. It returns false if the tag does not exist or has a different
+// type.
+func (c Compound) String(name string) (string, bool) {
+	v, ok := c[name].(string)
+	return v, ok
+}
+
+// Int returns the integer with the specified name, which may be a byte, short, int or long tag. It returns false if
+// the tag does not exist or is not an integer.
+func (c Compound) Int(name string) (int64, bool) {
+	switch v := c[name].(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// Float returns the floating point number with the specified name, which may be a float or double tag. It returns
+// false if the tag does not exist or is not a floating point number.
+func (c Compound) Float(name string) (float64, bool) {
+	switch v := c[name].(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
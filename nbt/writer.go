@@ -0,0 +1,186 @@
+package nbt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Write writes c as an uncompressed root compound with the specified name. The values must use the types that are
+// described in the package documentation. Tags within a compound are written in alphabetical order, so the output is
+// deterministic.
+func Write(w io.Writer, name string, c Compound) error {
+	e := encoder{w: bufio.NewWriter(w)}
+	e.writeByte(uint8(TagCompound))
+	e.writeString(name)
+	e.writeCompound(c)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// tagTypeOf returns the TagType of a value, or false if the value does not have a valid type.
+func tagTypeOf(v interface{}) (TagType, bool) {
+	switch v.(type) {
+	case int8:
+		return TagByte, true
+	case int16:
+		return TagShort, true
+	case int32:
+		return TagInt, true
+	case int64:
+		return TagLong, true
+	case float32:
+		return TagFloat, true
+	case float64:
+		return TagDouble, true
+	case []byte:
+		return TagByteArray, true
+	case string:
+		return TagString, true
+	case List:
+		return TagList, true
+	case Compound:
+		return TagCompound, true
+	case []int32:
+		return TagIntArray, true
+	case []int64:
+		return TagLongArray, true
+	default:
+		return TagEnd, false
+	}
+}
+
+// encoder writes tags to a bufio.Writer. The first error is stored in err, after which all writes are ignored.
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) writeByte(v uint8) {
+	if e.err == nil {
+		e.err = e.w.WriteByte(v)
+	}
+}
+
+func (e *encoder) writeUint16(v uint16) {
+	binary.BigEndian.PutUint16(e.buf[:2], v)
+	e.write(e.buf[:2])
+}
+
+func (e *encoder) writeUint32(v uint32) {
+	binary.BigEndian.PutUint32(e.buf[:4], v)
+	e.write(e.buf[:4])
+}
+
+func (e *encoder) writeUint64(v uint64) {
+	binary.BigEndian.PutUint64(e.buf[:8], v)
+	e.write(e.buf[:8])
+}
+
+func (e *encoder) writeString(s string) {
+	if len(s) > math.MaxUint16 {
+		e.fail(fmt.Errorf("string of %d bytes is too long", len(s)))
+		return
+	}
+	e.writeUint16(uint16(len(s)))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) writeCompound(c Compound) {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := c[name]
+		t, ok := tagTypeOf(v)
+		if !ok {
+			e.fail(fmt.Errorf("%s: unsupported type %T", name, v))
+			return
+		}
+		e.writeByte(uint8(t))
+		e.writeString(name)
+		e.writePayload(v)
+	}
+	e.writeByte(uint8(TagEnd))
+}
+
+func (e *encoder) writeList(l List) {
+	t := TagEnd
+	if len(l) > 0 {
+		var ok bool
+		if t, ok = tagTypeOf(l[0]); !ok {
+			e.fail(fmt.Errorf("unsupported list element type %T", l[0]))
+			return
+		}
+	}
+
+	e.writeByte(uint8(t))
+	e.writeUint32(uint32(len(l)))
+	for i, v := range l {
+		if vt, _ := tagTypeOf(v); vt != t {
+			e.fail(fmt.Errorf("list element %d has type %T, expected the same type as the first element", i, v))
+			return
+		}
+		e.writePayload(v)
+	}
+}
+
+// writePayload writes the value of a tag. The type of v must have been checked using tagTypeOf.
+func (e *encoder) writePayload(v interface{}) {
+	switch v := v.(type) {
+	case int8:
+		e.writeByte(uint8(v))
+	case int16:
+		e.writeUint16(uint16(v))
+	case int32:
+		e.writeUint32(uint32(v))
+	case int64:
+		e.writeUint64(uint64(v))
+	case float32:
+		e.writeUint32(math.Float32bits(v))
+	case float64:
+		e.writeUint64(math.Float64bits(v))
+	case []byte:
+		e.writeUint32(uint32(len(v)))
+		e.write(v)
+	case string:
+		e.writeString(v)
+	case List:
+		e.writeList(v)
+	case Compound:
+		e.writeCompound(v)
+	case []int32:
+		e.writeUint32(uint32(len(v)))
+		for _, x := range v {
+			e.writeUint32(uint32(x))
+		}
+	case []int64:
+		e.writeUint32(uint32(len(v)))
+		for _, x := range v {
+			e.writeUint64(uint64(x))
+		}
+	}
+}
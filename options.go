@@ -0,0 +1,114 @@
+package mable
+
+import (
+	"time"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/login"
+	"github.com/gitfyu/mable/status"
+)
+
+// Option configures a Server, see New.
+type Option func(s *Server)
+
+// WithAddr sets the address that the Server listens on, such as ":25565". The default is ":25565".
+func WithAddr(addr string) Option {
+	return func(s *Server) {
+		s.srvCfg.Addr = addr
+	}
+}
+
+// WithWorlds sets the worlds of the Server. The first World is the default World, in which players spawn. By default,
+// the Server has a single empty World.
+func WithWorlds(worlds ...*game.World) Option {
+	return func(s *Server) {
+		s.worlds = worlds
+	}
+}
+
+// WithLoginHandler sets a login.Handler that decides which players may join the Server.
+func WithLoginHandler(h login.Handler) Option {
+	return func(s *Server) {
+		s.srvCfg.LoginHandler = h
+	}
+}
+
+// WithStatusHandler sets a status.Handler that customizes the information displayed in the server list.
+func WithStatusHandler(h status.Handler) Option {
+	return func(s *Server) {
+		s.srvCfg.StatusHandler = h
+	}
+}
+
+// WithMOTD sets the description displayed in the server list.
+func WithMOTD(motd *chat.Msg) Option {
+	return func(s *Server) {
+		s.srvCfg.MOTD = motd
+	}
+}
+
+// WithMaxPlayers sets the maximum number of players that can be online at the same time, which is also displayed in
+// the server list. The default is 20.
+func WithMaxPlayers(n int) Option {
+	return func(s *Server) {
+		s.srvCfg.MaxPlayers = n
+	}
+}
+
+// WithOnlineMode enables authentication of players using the session server.
+func WithOnlineMode(enabled bool) Option {
+	return func(s *Server) {
+		s.srvCfg.OnlineMode = enabled
+	}
+}
+
+// WithCompressionThreshold sets the minimum size in bytes of a packet before it is compressed. A negative value
+// disables compression. The default is 256.
+func WithCompressionThreshold(threshold int) Option {
+	return func(s *Server) {
+		s.srvCfg.CompressionThreshold = threshold
+	}
+}
+
+// WithLogLevel sets the minimum level of the messages that are logged, such as "debug". The default is "info".
+func WithLogLevel(level string) Option {
+	return func(s *Server) {
+		s.srvCfg.LogLevel = level
+	}
+}
+
+// WithTickInterval sets how often the game state is updated. The default is game.DefaultTickInterval.
+func WithTickInterval(d time.Duration) Option {
+	return func(s *Server) {
+		s.gameCfg.TickInterval = d
+	}
+}
+
+// WithChatFormatter sets the game.ChatFormatter that creates the messages that are broadcast when players chat.
+func WithChatFormatter(f game.ChatFormatter) Option {
+	return func(s *Server) {
+		s.gameCfg.ChatFormatter = f
+	}
+}
+
+// OnJoin registers a game.JoinHandler. This option may be used multiple times.
+func OnJoin(h game.JoinHandler) Option {
+	return func(s *Server) {
+		s.joinHandlers = append(s.joinHandlers, h)
+	}
+}
+
+// OnQuit registers a game.QuitHandler. This option may be used multiple times.
+func OnQuit(h game.QuitHandler) Option {
+	return func(s *Server) {
+		s.quitHandlers = append(s.quitHandlers, h)
+	}
+}
+
+// OnChat registers a game.ChatHandler. This option may be used multiple times.
+func OnChat(h game.ChatHandler) Option {
+	return func(s *Server) {
+		s.chatHandlers = append(s.chatHandlers, h)
+	}
+}
@@ -0,0 +1,19 @@
+/*
+Package schematic loads schematics in the MCEdit format, which is also used by WorldEdit before 1.13, and pastes them
+into a game.World.
+
+A schematic is pasted with its minimum corner at the specified position, after it has been mirrored and rotated:
+	s, err := schematic.Load("arena.schematic")
+	if err != nil {
+		// ...
+	}
+	g.Schedule(func() {
+		s.Paste(w, -20, 64, -20, schematic.PasteOptions{
+			Rotation: schematic.Rotate90,
+		})
+	})
+Players that have loaded the affected chunks receive the changed blocks immediately. The metadata of common
+directional blocks, such as stairs, logs, torches, ladders, signs, chests and furnaces, is transformed along with their
+positions. Other blocks keep their metadata.
+*/
+package schematic
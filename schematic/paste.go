@@ -0,0 +1,50 @@
+package schematic
+
+import "github.com/gitfyu/mable/game"
+
+// PasteOptions configures how a Schematic is pasted.
+type PasteOptions struct {
+	Rotation Rotation
+	Mirror   Mirror
+	// IgnoreAir skips the air blocks of the schematic, so the existing blocks at those positions are kept.
+	IgnoreAir bool
+}
+
+// Size returns the width and length of the schematic after it has been rotated.
+func (s *Schematic) Size(r Rotation) (width, length int) {
+	if r&1 != 0 {
+		return s.Length, s.Width
+	}
+	return s.Width, s.Length
+}
+
+// Paste places the blocks and block entities of the schematic in a World, using game.World.SetBlocks. The minimum
+// corner of the schematic is placed at the specified world coordinates, after it has been mirrored and rotated. Blocks
+// of which the Y coordinate is not within the range [0,255] are skipped. This function may only be called from the
+// goroutine that called Game.Run, if the World is registered in a Game.
+func (s *Schematic) Paste(w *game.World, x, y, z int32, opts PasteOptions) {
+	t := transform{
+		width:    s.Width,
+		length:   s.Length,
+		rotation: opts.Rotation & 3,
+		mirror:   opts.Mirror,
+	}
+
+	changes := make([]game.BlockChange, 0, len(s.Blocks))
+	for i, d := range s.Blocks {
+		if opts.IgnoreAir && d.Type() == 0 {
+			continue
+		}
+
+		bx, bz := t.pos(i%s.Width, i/s.Width%s.Length)
+		by := i / (s.Width * s.Length)
+		changes = append(changes, game.BlockChange{
+			X:           x + int32(bx),
+			Y:           y + int32(by),
+			Z:           z + int32(bz),
+			Data:        t.data(d),
+			BlockEntity: s.BlockEntities[i],
+		})
+	}
+	w.SetBlocks(changes)
+}
@@ -0,0 +1,148 @@
+package schematic
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/nbt"
+)
+
+// Schematic is a box of blocks, of which the dimensions are Width along the X axis, Height along the Y axis and Length
+// along the Z axis.
+type Schematic struct {
+	Width, Height, Length int
+	// Blocks contains the blocks in YZX order, see Index.
+	Blocks []block.Data
+	// BlockEntities contains the block entities by their index in Blocks. Their data does not contain the coordinates.
+	BlockEntities map[int]nbt.Compound
+}
+
+// Load loads a schematic from a file.
+func Load(path string) (*Schematic, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Read reads a schematic from r, which must still be compressed using gzip.
+func Read(r io.Reader) (*Schematic, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	_, root, err := nbt.Read(zr)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(root)
+}
+
+// Decode converts the root compound of a schematic to a Schematic.
+func Decode(root nbt.Compound) (*Schematic, error) {
+	if m, ok := root.String("Materials"); ok && m != "Alpha" {
+		return nil, fmt.Errorf("unsupported materials %q", m)
+	}
+
+	width, okW := root.Int("Width")
+	height, okH := root.Int("Height")
+	length, okL := root.Int("Length")
+	if !okW || !okH || !okL || width < 0 || height < 0 || length < 0 {
+		return nil, errors.New("invalid dimensions")
+	}
+	s := &Schematic{
+		Width:         int(width),
+		Height:        int(height),
+		Length:        int(length),
+		BlockEntities: make(map[int]nbt.Compound),
+	}
+	volume := s.Width * s.Height * s.Length
+
+	ids, ok := root.ByteArray("Blocks")
+	if !ok || len(ids) != volume {
+		return nil, errors.New("invalid Blocks")
+	}
+	data, ok := root.ByteArray("Data")
+	if !ok || len(data) != volume {
+		return nil, errors.New("invalid Data")
+	}
+	// like in Anvil, the upper bits of the IDs are stored as nibbles
+	add, ok := root.ByteArray("AddBlocks")
+	if ok && len(add) != (volume+1)/2 {
+		return nil, errors.New("invalid AddBlocks")
+	}
+
+	s.Blocks = make([]block.Data, volume)
+	for i := range s.Blocks {
+		id := block.ID(ids[i])
+		if add != nil {
+			if i&1 == 0 {
+				id |= block.ID(add[i>>1]&15) << 8
+			} else {
+				id |= block.ID(add[i>>1]>>4) << 8
+			}
+		}
+		s.Blocks[i] = id.ToDataWithMetadata(data[i])
+	}
+
+	entities, _ := root.List("TileEntities")
+	for i, v := range entities {
+		e, ok := v.(nbt.Compound)
+		if !ok {
+			return nil, fmt.Errorf("block entity %d is not a compound", i)
+		}
+		if err := s.decodeBlockEntity(e); err != nil {
+			return nil, fmt.Errorf("block entity %d: %w", i, err)
+		}
+	}
+	return s, nil
+}
+
+// decodeBlockEntity adds a block entity to s, without its coordinates.
+func (s *Schematic) decodeBlockEntity(e nbt.Compound) error {
+	x, okX := e.Int("x")
+	y, okY := e.Int("y")
+	z, okZ := e.Int("z")
+	if !okX || !okY || !okZ || !s.contains(int(x), int(y), int(z)) {
+		return errors.New("invalid position")
+	}
+
+	data := make(nbt.Compound, len(e))
+	for k, v := range e {
+		if k != "x" && k != "y" && k != "z" {
+			data[k] = v
+		}
+	}
+	s.BlockEntities[s.Index(int(x), int(y), int(z))] = data
+	return nil
+}
+
+// Index returns the index in Blocks of the block at the specified position, relative to the schematic.
+func (s *Schematic) Index(x, y, z int) int {
+	return (y*s.Length+z)*s.Width + x
+}
+
+// Block returns the block at the specified position, relative to the schematic. Panics if the position is outside the
+// schematic.
+func (s *Schematic) Block(x, y, z int) block.Data {
+	if !s.contains(x, y, z) {
+		panic("position outside schematic")
+	}
+	return s.Blocks[s.Index(x, y, z)]
+}
+
+// contains returns whether the specified position is within the schematic.
+func (s *Schematic) contains(x, y, z int) bool {
+	return x >= 0 && x < s.Width && y >= 0 && y < s.Height && z >= 0 && z < s.Length
+}
@@ -0,0 +1,146 @@
+package schematic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+const stoneStairs block.ID = 67
+
+// testSchematic returns a 2x1x3 schematic, containing stone at 0,0,0, stairs facing east at 1,0,0, a chest with a
+// block entity at 0,0,2 and block 256 at 1,0,2.
+func testSchematic() nbt.Compound {
+	return nbt.Compound{
+		"Width":     int16(2),
+		"Height":    int16(1),
+		"Length":    int16(3),
+		"Materials": "Alpha",
+		"Blocks":    []byte{1, byte(stoneStairs), 0, 0, 54, 0},
+		"Data":      []byte{0, 0, 0, 0, 2, 0},
+		"AddBlocks": []byte{0, 0, 1 << 4},
+		"TileEntities": nbt.List{
+			nbt.Compound{"id": "Chest", "x": int32(0), "y": int32(0), "z": int32(2)},
+		},
+	}
+}
+
+func TestDecode(t *testing.T) {
+	s, err := Decode(testSchematic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Width != 2 || s.Height != 1 || s.Length != 3 {
+		t.Errorf("Expected dimensions 2x1x3, got %dx%dx%d", s.Width, s.Height, s.Length)
+	}
+	if b := s.Block(1, 0, 0); b != stoneStairs.ToData() {
+		t.Errorf("Expected stairs at 1,0,0, got %v", b)
+	}
+	if b := s.Block(1, 0, 2); b != block.ID(256).ToData() {
+		t.Errorf("Expected block 256 from AddBlocks, got %v", b)
+	}
+	e := s.BlockEntities[s.Index(0, 0, 2)]
+	if e == nil || e["id"] != "Chest" {
+		t.Fatalf("Expected the chest block entity, got %v", e)
+	}
+	if _, ok := e["x"]; ok {
+		t.Error("Expected the coordinates to be removed from the block entity")
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	c := testSchematic()
+	c["Blocks"] = []byte{1}
+	if _, err := Decode(c); err == nil {
+		t.Error("Expected error for short Blocks")
+	}
+
+	c = testSchematic()
+	c["TileEntities"] = nbt.List{nbt.Compound{"x": int32(5), "y": int32(0), "z": int32(0)}}
+	if _, err := Decode(c); err == nil {
+		t.Error("Expected error for block entity outside the schematic")
+	}
+}
+
+func TestRead(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := nbt.Write(zw, "Schematic", testSchematic()); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+
+	if _, err := Read(&buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchematic_Paste(t *testing.T) {
+	s, err := Decode(testSchematic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts PasteOptions
+		// stone, stairs and chest are the expected positions relative to the paste position
+		stone, stairs, chest [2]int32
+		stairsMeta           uint8
+		chestMeta            uint8
+	}{
+		{"none", PasteOptions{}, [2]int32{0, 0}, [2]int32{1, 0}, [2]int32{0, 2}, 0, 2},
+		// after rotating clockwise, the schematic is 3 wide and 2 long
+		{"rotate 90", PasteOptions{Rotation: Rotate90}, [2]int32{2, 0}, [2]int32{2, 1}, [2]int32{0, 0}, 2, 5},
+		{"rotate 180", PasteOptions{Rotation: Rotate180}, [2]int32{1, 2}, [2]int32{0, 2}, [2]int32{1, 0}, 1, 3},
+		{"rotate 270", PasteOptions{Rotation: Rotate270}, [2]int32{0, 1}, [2]int32{0, 0}, [2]int32{2, 1}, 3, 4},
+		{"mirror x", PasteOptions{Mirror: MirrorX}, [2]int32{1, 0}, [2]int32{0, 0}, [2]int32{1, 2}, 1, 2},
+		{"mirror z", PasteOptions{Mirror: MirrorZ}, [2]int32{0, 2}, [2]int32{1, 2}, [2]int32{0, 0}, 0, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := game.NewWorld(nil)
+			s.Paste(w, 16, 10, -16, test.opts)
+
+			check := func(pos [2]int32, want block.Data) {
+				x, z := 16+pos[0], -16+pos[1]
+				c := w.GetChunk(game.ChunkPos{X: x >> 4, Z: z >> 4})
+				if c == nil {
+					t.Fatalf("Expected chunk at %d,%d", x>>4, z>>4)
+				}
+				if b := c.Block(uint8(x&15), 10, uint8(z&15)); b != want {
+					t.Errorf("Block at %d,10,%d: expected %v, got %v", x, z, want, b)
+				}
+			}
+			check(test.stone, block.Stone.ToData())
+			check(test.stairs, stoneStairs.ToDataWithMetadata(test.stairsMeta))
+			check(test.chest, block.ID(54).ToDataWithMetadata(test.chestMeta))
+
+			x, z := 16+test.chest[0], -16+test.chest[1]
+			if w.GetChunk(game.ChunkPos{X: x >> 4, Z: z >> 4}).BlockEntity(uint8(x&15), 10, uint8(z&15)) == nil {
+				t.Error("Expected the block entity to move with the chest")
+			}
+		})
+	}
+}
+
+func TestTransform_data(t *testing.T) {
+	rot90 := transform{rotation: Rotate90}
+	if d := rot90.data(standingSign.ToDataWithMetadata(0)); d.Metadata() != 4 {
+		t.Errorf("Expected a sign facing south to face west, got %d", d.Metadata())
+	}
+	if d := rot90.data(log.ToDataWithMetadata(4 | 1)); d.Metadata() != 8|1 {
+		t.Errorf("Expected a log along the X axis to be along the Z axis, got %d", d.Metadata())
+	}
+	if d := rot90.data(block.ID(50).ToDataWithMetadata(5)); d.Metadata() != 5 {
+		t.Errorf("Expected a torch on the floor to be unchanged, got %d", d.Metadata())
+	}
+	if d := (transform{mirror: MirrorX}).data(standingSign.ToDataWithMetadata(4)); d.Metadata() != 12 {
+		t.Errorf("Expected a sign facing west to face east, got %d", d.Metadata())
+	}
+}
@@ -0,0 +1,150 @@
+package schematic
+
+import "github.com/gitfyu/mable/block"
+
+// Rotation is a clockwise rotation around the Y axis, as seen from above.
+type Rotation uint8
+
+const (
+	Rotate0 Rotation = iota
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+// Mirror flips a schematic along an axis. It is applied before the Rotation.
+type Mirror uint8
+
+const (
+	MirrorNone Mirror = iota
+	// MirrorX reverses the X axis, which swaps east and west.
+	MirrorX
+	// MirrorZ reverses the Z axis, which swaps north and south.
+	MirrorZ
+)
+
+// facing is a horizontal direction. The values are in clockwise order, so rotating a facing adds the Rotation.
+type facing uint8
+
+const (
+	north facing = iota
+	east
+	south
+	west
+)
+
+// directional describes how a horizontal facing is stored in the metadata of a block.
+type directional struct {
+	// mask contains the bits of the metadata that store the facing.
+	mask uint8
+	// values contains the metadata of each facing, indexed by facing.
+	values [4]uint8
+}
+
+var (
+	stairs     = directional{mask: 3, values: [4]uint8{3, 0, 2, 1}}
+	facing2to5 = directional{mask: 7, values: [4]uint8{2, 5, 3, 4}}
+	torch      = directional{mask: 7, values: [4]uint8{4, 1, 3, 2}}
+	pumpkin    = directional{mask: 3, values: [4]uint8{2, 3, 0, 1}}
+)
+
+// Blocks of which the metadata is transformed in a different way than using directionalBlocks.
+const (
+	standingSign block.ID = 63
+	log          block.ID = 17
+	log2         block.ID = 162
+)
+
+// directionalBlocks contains the blocks of which the metadata stores a horizontal facing.
+var directionalBlocks = map[block.ID]directional{
+	// stairs
+	53: stairs, 67: stairs, 108: stairs, 109: stairs, 114: stairs, 128: stairs, 134: stairs, 135: stairs,
+	136: stairs, 156: stairs, 163: stairs, 164: stairs, 180: stairs,
+	// dispenser, chest, furnace, lit furnace, ladder, wall sign, ender chest, trapped chest, hopper and dropper
+	23: facing2to5, 54: facing2to5, 61: facing2to5, 62: facing2to5, 65: facing2to5, 68: facing2to5,
+	130: facing2to5, 146: facing2to5, 154: facing2to5, 158: facing2to5,
+	// torch, redstone torch and lit redstone torch
+	50: torch, 75: torch, 76: torch,
+	// pumpkin and jack o'lantern
+	86: pumpkin, 91: pumpkin,
+}
+
+// transform mirrors and rotates the positions and blocks of a schematic.
+type transform struct {
+	width, length int
+	rotation      Rotation
+	mirror        Mirror
+}
+
+// pos returns the transformed horizontal position of a block, relative to the minimum corner of the transformed
+// schematic.
+func (t transform) pos(x, z int) (int, int) {
+	switch t.mirror {
+	case MirrorX:
+		x = t.width - 1 - x
+	case MirrorZ:
+		z = t.length - 1 - z
+	}
+
+	switch t.rotation {
+	case Rotate90:
+		return t.length - 1 - z, x
+	case Rotate180:
+		return t.width - 1 - x, t.length - 1 - z
+	case Rotate270:
+		return z, t.width - 1 - x
+	default:
+		return x, z
+	}
+}
+
+// facing returns the transformed facing.
+func (t transform) facing(f facing) facing {
+	switch {
+	case t.mirror == MirrorX && (f == east || f == west):
+		f = west + east - f
+	case t.mirror == MirrorZ && (f == north || f == south):
+		f = south - f
+	}
+	return (f + facing(t.rotation)) % 4
+}
+
+// data returns the transformed block, which only differs from d if the metadata of the block stores its direction.
+func (t transform) data(d block.Data) block.Data {
+	if t.rotation == Rotate0 && t.mirror == MirrorNone {
+		return d
+	}
+
+	id, meta := d.Type(), d.Metadata()
+	switch id {
+	case standingSign:
+		// the rotation is stored in 16 steps, starting from south
+		switch t.mirror {
+		case MirrorX:
+			meta = (16 - meta) & 15
+		case MirrorZ:
+			meta = (8 - meta) & 15
+		}
+		meta = (meta + uint8(t.rotation)*4) & 15
+	case log, log2:
+		// the upper 2 bits store the axis, in which 4 is the X axis and 8 is the Z axis
+		if t.rotation&1 != 0 && (meta&12 == 4 || meta&12 == 8) {
+			meta ^= 12
+		}
+	default:
+		if dir, ok := directionalBlocks[id]; ok {
+			meta = dir.transform(meta, t)
+		}
+	}
+	return id.ToDataWithMetadata(meta)
+}
+
+// transform returns the metadata of a block after t has been applied to its facing.
+func (d directional) transform(meta uint8, t transform) uint8 {
+	for f, v := range d.values {
+		if meta&d.mask == v {
+			return meta&^d.mask | d.values[t.facing(facing(f))]
+		}
+	}
+	return meta
+}
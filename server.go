@@ -0,0 +1,186 @@
+package mable
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/command"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/server"
+	"github.com/gitfyu/mable/log"
+)
+
+// shutdownPollInterval is how often Shutdown checks whether all players have disconnected.
+const shutdownPollInterval = 10 * time.Millisecond
+
+var (
+	errAlreadyStarted = errors.New("server already started")
+	errNotStarted     = errors.New("server not started")
+
+	msgShutdown = &chat.Msg{Text: "Server closed"}
+)
+
+// Server is an embeddable Minecraft server. Create one using New.
+type Server struct {
+	srvCfg  server.Config
+	gameCfg game.Config
+	worlds  []*game.World
+
+	joinHandlers []game.JoinHandler
+	quitHandlers []game.QuitHandler
+	chatHandlers []game.ChatHandler
+
+	game     *game.Game
+	commands *command.Dispatcher
+	logger   log.Logger
+
+	// mu guards srv and shutdown.
+	mu       sync.Mutex
+	srv      *server.Server
+	shutdown bool
+	// done is closed when Game.Run returns.
+	done chan struct{}
+}
+
+// New creates a Server, configured using the specified options. The Server does not accept connections until Start
+// is called.
+func New(opts ...Option) *Server {
+	s := &Server{
+		srvCfg: server.Config{
+			Addr:                 ":25565",
+			MaxPacketSize:        1 << 16,
+			Timeout:              20,
+			LogLevel:             "info",
+			SessionServer:        "https://sessionserver.mojang.com",
+			CompressionThreshold: 256,
+			MaxPlayers:           20,
+			MOTD:                 &chat.Msg{Text: "A Mable server"},
+		},
+		gameCfg: game.Config{
+			MaxJobs:      100,
+			TickInterval: game.DefaultTickInterval,
+		},
+		logger: log.Logger{
+			Name: "MABLE",
+		},
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.worlds) == 0 {
+		s.worlds = []*game.World{game.NewWorld(nil)}
+	}
+
+	s.game = game.NewGame(s.worlds, s.gameCfg)
+	for _, h := range s.joinHandlers {
+		s.game.OnJoin(h)
+	}
+	for _, h := range s.quitHandlers {
+		s.game.OnQuit(h)
+	}
+	for _, h := range s.chatHandlers {
+		s.game.OnChat(h)
+	}
+
+	s.commands = command.NewDispatcher(s.game)
+	s.game.SetCommandHandler(s.commands)
+	return s
+}
+
+// Game returns the Game that is managed by the Server. Most of its functions may only be called from the goroutine
+// that runs it, use game.Game.Schedule to run code on that goroutine once the Server has been started.
+func (s *Server) Game() *game.Game {
+	return s.game
+}
+
+// Commands returns the Dispatcher that executes the commands sent by players. Its functions may only be called before
+// the Server is started, or from the goroutine that runs the Game.
+func (s *Server) Commands() *command.Dispatcher {
+	return s.commands
+}
+
+// Start starts listening for connections and starts running the Game, both on separate goroutines. It returns an
+// error if the Server could not be started, for example because the address is already in use. A Server can only be
+// started once.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.srv != nil || s.shutdown {
+		return errAlreadyStarted
+	}
+
+	srv, err := server.NewServer(s.srvCfg, s.game)
+	if err != nil {
+		return err
+	}
+	s.srv = srv
+	s.logger.Level = srv.LogLevel()
+
+	go func() {
+		defer close(s.done)
+		if err := s.game.Run(); err != nil {
+			s.logger.Error("Game execution failed").Err(err).Log()
+		}
+	}()
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, net.ErrClosed) {
+			s.logger.Error("Server execution failed").Err(err).Log()
+		}
+	}()
+
+	s.logger.Info("Server started").Stringer("addr", srv.Addr()).Log()
+	return nil
+}
+
+// Addr returns the address that the Server is listening on, or nil if it has not been started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Addr()
+}
+
+// Shutdown stops accepting new connections, kicks all players and stops the Game once they have disconnected. If ctx
+// expires before all players have disconnected, the Game is stopped anyway and the error of ctx is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.srv
+	if srv == nil || s.shutdown {
+		s.mu.Unlock()
+		return errNotStarted
+	}
+	s.shutdown = true
+	s.mu.Unlock()
+
+	s.logger.Info("Shutting down").Log()
+	err := srv.Close()
+
+	for _, p := range s.game.Players() {
+		p.Kick(msgShutdown)
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for s.game.PlayerCount() > 0 && ctx.Err() == nil {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+		}
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	s.game.Close()
+	<-s.done
+	return err
+}
@@ -0,0 +1,165 @@
+package mable
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/protocol"
+	"github.com/gitfyu/mable/login"
+)
+
+func startTestServer(t *testing.T, opts ...Option) *Server {
+	opts = append([]Option{
+		WithAddr("127.0.0.1:0"),
+		WithCompressionThreshold(-1),
+		WithLogLevel("error"),
+	}, opts...)
+	s := New(opts...)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// writeTestPacket writes an uncompressed packet.
+func writeTestPacket(t *testing.T, w io.Writer, id int32, body []byte) {
+	var data bytes.Buffer
+	protocol.WriteVarInt(&data, id)
+	data.Write(body)
+
+	var frame bytes.Buffer
+	protocol.WriteVarInt(&frame, int32(data.Len()))
+	frame.Write(data.Bytes())
+	if _, err := w.Write(frame.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestPacket reads an uncompressed packet and returns its ID and body.
+func readTestPacket(t *testing.T, r protocol.Reader) (int32, []byte) {
+	size, err := protocol.ReadVarInt(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bytes.NewReader(data)
+	id, err := protocol.ReadVarInt(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, data[len(data)-br.Len():]
+}
+
+// dialLogin connects to the server and sends the packets to log in as the specified player using 1.8.
+func dialLogin(t *testing.T, s *Server, name string) (net.Conn, protocol.Reader) {
+	c, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var b bytes.Buffer
+	protocol.WriteVarInt(&b, int32(protocol.Version1_8))
+	protocol.WriteString(&b, "localhost")
+	protocol.WriteUint16(&b, 25565)
+	protocol.WriteVarInt(&b, int32(protocol.StateLogin))
+	writeTestPacket(t, c, 0x00, b.Bytes())
+
+	b.Reset()
+	protocol.WriteString(&b, name)
+	writeTestPacket(t, c, 0x00, b.Bytes())
+	return c, bufio.NewReader(c)
+}
+
+func TestServer_StartShutdown(t *testing.T) {
+	s := startTestServer(t)
+	if s.Addr() == nil {
+		t.Fatal("Expected an address")
+	}
+	if err := s.Start(); err == nil {
+		t.Error("Expected an error when starting twice")
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.Dial("tcp", s.Addr().String()); err == nil {
+		t.Error("Expected the listener to be closed")
+	}
+	if err := s.Shutdown(context.Background()); err == nil {
+		t.Error("Expected an error when shutting down twice")
+	}
+}
+
+func TestServer_LoginHandler(t *testing.T) {
+	s := startTestServer(t, WithLoginHandler(func(req *login.Request) *chat.Msg {
+		if req.Name == "denied" {
+			return &chat.Msg{Text: "Not whitelisted"}
+		}
+		return nil
+	}))
+	defer s.Shutdown(context.Background())
+
+	c, r := dialLogin(t, s, "denied")
+	defer c.Close()
+
+	id, body := readTestPacket(t, r)
+	if id != 0x00 {
+		t.Fatalf("Expected a disconnect, got packet 0x%x", id)
+	}
+	if !strings.Contains(string(body), "Not whitelisted") {
+		t.Errorf("Expected the reason in %q", body)
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	joined := make(chan string, 1)
+	quit := make(chan string, 1)
+	s := startTestServer(t,
+		OnJoin(func(p *game.Player) {
+			joined <- p.Name()
+		}),
+		OnQuit(func(p *game.Player) {
+			quit <- p.Name()
+		}),
+	)
+
+	c, _ := dialLogin(t, s, "tester")
+	defer c.Close()
+
+	select {
+	case name := <-joined:
+		if name != "tester" {
+			t.Errorf("Expected tester to join, got %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the join event")
+	}
+
+	// shutting down kicks the player, which must trigger the quit event before the game stops
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case name := <-quit:
+		if name != "tester" {
+			t.Errorf("Expected tester to quit, got %s", name)
+		}
+	default:
+		t.Error("Expected a quit event")
+	}
+}
@@ -0,0 +1,2 @@
+// Package status contains the types that are used to build the information displayed in a client's server list.
+package status
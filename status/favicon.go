@@ -0,0 +1,32 @@
+package status
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"os"
+)
+
+// FaviconSize is the required width and height of a favicon, in pixels.
+const FaviconSize = 64
+
+// LoadFavicon loads a PNG image from a file and returns it as a data URI, which can be used for Response.Favicon. The
+// image must be FaviconSize by FaviconSize pixels.
+func LoadFavicon(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := png.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return "", fmt.Errorf("decoding favicon: %w", err)
+	}
+	if cfg.Width != FaviconSize || cfg.Height != FaviconSize {
+		return "", fmt.Errorf("favicon must be %dx%d pixels, got %dx%d", FaviconSize, FaviconSize, cfg.Width,
+			cfg.Height)
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,40 @@
+package status
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestImage(t *testing.T, size int) string {
+	path := filepath.Join(t.TempDir(), "favicon.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFavicon(t *testing.T) {
+	favicon, err := LoadFavicon(writeTestImage(t, FaviconSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(favicon, "data:image/png;base64,") {
+		t.Errorf("Unexpected favicon %s", favicon)
+	}
+}
+
+func TestLoadFavicon_WrongSize(t *testing.T) {
+	if _, err := LoadFavicon(writeTestImage(t, FaviconSize*2)); err == nil {
+		t.Error("Expected error")
+	}
+}
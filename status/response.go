@@ -0,0 +1,59 @@
+package status
+
+import (
+	"net"
+
+	"github.com/gitfyu/mable/chat"
+	"github.com/google/uuid"
+)
+
+// Response contains the information displayed in a client's server list. It is sent to the client as JSON.
+type Response struct {
+	Version     Version   `json:"version"`
+	Players     Players   `json:"players"`
+	Description *chat.Msg `json:"description"`
+	// Favicon is the server icon as a data URI, which can be created using LoadFavicon. If it is empty, the client
+	// will display the default icon.
+	Favicon string `json:"favicon,omitempty"`
+}
+
+// Version describes the version of the server.
+type Version struct {
+	// Name is displayed to the client if its protocol version does not match Protocol.
+	Name     string `json:"name"`
+	Protocol int32  `json:"protocol"`
+}
+
+// Players contains the player counts, and optionally a sample of online players that is displayed when hovering over
+// the player count.
+type Players struct {
+	Max    int            `json:"max"`
+	Online int            `json:"online"`
+	Sample []PlayerSample `json:"sample,omitempty"`
+}
+
+// PlayerSample is a single entry in Players.Sample.
+type PlayerSample struct {
+	Name string    `json:"name"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// Request contains information about a client that is requesting the server status.
+type Request struct {
+	// Addr and Port are the server address and port that the client used to connect, which can be used to send a
+	// different response depending on the hostname.
+	Addr string
+	Port uint16
+	// ProtocolVersion is the protocol version of the client.
+	ProtocolVersion int32
+	// Legacy indicates that the request is a server list ping from a client older than 1.7. In this case
+	// ProtocolVersion, Addr and Port are unknown and the Favicon and Players.Sample of the response are not used.
+	Legacy bool
+	// RemoteAddr is the address of the client.
+	RemoteAddr net.Addr
+}
+
+// Handler is a function that can modify a Response before it is sent to a client. The Description and
+// Players.Sample of the Response may be shared, so they should be replaced instead of modified. A Handler may be called
+// concurrently.
+type Handler func(req *Request, resp *Response)
@@ -0,0 +1,22 @@
+/*
+Package worldfile implements Mable's compact world format, which stores an entire game.World in a single compressed
+file. It is intended for small maps such as lobbies, which load much faster than from Anvil region files since only
+the sections that exist in a game.Chunk are stored, in the same format that game.Chunk uses in memory.
+
+A file starts with a header containing the magic string "MBLW", the format version and the compression type, which is
+always gzip. The compressed data contains the spawn and settings of the World, followed by its chunks:
+	spawn           3 float64
+	dimension       int8
+	difficulty      uint8
+	level type      uint8 length + string
+	chunk count     uint32
+	chunks          chunk count times:
+		x, z            2 int32
+		section mask    uint16
+		sections        4096 uint16 blocks for each bit in the section mask, see game.Chunk.SectionData
+		has biomes      uint8, 0 or 1
+		biomes          256 bytes if has biomes is 1
+		block entities  uint16 count, followed by the x, y and z uint8 coordinates and an NBT compound for each
+All values are big-endian, except for the blocks of a section. Anvil worlds can be converted using "mable convert".
+*/
+package worldfile
@@ -0,0 +1,299 @@
+package worldfile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/internal/atomicfile"
+	"github.com/gitfyu/mable/nbt"
+)
+
+// Extension is the file extension that is used for files in this format.
+const Extension = ".mable"
+
+const (
+	// version is the version of the format, which is incremented whenever it changes in an incompatible way.
+	version = 1
+	// compressionGzip indicates that the data following the header is compressed using gzip.
+	compressionGzip = 1
+
+	sectionsPerChunk = 16
+	sectionDataSize  = 16 * 16 * 16 * 2
+	biomeCount       = 16 * 16
+)
+
+var magic = [4]byte{'M', 'B', 'L', 'W'}
+
+var errBadMagic = errors.New("not a mable world file")
+
+// Load loads the World stored in the file at path.
+func Load(path string) (*game.World, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(bufio.NewReader(f))
+}
+
+// Save writes a snapshot of a World to the file at path, replacing it if it exists. The file is only replaced once the
+// snapshot has been written successfully. Since the snapshot does not share data with the World, Save can be called
+// from any goroutine.
+func Save(path string, s *game.WorldSnapshot) error {
+	return atomicfile.Write(path, func(w io.Writer) error {
+		return Write(w, s)
+	})
+}
+
+// Write writes a snapshot of a World to w. Chunks are written in order of their position, so the output only depends
+// on the contents of the snapshot.
+func Write(w io.Writer, s *game.WorldSnapshot) error {
+	if _, err := w.Write([]byte{magic[0], magic[1], magic[2], magic[3], version, compressionGzip}); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	e := encoder{w: bufio.NewWriter(zw)}
+	e.writeSpawnAndSettings(s.Spawn, s.Settings)
+
+	positions := make([]game.ChunkPos, 0, len(s.Chunks))
+	for pos := range s.Chunks {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		return a.X < b.X || a.X == b.X && a.Z < b.Z
+	})
+
+	e.write(uint32(len(positions)))
+	for _, pos := range positions {
+		e.writeChunk(pos, s.Chunks[pos])
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.w.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read reads a World from r.
+func Read(r io.Reader) (*game.World, error) {
+	var header [6]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != magic[0] || header[1] != magic[1] || header[2] != magic[2] || header[3] != magic[3] {
+		return nil, errBadMagic
+	}
+	if header[4] != version {
+		return nil, fmt.Errorf("unsupported version %d", header[4])
+	}
+	if header[5] != compressionGzip {
+		return nil, fmt.Errorf("unknown compression type %d", header[5])
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	d := decoder{r: bufio.NewReader(zr)}
+
+	spawn, settings := d.readSpawnAndSettings()
+	var count uint32
+	d.read(&count)
+
+	chunks := make(map[game.ChunkPos]*game.Chunk)
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		pos, c := d.readChunk()
+		if _, ok := chunks[pos]; ok && d.err == nil {
+			d.err = fmt.Errorf("duplicate chunk %d,%d", pos.X, pos.Z)
+		}
+		chunks[pos] = c
+	}
+	// reading until the end of the stream verifies the gzip checksum
+	if d.err == nil {
+		if _, err := d.r.ReadByte(); err == nil {
+			d.err = errors.New("unexpected data after the last chunk")
+		} else if err != io.EOF {
+			d.err = err
+		}
+	}
+	if d.err != nil {
+		if d.err == io.EOF {
+			d.err = io.ErrUnexpectedEOF
+		}
+		return nil, d.err
+	}
+
+	w := game.NewWorld(chunks)
+	w.SetSpawn(spawn)
+	w.SetSettings(settings)
+	return w, nil
+}
+
+// encoder writes values to a bufio.Writer. The first error is stored in err, after which all writes are ignored.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) write(v interface{}) {
+	if e.err == nil {
+		e.err = binary.Write(e.w, binary.BigEndian, v)
+	}
+}
+
+func (e *encoder) writeBytes(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *encoder) writeSpawnAndSettings(spawn game.Pos, settings game.WorldSettings) {
+	e.write([]float64{spawn.X, spawn.Y, spawn.Z})
+	e.write(int8(settings.Dimension))
+	e.write(uint8(settings.Difficulty))
+
+	levelType := string(settings.LevelType)
+	if len(levelType) > math.MaxUint8 {
+		e.err = errors.New("level type is too long")
+		return
+	}
+	e.write(uint8(len(levelType)))
+	e.writeBytes([]byte(levelType))
+}
+
+func (e *encoder) writeChunk(pos game.ChunkPos, c *game.Chunk) {
+	e.write(pos.X)
+	e.write(pos.Z)
+
+	var mask uint16
+	for i := uint8(0); i < sectionsPerChunk; i++ {
+		if c.HasSection(i) {
+			mask |= 1 << i
+		}
+	}
+	e.write(mask)
+	for i := uint8(0); i < sectionsPerChunk; i++ {
+		if data := c.SectionData(i); data != nil {
+			e.writeBytes(data)
+		}
+	}
+
+	biomes := make([]byte, biomeCount)
+	hasBiomes := false
+	for i := range biomes {
+		biomes[i] = byte(c.Biome(uint8(i&15), uint8(i>>4)))
+		hasBiomes = hasBiomes || biomes[i] != byte(biome.Plains)
+	}
+	if hasBiomes {
+		e.write(uint8(1))
+		e.writeBytes(biomes)
+	} else {
+		e.write(uint8(0))
+	}
+
+	entities := c.BlockEntities()
+	if len(entities) > math.MaxUint16 {
+		e.err = fmt.Errorf("chunk %d,%d contains too many block entities", pos.X, pos.Z)
+		return
+	}
+	e.write(uint16(len(entities)))
+	for _, be := range entities {
+		e.writeBytes([]byte{be.X, be.Y, be.Z})
+		if e.err == nil {
+			e.err = nbt.Write(e.w, "", be.Data)
+		}
+	}
+}
+
+// decoder reads values from a bufio.Reader. The first error is stored in err, after which all reads are ignored.
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) read(v interface{}) {
+	if d.err == nil {
+		d.err = binary.Read(d.r, binary.BigEndian, v)
+	}
+}
+
+func (d *decoder) readBytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return b
+}
+
+func (d *decoder) readSpawnAndSettings() (game.Pos, game.WorldSettings) {
+	var spawn [3]float64
+	var dimension int8
+	var difficulty, levelTypeLen uint8
+	d.read(&spawn)
+	d.read(&dimension)
+	d.read(&difficulty)
+	d.read(&levelTypeLen)
+	levelType := d.readBytes(int(levelTypeLen))
+
+	return game.Pos{X: spawn[0], Y: spawn[1], Z: spawn[2]}, game.WorldSettings{
+		Dimension:  game.Dimension(dimension),
+		Difficulty: game.Difficulty(difficulty),
+		LevelType:  game.LevelType(levelType),
+	}
+}
+
+func (d *decoder) readChunk() (game.ChunkPos, *game.Chunk) {
+	var pos game.ChunkPos
+	var mask uint16
+	d.read(&pos.X)
+	d.read(&pos.Z)
+	d.read(&mask)
+
+	c := game.NewChunk()
+	for i := uint8(0); i < sectionsPerChunk && d.err == nil; i++ {
+		if mask&(1<<i) != 0 {
+			if data := d.readBytes(sectionDataSize); d.err == nil {
+				c.SetSectionData(i, data)
+			}
+		}
+	}
+
+	var hasBiomes uint8
+	d.read(&hasBiomes)
+	if hasBiomes != 0 {
+		biomes := d.readBytes(biomeCount)
+		for i := 0; i < biomeCount && d.err == nil; i++ {
+			c.SetBiome(uint8(i&15), uint8(i>>4), biome.ID(biomes[i]))
+		}
+	}
+
+	var entityCount uint16
+	d.read(&entityCount)
+	for i := uint16(0); i < entityCount && d.err == nil; i++ {
+		xyz := d.readBytes(3)
+		if d.err != nil {
+			break
+		}
+		var data nbt.Compound
+		if _, data, d.err = nbt.Read(d.r); d.err == nil {
+			c.SetBlockEntity(xyz[0], xyz[1], xyz[2], data)
+		}
+	}
+	return pos, c
+}
@@ -0,0 +1,98 @@
+package worldfile
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+	"github.com/gitfyu/mable/nbt"
+)
+
+func TestWriteRead(t *testing.T) {
+	c := game.NewChunk()
+	c.SetBlock(1, 2, 3, block.Stone.ToData())
+	c.SetBlock(4, 200, 5, block.Stone.ToDataWithMetadata(3))
+	c.SetBiome(7, 8, 2)
+	c.SetBlockEntity(4, 200, 5, nbt.Compound{"id": "Chest"})
+
+	w := game.NewWorld(map[game.ChunkPos]*game.Chunk{
+		{X: -3, Z: 7}: c,
+		{X: 0, Z: 0}:  game.NewChunk(),
+	})
+	w.SetSpawn(game.Pos{X: 1.5, Y: 70, Z: -2.5})
+	w.SetSettings(game.WorldSettings{
+		Dimension:  game.DimensionEnd,
+		Difficulty: game.DifficultyHard,
+		LevelType:  game.LevelTypeAmplified,
+	})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Spawn() != w.Spawn() {
+		t.Errorf("Expected spawn %v, got %v", w.Spawn(), got.Spawn())
+	}
+	if got.Settings() != w.Settings() {
+		t.Errorf("Expected settings %v, got %v", w.Settings(), got.Settings())
+	}
+	if got.GetChunk(game.ChunkPos{X: 0, Z: 0}) == nil {
+		t.Error("Expected the empty chunk to be stored")
+	}
+
+	gc := got.GetChunk(game.ChunkPos{X: -3, Z: 7})
+	if gc == nil {
+		t.Fatal("Expected chunk -3,7 to be stored")
+	}
+	for _, pos := range [][3]uint8{{1, 2, 3}, {4, 200, 5}, {0, 0, 0}} {
+		if want, b := c.Block(pos[0], pos[1], pos[2]), gc.Block(pos[0], pos[1], pos[2]); b != want {
+			t.Errorf("Block at %v: expected %v, got %v", pos, want, b)
+		}
+	}
+	if gc.HasSection(5) {
+		t.Error("Expected only existing sections to be stored")
+	}
+	if b := gc.Biome(7, 8); b != 2 {
+		t.Errorf("Expected biome 2, got %v", b)
+	}
+	if e := gc.BlockEntity(4, 200, 5); e["id"] != "Chest" {
+		t.Errorf("Expected the chest block entity, got %v", e)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("MBLX\x01\x01"))); err != errBadMagic {
+		t.Errorf("Expected errBadMagic, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, game.NewWorld(map[game.ChunkPos]*game.Chunk{{}: game.NewChunk()}).Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(bytes.NewReader(buf.Bytes()[:buf.Len()-10])); err == nil {
+		t.Error("Expected error for truncated file")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lobby"+Extension)
+	w := game.NewWorld(map[game.ChunkPos]*game.Chunk{{X: 1, Z: 1}: game.NewChunk()})
+	if err := Save(path, w.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetChunk(game.ChunkPos{X: 1, Z: 1}) == nil {
+		t.Error("Expected chunk 1,1 to be loaded")
+	}
+}
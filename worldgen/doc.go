@@ -0,0 +1,12 @@
+/*
+Package worldgen contains implementations of game.ChunkGenerator, which create the chunks of a game.World that do not
+exist yet:
+	gen, err := worldgen.ParseFlatPreset(worldgen.DefaultFlatPreset)
+	if err != nil {
+		// ...
+	}
+	w.SetGenerator(gen, 0)
+Flat generates the same layers in every chunk, Void generates empty chunks and Platform generates a single platform.
+Terrain generates hills, oceans, caves and basic biomes using noise, which only depends on its seed.
+*/
+package worldgen
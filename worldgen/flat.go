@@ -0,0 +1,154 @@
+package worldgen
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+)
+
+// DefaultFlatPreset is the preset of the classic flat world in vanilla, which contains a layer of bedrock, two layers
+// of dirt and a layer of grass.
+const DefaultFlatPreset = "3;minecraft:bedrock,2*minecraft:dirt,minecraft:grass;1;village"
+
+// maxHeight is the number of blocks in a column of a chunk.
+const maxHeight = 256
+
+// Layer is a layer of blocks in a flat world.
+type Layer struct {
+	Block block.Data
+	// Height is the number of blocks in the layer.
+	Height int
+}
+
+// Flat generates chunks that contain the same layers of blocks, like the superflat worlds in vanilla.
+type Flat struct {
+	// template is the chunk that is copied for every generated chunk.
+	template *game.Chunk
+	height   int
+}
+
+// NewFlat constructs a Flat generator. The first layer starts at Y=0 and every column uses the specified biome. It
+// returns an error if the layers are higher than the world.
+func NewFlat(layers []Layer, b biome.ID) (*Flat, error) {
+	c := game.NewChunk()
+	y := 0
+	for _, l := range layers {
+		if l.Height < 0 || y+l.Height > maxHeight {
+			return nil, fmt.Errorf("layers must not be higher than %d blocks", maxHeight)
+		}
+		if l.Block.Type() != block.Air {
+			for i := y; i < y+l.Height; i++ {
+				fillLayer(c, uint8(i), l.Block)
+			}
+		}
+		y += l.Height
+	}
+
+	if b != biome.Plains {
+		for x := uint8(0); x < 16; x++ {
+			for z := uint8(0); z < 16; z++ {
+				c.SetBiome(x, z, b)
+			}
+		}
+	}
+	return &Flat{
+		template: c,
+		height:   y,
+	}, nil
+}
+
+// ParseFlatPreset constructs a Flat generator from a superflat preset string, such as DefaultFlatPreset. It supports
+// version 2 of the format, which is used by 1.7, and version 3, which is used by 1.8 to 1.12. Blocks can be specified
+// using their numeric ID or using a name that block.FromName knows, optionally followed by a colon and the metadata.
+// Structures are ignored.
+func ParseFlatPreset(preset string) (*Flat, error) {
+	parts := strings.Split(preset, ";")
+	if len(parts) < 2 {
+		return nil, errors.New("expected version;layers[;biome[;structures]]")
+	}
+
+	var countSep string
+	switch parts[0] {
+	case "2":
+		countSep = "x"
+	case "3":
+		countSep = "*"
+	default:
+		return nil, fmt.Errorf("unsupported preset version %q", parts[0])
+	}
+
+	var layers []Layer
+	for _, s := range strings.Split(parts[1], ",") {
+		l, err := parseLayer(strings.TrimSpace(s), countSep)
+		if err != nil {
+			return nil, fmt.Errorf("layer %q: %w", s, err)
+		}
+		layers = append(layers, l)
+	}
+
+	b := biome.Plains
+	if len(parts) > 2 && parts[2] != "" {
+		id, err := strconv.ParseUint(parts[2], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid biome %q", parts[2])
+		}
+		b = biome.ID(id)
+	}
+	return NewFlat(layers, b)
+}
+
+// parseLayer parses a single layer of a preset, which has the format [count<countSep>]block[:metadata].
+func parseLayer(s, countSep string) (Layer, error) {
+	l := Layer{Height: 1}
+	if i := strings.Index(s, countSep); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n < 1 {
+			return l, errors.New("invalid count")
+		}
+		l.Height = n
+		s = s[i+len(countSep):]
+	}
+
+	var meta uint8
+	fields := strings.Split(s, ":")
+	if n := len(fields); n > 1 {
+		if m, err := strconv.ParseUint(fields[n-1], 10, 4); err == nil {
+			meta = uint8(m)
+			s = strings.Join(fields[:n-1], ":")
+		}
+	}
+
+	if id, err := strconv.ParseUint(s, 10, 12); err == nil {
+		l.Block = block.ID(id).ToDataWithMetadata(meta)
+		return l, nil
+	}
+	id, ok := block.FromName(s)
+	if !ok {
+		return l, fmt.Errorf("unknown block %q", s)
+	}
+	l.Block = id.ToDataWithMetadata(meta)
+	return l, nil
+}
+
+// Height returns the total height of the layers, which is the Y coordinate at which players can stand on top of them.
+func (f *Flat) Height() int {
+	return f.height
+}
+
+func (f *Flat) GenerateChunk(game.ChunkPos) *game.Chunk {
+	return f.template.Clone()
+}
+
+// fillLayer sets all blocks at the specified Y coordinate in c.
+func fillLayer(c *game.Chunk, y uint8, b block.Data) {
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			c.SetBlock(x, y, z, b)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package worldgen
+
+import "math"
+
+// The noise functions only use explicitly rounded floating-point operations, since the compiler may otherwise fuse a
+// multiplication and an addition on some architectures, which would make the generated terrain depend on the
+// architecture. An explicit conversion to float64 prevents this.
+
+// splitMix is the SplitMix64 random number generator. It is used instead of math/rand, so the output for a seed is
+// fully defined by this package.
+type splitMix uint64
+
+func (s *splitMix) next() uint64 {
+	*s += 0x9E3779B97F4A7C15
+	z := uint64(*s)
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	return z ^ z>>31
+}
+
+// perlin is Ken Perlin's improved noise, using a permutation that is shuffled using a random number generator.
+type perlin struct {
+	// p contains the permutation twice, so indices up to 511 do not have to be wrapped.
+	p [512]uint8
+}
+
+func newPerlin(r *splitMix) *perlin {
+	n := &perlin{}
+	for i := 0; i < 256; i++ {
+		n.p[i] = uint8(i)
+	}
+	for i := 255; i > 0; i-- {
+		j := r.next() % uint64(i+1)
+		n.p[i], n.p[j] = n.p[j], n.p[i]
+	}
+	copy(n.p[256:], n.p[:256])
+	return n
+}
+
+// noise returns the noise value at the specified coordinates, which is between -1 and 1. It is 0 at every integer
+// coordinate.
+func (n *perlin) noise(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int(fx)&255, int(fy)&255, int(fz)&255
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	p := &n.p
+	a := int(p[xi]) + yi
+	aa, ab := int(p[a])+zi, int(p[a+1])+zi
+	b := int(p[xi+1]) + yi
+	ba, bb := int(p[b])+zi, int(p[b+1])+zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(p[aa], x, y, z), grad(p[ba], x-1, y, z)),
+			lerp(u, grad(p[ab], x, y-1, z), grad(p[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(p[aa+1], x, y, z-1), grad(p[ba+1], x-1, y, z-1)),
+			lerp(u, grad(p[ab+1], x, y-1, z-1), grad(p[bb+1], x-1, y-1, z-1))))
+}
+
+// fade is the curve 6t^5-15t^4+10t^3, which smooths the interpolation between the corners of a cell.
+func fade(t float64) float64 {
+	a := float64(t*6) - 15
+	b := float64(t*a) + 10
+	return t * t * t * b
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + float64(t*(b-a))
+}
+
+// grad returns the dot product of x, y, z and one of the 12 gradient vectors, which is selected by hash.
+func grad(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// octaves adds multiple layers of noise, of which each layer has twice the frequency and half the amplitude of the
+// previous layer. This adds detail to the large shapes of the first layer.
+type octaves []*perlin
+
+func newOctaves(r *splitMix, count int) octaves {
+	o := make(octaves, count)
+	for i := range o {
+		o[i] = newPerlin(r)
+	}
+	return o
+}
+
+// noise returns the sum of the layers at the specified coordinates, scaled to be between -1 and 1.
+func (o octaves) noise(x, y, z float64) float64 {
+	var sum, total float64
+	amplitude, frequency := 1.0, 1.0
+	for _, n := range o {
+		v := n.noise(float64(x*frequency), float64(y*frequency), float64(z*frequency))
+		sum += float64(v * amplitude)
+		total += amplitude
+		amplitude /= 2
+		frequency *= 2
+	}
+	return sum / total
+}
@@ -0,0 +1,60 @@
+package worldgen
+
+import (
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+)
+
+// Void generates empty chunks. Unlike chunks that do not exist, empty chunks are sent to players, so they can move
+// through them.
+type Void struct{}
+
+func (Void) GenerateChunk(game.ChunkPos) *game.Chunk {
+	return game.NewChunk()
+}
+
+// Platform generates a single horizontal platform of blocks, surrounded by empty chunks.
+type Platform struct {
+	Block block.Data
+	Y     uint8
+	// MinX, MinZ, MaxX and MaxZ are the world coordinates of the corners of the platform, which are inclusive.
+	MinX, MinZ, MaxX, MaxZ int32
+}
+
+// NewPlatform constructs a Platform of which the center is at 0,0 and which extends radius blocks in each direction,
+// so it is 2*radius+1 blocks wide.
+func NewPlatform(b block.Data, y uint8, radius int32) *Platform {
+	return &Platform{
+		Block: b,
+		Y:     y,
+		MinX:  -radius,
+		MinZ:  -radius,
+		MaxX:  radius,
+		MaxZ:  radius,
+	}
+}
+
+func (p *Platform) GenerateChunk(pos game.ChunkPos) *game.Chunk {
+	c := game.NewChunk()
+	minX, maxX := clampToChunk(pos.X, p.MinX, p.MaxX)
+	minZ, maxZ := clampToChunk(pos.Z, p.MinZ, p.MaxZ)
+	for x := minX; x <= maxX; x++ {
+		for z := minZ; z <= maxZ; z++ {
+			c.SetBlock(uint8(x&15), p.Y, uint8(z&15), p.Block)
+		}
+	}
+	return c
+}
+
+// clampToChunk returns the part of the range [min,max] of world coordinates that is within the chunk at the specified
+// chunk coordinate. If they do not overlap, the returned minimum is larger than the maximum.
+func clampToChunk(chunk, min, max int32) (int32, int32) {
+	start, end := chunk<<4, chunk<<4|15
+	if min < start {
+		min = start
+	}
+	if max > end {
+		max = end
+	}
+	return min, max
+}
@@ -0,0 +1,174 @@
+package worldgen
+
+import (
+	"math"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+)
+
+const (
+	// SeaLevel is the highest Y coordinate that Terrain fills with water.
+	SeaLevel = 62
+	// lavaLevel is the highest Y coordinate at which caves are filled with lava instead of air.
+	lavaLevel = 10
+	// mountainThreshold is the roughness above which hills turn into mountains.
+	mountainThreshold = 0.15
+	// snowLine is the height above which mountains are not covered by grass.
+	snowLine = 95
+	// caveSize is the maximum squared distance from the center of a cave tunnel in noise space. Larger values result
+	// in wider and more frequent tunnels.
+	caveSize = 0.005
+)
+
+// Terrain generates natural terrain using Perlin noise, with hills, mountains, oceans and caves. The terrain is fully
+// defined by the seed, so generating the same chunk twice results in the same blocks, even on other machines.
+//
+// The biome of a column is based on its height and its temperature, which is another noise value. Columns below the sea
+// are oceans, surrounded by beaches. Above that, the terrain is divided into plains, deserts and ice plains, and rough
+// areas become extreme hills. Decorations such as trees and ores are not generated.
+type Terrain struct {
+	// continents determines whether an area is land or ocean, hills adds the smaller hills on top of that and
+	// roughness determines how high those hills are.
+	continents, hills, roughness octaves
+	temperature                  octaves
+	// a block is part of a cave if both cave noise values are close to 0, which results in long tunnels where the
+	// surfaces of the values intersect.
+	caveA, caveB octaves
+}
+
+// NewTerrain constructs a Terrain generator using the specified seed.
+func NewTerrain(seed int64) *Terrain {
+	r := splitMix(seed)
+	return &Terrain{
+		continents:  newOctaves(&r, 4),
+		hills:       newOctaves(&r, 4),
+		roughness:   newOctaves(&r, 2),
+		temperature: newOctaves(&r, 2),
+		caveA:       newOctaves(&r, 2),
+		caveB:       newOctaves(&r, 2),
+	}
+}
+
+// Height returns the Y coordinate of the highest solid block in the column at the specified world coordinates. Water
+// and snow are not included, so it is lower than SeaLevel in oceans.
+func (t *Terrain) Height(x, z int32) int {
+	h, _ := t.column(x, z)
+	return h
+}
+
+// column returns the height and the roughness of a column.
+func (t *Terrain) column(x, z int32) (int, float64) {
+	fx, fz := float64(x), float64(z)
+	continent := t.continents.noise(fx/512, 0, fz/512)
+	hills := t.hills.noise(fx/128, 0, fz/128)
+	rough := t.roughness.noise(fx/256, 0, fz/256)
+
+	h := SeaLevel + 2 + continent*48 + hills*16
+	if rough > mountainThreshold {
+		// mountains raise the hills, so they do not create deep valleys
+		h += (rough - mountainThreshold) * 400 * (1 + hills)
+	}
+	height := int(math.Floor(h))
+	if height < 5 {
+		height = 5
+	} else if height > maxHeight-2 {
+		height = maxHeight - 2
+	}
+	return height, rough
+}
+
+// biome returns the biome of a column with the specified height and roughness.
+func (t *Terrain) biome(x, z int32, height int, rough float64) biome.ID {
+	temp := t.temperature.noise(float64(x)/384, 0, float64(z)/384)
+	cold := temp < -0.15
+	switch {
+	case height < SeaLevel-2 && cold:
+		return biome.FrozenOcean
+	case height < SeaLevel-2:
+		return biome.Ocean
+	case height <= SeaLevel+1 && cold:
+		return biome.ColdBeach
+	case height <= SeaLevel+1:
+		return biome.Beach
+	case rough > mountainThreshold:
+		return biome.ExtremeHills
+	case cold:
+		return biome.IcePlains
+	case temp > 0.15:
+		return biome.Desert
+	default:
+		return biome.Plains
+	}
+}
+
+func (t *Terrain) GenerateChunk(pos game.ChunkPos) *game.Chunk {
+	c := game.NewChunk()
+	for dx := uint8(0); dx < 16; dx++ {
+		for dz := uint8(0); dz < 16; dz++ {
+			x, z := pos.X<<4|int32(dx), pos.Z<<4|int32(dz)
+			height, rough := t.column(x, z)
+			b := t.biome(x, z, height, rough)
+			c.SetBiome(dx, dz, b)
+			t.fillColumn(c, dx, dz, height, b)
+			t.carveCaves(c, x, z, height)
+		}
+	}
+	return c
+}
+
+// fillColumn sets the blocks of a column: bedrock at the bottom, then stone, three blocks of filler and the surface
+// block, followed by water up to SeaLevel.
+func (t *Terrain) fillColumn(c *game.Chunk, x, z uint8, height int, b biome.ID) {
+	top, filler := block.Grass.ToData(), block.Dirt.ToData()
+	switch b {
+	case biome.Ocean, biome.FrozenOcean:
+		top, filler = block.Gravel.ToData(), block.Dirt.ToData()
+	case biome.Beach, biome.ColdBeach, biome.Desert:
+		top, filler = block.Sand.ToData(), block.Sand.ToData()
+	case biome.ExtremeHills:
+		if height > snowLine {
+			top, filler = block.Stone.ToData(), block.Stone.ToData()
+		}
+	}
+
+	c.SetBlock(x, 0, z, block.Bedrock.ToData())
+	for y := 1; y < height-3; y++ {
+		c.SetBlock(x, uint8(y), z, block.Stone.ToData())
+	}
+	for y := height - 3; y < height; y++ {
+		c.SetBlock(x, uint8(y), z, filler)
+	}
+	c.SetBlock(x, uint8(height), z, top)
+
+	for y := height + 1; y <= SeaLevel; y++ {
+		c.SetBlock(x, uint8(y), z, block.Water.ToData())
+	}
+	switch {
+	case b == biome.FrozenOcean || b == biome.ColdBeach && height < SeaLevel:
+		c.SetBlock(x, SeaLevel, z, block.Ice.ToData())
+	case b == biome.IcePlains || b == biome.ExtremeHills && height > snowLine:
+		c.SetBlock(x, uint8(height+1), z, block.SnowLayer.ToData())
+	}
+}
+
+// carveCaves replaces the stone of a column with air where the cave noise is close to 0. The blocks near the surface
+// are kept, so caves do not open into the sky or the sea. Caves below lavaLevel are filled with lava.
+func (t *Terrain) carveCaves(c *game.Chunk, x, z int32, height int) {
+	fx, fz := float64(x)/48, float64(z)/48
+	for y := 1; y < height-4; y++ {
+		fy := float64(y) / 24
+		a := t.caveA.noise(fx, fy, fz)
+		b := t.caveB.noise(fx, fy, fz)
+		if float64(a*a)+float64(b*b) >= caveSize {
+			continue
+		}
+
+		data := block.Data(0)
+		if y <= lavaLevel {
+			data = block.Lava.ToData()
+		}
+		c.SetBlock(uint8(x&15), uint8(y), uint8(z&15), data)
+	}
+}
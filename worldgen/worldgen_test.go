@@ -0,0 +1,163 @@
+package worldgen
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gitfyu/mable/biome"
+	"github.com/gitfyu/mable/block"
+	"github.com/gitfyu/mable/game"
+)
+
+func TestParseFlatPreset(t *testing.T) {
+	tests := []struct {
+		preset string
+		blocks []block.Data
+		biome  biome.ID
+	}{
+		{
+			DefaultFlatPreset,
+			[]block.Data{block.Bedrock.ToData(), block.Dirt.ToData(), block.Dirt.ToData(), block.Grass.ToData()},
+			biome.Plains,
+		},
+		{
+			"3;minecraft:stone,minecraft:wool:14,2*35:1;2",
+			[]block.Data{block.Stone.ToData(), block.Wool.ToDataWithMetadata(14), block.Wool.ToDataWithMetadata(1),
+				block.Wool.ToDataWithMetadata(1)},
+			2,
+		},
+		{
+			"2;7,2x3,2;1;",
+			[]block.Data{block.Bedrock.ToData(), block.Dirt.ToData(), block.Dirt.ToData(), block.Grass.ToData()},
+			biome.Plains,
+		},
+	}
+	for _, test := range tests {
+		f, err := ParseFlatPreset(test.preset)
+		if err != nil {
+			t.Errorf("%s: %v", test.preset, err)
+			continue
+		}
+		if f.Height() != len(test.blocks) {
+			t.Errorf("%s: expected height %d, got %d", test.preset, len(test.blocks), f.Height())
+		}
+
+		c := f.GenerateChunk(game.ChunkPos{X: 5, Z: -3})
+		for y, want := range test.blocks {
+			if b := c.Block(7, uint8(y), 9); b != want {
+				t.Errorf("%s: expected %v at y=%d, got %v", test.preset, want, y, b)
+			}
+		}
+		if b := c.Block(7, uint8(len(test.blocks)), 9); b != 0 {
+			t.Errorf("%s: expected air above the layers, got %v", test.preset, b)
+		}
+		if b := c.Biome(0, 15); b != test.biome {
+			t.Errorf("%s: expected biome %d, got %d", test.preset, test.biome, b)
+		}
+	}
+}
+
+func TestParseFlatPreset_Invalid(t *testing.T) {
+	for _, preset := range []string{
+		"",
+		"4;minecraft:stone",
+		"3;minecraft:unknown",
+		"3;0*minecraft:stone",
+		"3;300*minecraft:stone",
+		"3;minecraft:stone;biome",
+	} {
+		if _, err := ParseFlatPreset(preset); err == nil {
+			t.Errorf("Expected error for %q", preset)
+		}
+	}
+}
+
+func TestFlat_GenerateChunk(t *testing.T) {
+	f, err := NewFlat([]Layer{{Block: block.Stone.ToData(), Height: 1}}, biome.Plains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := f.GenerateChunk(game.ChunkPos{})
+	a.SetBlock(0, 0, 0, 0)
+	if b := f.GenerateChunk(game.ChunkPos{}).Block(0, 0, 0); b != block.Stone.ToData() {
+		t.Error("Expected generated chunks to not share blocks")
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	p := NewPlatform(block.Stone.ToData(), 64, 20)
+
+	c := p.GenerateChunk(game.ChunkPos{X: 1, Z: -2})
+	// the chunk contains x=16..31 and z=-32..-17, of which x=16..20 and z=-20..-17 are part of the platform
+	for x := uint8(0); x < 16; x++ {
+		for z := uint8(0); z < 16; z++ {
+			want := block.Data(0)
+			if x <= 4 && z >= 12 {
+				want = block.Stone.ToData()
+			}
+			if b := c.Block(x, 64, z); b != want {
+				t.Fatalf("Expected %v at %d,%d, got %v", want, x, z, b)
+			}
+		}
+	}
+
+	if c := p.GenerateChunk(game.ChunkPos{X: 2, Z: 0}); c.HasSection(4) {
+		t.Error("Expected an empty chunk outside the platform")
+	}
+}
+
+func TestTerrain_GenerateChunk(t *testing.T) {
+	tr := NewTerrain(42)
+	tests := []struct {
+		x, y, z int32
+		want    block.Data
+		biome   biome.ID
+	}{
+		{0, 0, 0, block.Bedrock.ToData(), biome.Plains},
+		{-128, 64, -128, block.Sand.ToData(), biome.Desert},
+		{-75, 64, -118, block.Grass.ToData(), biome.Plains},
+		{-75, 65, -118, 0, biome.Plains},
+		{-128, 71, -1, block.Grass.ToData(), biome.ExtremeHills},
+		{15, 65, -59, block.SnowLayer.ToData(), biome.IcePlains},
+		{10, 59, 26, block.Gravel.ToData(), biome.Ocean},
+		{10, SeaLevel, 26, block.Water.ToData(), biome.Ocean},
+		{5, SeaLevel, -128, block.Ice.ToData(), biome.FrozenOcean},
+		{-113, 63, -128, block.Sand.ToData(), biome.Beach},
+		// caves
+		{-125, 27, -125, 0, biome.Desert},
+		{-125, 3, 67, block.Lava.ToData(), biome.ExtremeHills},
+	}
+	for _, test := range tests {
+		c := tr.GenerateChunk(game.ChunkPos{X: test.x >> 4, Z: test.z >> 4})
+		x, z := uint8(test.x&15), uint8(test.z&15)
+		if b := c.Block(x, uint8(test.y), z); b != test.want {
+			t.Errorf("Expected %v at %d,%d,%d, got %v", test.want, test.x, test.y, test.z, b)
+		}
+		if b := c.Biome(x, z); b != test.biome {
+			t.Errorf("Expected biome %d at %d,%d, got %d", test.biome, test.x, test.z, b)
+		}
+	}
+
+	if h := tr.Height(-75, -118); h != 64 {
+		t.Errorf("Expected height 64, got %d", h)
+	}
+}
+
+func TestTerrain_Seed(t *testing.T) {
+	pos := game.ChunkPos{X: 3, Z: -2}
+	a := NewTerrain(1).GenerateChunk(pos)
+	b := NewTerrain(1).GenerateChunk(pos)
+	other := NewTerrain(2).GenerateChunk(pos)
+
+	same, differs := true, false
+	for i := uint8(0); i < 16; i++ {
+		same = same && bytes.Equal(a.SectionData(i), b.SectionData(i))
+		differs = differs || !bytes.Equal(a.SectionData(i), other.SectionData(i))
+	}
+	if !same {
+		t.Error("Expected the same chunk for the same seed")
+	}
+	if !differs {
+		t.Error("Expected a different chunk for another seed")
+	}
+}